@@ -1,13 +1,20 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	f2 "github.com/ayoisaiah/f2/src"
 )
 
 func run(args []string) error {
-	return f2.GetApp().Run(args)
+	args, err := f2.ExpandAlias(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	return f2.Run(args)
 }
 
 func main() {
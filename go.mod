@@ -3,7 +3,8 @@ module github.com/ayoisaiah/f2
 go 1.16
 
 require (
-	github.com/barasher/go-exiftool v1.5.0 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	github.com/barasher/go-exiftool v1.5.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dhowden/tag v0.0.0-20201120070457-d52dcb253c63
 	github.com/google/go-cmp v0.5.4
@@ -11,6 +12,7 @@ require (
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/rwcarlsen/goexif v0.0.0-20190401172101-9e8deecbddbd
 	github.com/urfave/cli/v2 v2.2.0
+	github.com/yuin/gopher-lua v1.1.1
 	golang.org/x/sys v0.0.0-20210414055047-fe65e336abe0 // indirect
 	golang.org/x/text v0.3.6
 	gopkg.in/djherbis/times.v1 v1.2.0
@@ -0,0 +1,58 @@
+package f2
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gopkg.in/djherbis/times.v1"
+)
+
+// defaultBucketYears is the number of years after which files are placed
+// in the `older` bucket when `{{agebucket}}` does not specify a limit
+const defaultBucketYears = 2
+
+var ageBucketRegex = regexp.MustCompile(`{{agebucket(?:\.(\d+))?}}`)
+
+// ageBucket returns the retention bucket for a file modified at the
+// specified time: `this-week` and `this-month` for files modified within
+// the last 7 and 30 days, the year and quarter (e.g. `2021-Q3`) for files
+// modified within the last number of years and `older` for the rest
+func ageBucket(mtime, now time.Time, years int) string {
+	age := now.Sub(mtime)
+
+	switch {
+	case age < 7*24*time.Hour:
+		return "this-week"
+	case age < 30*24*time.Hour:
+		return "this-month"
+	case mtime.After(now.AddDate(-years, 0, 0)):
+		return fmt.Sprintf("%d-Q%d", mtime.Year(), (int(mtime.Month())+2)/3)
+	}
+
+	return "older"
+}
+
+// replaceAgeBucketVariables replaces `{{agebucket}}` with the retention
+// bucket of the file based on its modification time. The number of years
+// before a file is considered `older` may be specified (e.g.
+// `{{agebucket.5}}`)
+func replaceAgeBucketVariables(
+	input, sourcePath string,
+	now time.Time,
+) (string, error) {
+	t, err := times.Stat(sourcePath)
+	if err != nil {
+		return "", err
+	}
+
+	return ageBucketRegex.ReplaceAllStringFunc(input, func(token string) string {
+		years := defaultBucketYears
+		if n := ageBucketRegex.FindStringSubmatch(token)[1]; n != "" {
+			years, _ = strconv.Atoi(n)
+		}
+
+		return ageBucket(t.ModTime(), now, years)
+	}), nil
+}
@@ -0,0 +1,78 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAgeBucket(t *testing.T) {
+	now := time.Date(2021, 11, 20, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		mtime time.Time
+		years int
+		want  string
+	}{
+		{mtime: now.Add(-time.Hour), years: 2, want: "this-week"},
+		{mtime: now.AddDate(0, 0, -10), years: 2, want: "this-month"},
+		{mtime: time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC), years: 2, want: "2021-Q3"},
+		{mtime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), years: 2, want: "2020-Q1"},
+		{mtime: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), years: 2, want: "older"},
+		{mtime: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), years: 5, want: "2019-Q2"},
+	}
+
+	for _, tc := range cases {
+		got := ageBucket(tc.mtime, now, tc.years)
+		if got != tc.want {
+			t.Fatalf("Expected %s for %s, but got %s", tc.want, tc.mtime, got)
+		}
+	}
+}
+
+func TestAgeBucketVariable(t *testing.T) {
+	testDir := setupFiles(t, []string{"recent.log", "old.log"})
+
+	now := time.Date(2021, 11, 20, 12, 0, 0, 0, time.UTC)
+
+	mtimes := map[string]time.Time{
+		"recent.log": now.AddDate(0, 0, -2),
+		"old.log":    time.Date(2021, 2, 14, 0, 0, 0, 0, time.UTC),
+	}
+
+	for name, mtime := range mtimes {
+		err := os.Chtimes(filepath.Join(testDir, name), mtime, mtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	op, err := New(Options{
+		Paths:   []string{testDir},
+		Find:    []string{`.*`},
+		Replace: []string{"{{agebucket}}/{{f}}{{ext}}"},
+		Now: func() time.Time {
+			return now
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := op.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"recent.log": filepath.Join("this-week", "recent.log"),
+		"old.log":    filepath.Join("2021-Q1", "old.log"),
+	}
+
+	for _, ch := range changes {
+		if ch.Target != want[ch.Source] {
+			t.Fatalf("Expected %s, but got %s", want[ch.Source], ch.Target)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package f2
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// argRegex matches the placeholders in an alias that are
+// filled in with the positional arguments (e.g. `{{arg.1}}`)
+var argRegex = regexp.MustCompile(`{{arg\.(\d+)}}`)
+
+// expandAlias replaces the alias with its arguments. The placeholders in
+// the alias are replaced with the arguments that follow it up to the first
+// flag and the arguments that are not used by a placeholder are appended
+func expandAlias(name string, alias, args []string) ([]string, error) {
+	positional := args
+	for i, v := range args {
+		if strings.HasPrefix(v, "-") {
+			positional = args[:i]
+			break
+		}
+	}
+
+	used := make(map[int]bool)
+
+	expanded := make([]string, 0, len(alias)+len(args))
+	for _, v := range alias {
+		var missing int
+		v = argRegex.ReplaceAllStringFunc(v, func(p string) string {
+			n, _ := strconv.Atoi(argRegex.FindStringSubmatch(p)[1])
+			if n < 1 || n > len(positional) {
+				missing = n
+				return p
+			}
+
+			used[n] = true
+
+			return positional[n-1]
+		})
+
+		if missing != 0 {
+			return nil, fmt.Errorf(
+				"Missing argument %d for alias '%s'",
+				missing,
+				name,
+			)
+		}
+
+		expanded = append(expanded, v)
+	}
+
+	for i, v := range args {
+		if !used[i+1] {
+			expanded = append(expanded, v)
+		}
+	}
+
+	return expanded, nil
+}
+
+// ExpandAlias replaces the first argument with the arguments of the alias
+// of the same name defined in the alias table of the configuration (e.g.
+// `photos = ["-f", "IMG_(\\d+)", "-r", "{{arg.1}}_$1", "-R"]`) so that
+// `f2 photos holiday` runs `f2 -f 'IMG_(\d+)' -r 'holiday_$1' -R`. The
+// built-in commands cannot be redefined
+func ExpandAlias(args []string) ([]string, error) {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		return args, nil
+	}
+
+	for _, c := range GetApp().Commands {
+		if c.HasName(args[1]) {
+			return args, nil
+		}
+	}
+
+	config, err := readConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	alias, ok := config[aliasSection+"."+args[1]]
+	if !ok {
+		return args, nil
+	}
+
+	if len(alias) == 1 {
+		alias = strings.Fields(alias[0])
+	}
+
+	expanded, err := expandAlias(args[1], alias, args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{args[0]}, expanded...), nil
+}
@@ -0,0 +1,131 @@
+package f2
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandAlias(t *testing.T) {
+	alias := []string{"-f", "IMG_(\\d+)", "-r", "{{arg.1}}_$1", "-R"}
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"holiday"},
+			want: []string{"-f", "IMG_(\\d+)", "-r", "holiday_$1", "-R"},
+		},
+		{
+			args: []string{"holiday", "photos", "-x"},
+			want: []string{"-f", "IMG_(\\d+)", "-r", "holiday_$1", "-R", "photos", "-x"},
+		},
+		{
+			args: []string{"holiday", "-H", "extra"},
+			want: []string{"-f", "IMG_(\\d+)", "-r", "holiday_$1", "-R", "-H", "extra"},
+		},
+	}
+
+	for _, tc := range cases {
+		got, err := expandAlias("photos", alias, tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !cmp.Equal(tc.want, got) {
+			t.Fatalf("Expected: %v, but got: %v", tc.want, got)
+		}
+	}
+
+	if _, err := expandAlias("photos", alias, []string{"-x"}); err == nil {
+		t.Fatal("Expected an error for a missing argument")
+	}
+}
+
+func TestExpandAliasConfig(t *testing.T) {
+	configHome := setConfigHome(t)
+
+	config := `
+[alias]
+photos = ["-f", "IMG_(\\d+)", "-r", "{{arg.1}}_$1", "-R"]
+clean = "-f \\s+ -r _"
+again = ["photos", "{{arg.1}}"]
+loop = ["loop", "-x"]
+history = ["-f", "a"]
+`
+
+	err := ioutil.WriteFile(filepath.Join(configHome, "f2", configFileName), []byte(config), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		args []string
+		want []string
+		err  bool
+	}{
+		{
+			name: "Expand an alias with a placeholder",
+			args: []string{"f2", "photos", "holiday", "-x"},
+			want: []string{"f2", "-f", "IMG_(\\d+)", "-r", "holiday_$1", "-R", "-x"},
+		},
+		{
+			name: "Split an alias that is a single string at whitespace",
+			args: []string{"f2", "clean", "-x"},
+			want: []string{"f2", "-f", "\\s+", "-r", "_", "-x"},
+		},
+		{
+			name: "Do not expand an alias within another alias",
+			args: []string{"f2", "again", "trip"},
+			want: []string{"f2", "photos", "trip"},
+		},
+		{
+			name: "Expand an alias that refers to itself once",
+			args: []string{"f2", "loop"},
+			want: []string{"f2", "loop", "-x"},
+		},
+		{
+			name: "Leave unknown aliases as paths",
+			args: []string{"f2", "unknown", "-f", "a"},
+			want: []string{"f2", "unknown", "-f", "a"},
+		},
+		{
+			name: "Do not redefine the built-in commands",
+			args: []string{"f2", "history"},
+			want: []string{"f2", "history"},
+		},
+		{
+			name: "Leave the arguments unchanged when they start with a flag",
+			args: []string{"f2", "-f", "photos"},
+			want: []string{"f2", "-f", "photos"},
+		},
+		{
+			name: "Report a missing placeholder argument",
+			args: []string{"f2", "photos", "-x"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		got, err := ExpandAlias(tc.args)
+		if tc.err {
+			if err == nil {
+				t.Fatalf("%s: expected an error", tc.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		if !cmp.Equal(tc.want, got) {
+			t.Fatalf("%s: expected: %v, but got: %v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package f2
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	anchorStart = "start"
+	anchorEnd   = "end"
+	anchorBoth  = "both"
+)
+
+var (
+	errAnchorWithFind = errors.New(
+		"Invalid argument: --starts-with and --ends-with cannot be combined with --find",
+	)
+
+	errInvalidAnchor = errors.New(
+		"Invalid argument: --anchor must be set to 'start', 'end' or 'both'",
+	)
+)
+
+// wrapPattern restricts the find pattern to whole words and/or anchors it
+// to the start or end of the file name so that `\b`, `^` and `$` do not
+// need to be written by hand
+func wrapPattern(findPattern string, wholeWord bool, anchor string) string {
+	if wholeWord {
+		findPattern = `\b(?:` + findPattern + `)\b`
+	}
+
+	switch anchor {
+	case anchorStart:
+		findPattern = "^(?:" + findPattern + ")"
+	case anchorEnd:
+		findPattern = "(?:" + findPattern + ")$"
+	case anchorBoth:
+		findPattern = "^(?:" + findPattern + ")$"
+	}
+
+	return findPattern
+}
+
+// anchoredPattern returns a find pattern that matches file names that
+// start and/or end with the specified strings. The strings are matched
+// literally. If both are specified, the entire file name is matched
+func anchoredPattern(startsWith, endsWith string) string {
+	switch {
+	case startsWith != "" && endsWith != "":
+		return "^" + regexp.QuoteMeta(startsWith) + ".*" +
+			regexp.QuoteMeta(endsWith) + "$"
+	case startsWith != "":
+		return "^" + regexp.QuoteMeta(startsWith)
+	case endsWith != "":
+		return regexp.QuoteMeta(endsWith) + "$"
+	}
+
+	return ""
+}
+
+// escapeDots escapes every unescaped period in the pattern so that it
+// matches a literal period instead of any character
+func escapeDots(pattern string) string {
+	var b strings.Builder
+
+	var escaped bool
+	for _, r := range pattern {
+		if r == '.' && !escaped {
+			b.WriteString(`\.`)
+			continue
+		}
+
+		escaped = r == '\\' && !escaped
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
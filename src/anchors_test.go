@@ -0,0 +1,164 @@
+package f2
+
+import "testing"
+
+func TestEscapeDots(t *testing.T) {
+	cases := map[string]string{
+		".jpg":        `\.jpg`,
+		`a\.b.c`:      `a\.b\.c`,
+		`a\\.b`:       `a\\\.b`,
+		"(.+)-(\\d+)": `(\.+)-(\d+)`,
+	}
+
+	for input, want := range cases {
+		if got := escapeDots(input); got != want {
+			t.Fatalf("Input: %s — Expected: %s, but got: %s", input, want, got)
+		}
+	}
+}
+
+func TestAnchors(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	cases := []testCase{
+		{
+			name: "Replace a literal prefix",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "xyc.pdf",
+				},
+				{
+					Source:  "abc.epub",
+					BaseDir: testDir,
+					Target:  "xyc.epub",
+				},
+			},
+			args: []string{"--starts-with", "ab", "-r", "xy", testDir},
+		},
+		{
+			name: "Replace a literal suffix",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "abc.txt",
+				},
+			},
+			args: []string{"--ends-with", ".pdf", "-r", ".txt", testDir},
+		},
+		{
+			name: "Match both a prefix and a suffix",
+			want: []Change{
+				{
+					Source:  "abc.epub",
+					BaseDir: testDir,
+					Target:  "book.epub",
+				},
+			},
+			args: []string{
+				"--starts-with",
+				"a",
+				"--ends-with",
+				".epub",
+				"-r",
+				"book.epub",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestLiteralDot(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.b.txt", "axb.txt"})
+
+	cases := []testCase{
+		{
+			name: "Treat periods literally",
+			want: []Change{
+				{
+					Source:  "a.b.txt",
+					BaseDir: testDir,
+					Target:  "ab.txt",
+				},
+			},
+			args: []string{"-f", "a.b", "-r", "ab", "--literal-dot", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestWholeWordAndAnchor(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"cat.jpg",
+		"the cat.jpg",
+		"concat.jpg",
+		"a.b cat a.b.txt",
+	})
+
+	cases := []testCase{
+		{
+			name: "Match whole words only",
+			want: []Change{
+				{Source: "a.b cat a.b.txt", BaseDir: testDir, Target: "a.b dog a.b.txt"},
+				{Source: "cat.jpg", BaseDir: testDir, Target: "dog.jpg"},
+				{Source: "the cat.jpg", BaseDir: testDir, Target: "the dog.jpg"},
+			},
+			args: []string{"-f", "cat", "-r", "dog", "--whole-word", testDir},
+		},
+		{
+			name: "Anchor a whole word to the start of the file name",
+			want: []Change{
+				{Source: "cat.jpg", BaseDir: testDir, Target: "dog.jpg"},
+			},
+			args: []string{
+				"-f",
+				"cat",
+				"-r",
+				"dog",
+				"--whole-word",
+				"--anchor",
+				"start",
+				testDir,
+			},
+		},
+		{
+			name: "Anchor a literal string to the end of the file name",
+			want: []Change{
+				{Source: "a.b cat a.b.txt", BaseDir: testDir, Target: "a.b cat a-b.txt"},
+			},
+			args: []string{
+				"-f",
+				"a.b.txt",
+				"-r",
+				"a-b.txt",
+				"-s",
+				"--anchor",
+				"end",
+				testDir,
+			},
+		},
+		{
+			name: "Anchor an alternation to the entire file name",
+			want: []Change{
+				{Source: "cat.jpg", BaseDir: testDir, Target: "kitten.jpg"},
+			},
+			args: []string{
+				"-f",
+				`cat|dog`,
+				"-r",
+				"kitten",
+				"--anchor",
+				"both",
+				"-e",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,162 @@
+package f2
+
+import (
+	"context"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Options configures a renaming operation created with New. The fields
+// correspond to the command line flags of the same name
+type Options struct {
+	// Paths are the files and directories to search. Defaults to the
+	// current directory
+	Paths []string
+	// Find contains the find patterns (regular expressions unless
+	// StringMode is set). Each pattern is paired with the replacement
+	// at the same position
+	Find []string
+	// Replace contains the replacement strings
+	Replace []string
+	// Exclude contains the patterns of the files to exclude
+	Exclude []string
+	// ReplaceLimit limits the number of replacements in each file name
+	ReplaceLimit int
+	// Sort is the order in which the matches are sorted
+	Sort string
+	// ReverseSort sorts the matches in reverse order
+	ReverseSort bool
+	// MaxDepth limits the depth of a recursive search
+	MaxDepth      int
+	StringMode    bool
+	IgnoreCase    bool
+	IgnoreExt     bool
+	IncludeDir    bool
+	OnlyDir       bool
+	IncludeHidden bool
+	Recursive     bool
+	FixConflicts  bool
+	// Sanitize makes the new file names valid on the current
+	// operating system
+	Sanitize bool
+	// Seed makes the random variables produce the same values each time
+	// the operation is run. Random values are used if it is zero
+	Seed int64
+	// Now returns the time used for the `{{now}}` variables. Defaults to
+	// the current time
+	Now func() time.Time
+	// Events contains the callbacks invoked as the operation progresses
+	Events Events
+	// FS is the filesystem on which files are found and renamed.
+	// Defaults to the filesystem of the operating system
+	FS FS
+}
+
+// flagOptions returns the options that are set by the command line flags
+// and arguments. The other flags are applied to the operation by setFlags
+func flagOptions(c *cli.Context, fsys FS) Options {
+	o := Options{
+		Paths:         c.Args().Slice(),
+		Find:          c.StringSlice("find"),
+		Replace:       c.StringSlice("replace"),
+		Exclude:       c.StringSlice("exclude"),
+		ReplaceLimit:  c.Int("replace-limit"),
+		Sort:          c.String("sort"),
+		MaxDepth:      int(c.Uint("max-depth")),
+		StringMode:    c.Bool("string-mode"),
+		IgnoreCase:    c.Bool("ignore-case"),
+		IgnoreExt:     c.Bool("ignore-ext"),
+		IncludeDir:    c.Bool("include-dir"),
+		OnlyDir:       c.Bool("only-dir"),
+		IncludeHidden: c.Bool("hidden"),
+		Recursive:     c.Bool("recursive"),
+		FixConflicts:  c.Bool("fix-conflicts"),
+		Sanitize:      c.Bool("sanitize"),
+		Seed:          c.Int64("seed"),
+		FS:            fsys,
+	}
+
+	if o.Sort == "" && c.String("sortr") != "" {
+		o.Sort = c.String("sortr")
+		o.ReverseSort = true
+	}
+
+	return o
+}
+
+// setOptions sets the fields of the operation from the options
+func (op *Operation) setOptions(o Options) {
+	op.directories = o.Paths
+	op.findSlice = o.Find
+	op.replacementSlice = o.Replace
+	op.excludeFilter = o.Exclude
+	op.replaceLimit = o.ReplaceLimit
+	op.sort = o.Sort
+	op.reverseSort = o.ReverseSort && o.Sort != ""
+	op.maxDepth = o.MaxDepth
+	op.stringLiteralMode = o.StringMode
+	op.ignoreCase = o.IgnoreCase
+	op.ignoreExt = o.IgnoreExt
+	op.includeDir = o.IncludeDir
+	op.onlyDir = o.OnlyDir
+	op.hidden = hiddenPolicy{files: o.IncludeHidden, dirs: o.IncludeHidden}
+	op.recursive = o.Recursive
+	op.fixConflicts = o.FixConflicts
+	op.sanitize = o.Sanitize
+	op.seed = o.Seed
+	op.seeded = o.Seed != 0
+	op.now = o.Now
+	op.events = o.Events
+	op.fs = o.FS
+}
+
+// New creates a renaming operation with the specified options so that
+// other programs can use f2 without running the command line application.
+// The options are validated in the same way as the command line flags
+func New(opts Options) (*Operation, error) {
+	if len(opts.Find) == 0 && len(opts.Replace) == 0 {
+		return nil, errInvalidArgument
+	}
+
+	// the defaults of the flags that are not part of the options
+	op := &Operation{
+		quiet:            true,
+		targetStructure:  structurePreserve,
+		transactionScope: scopeOperation,
+		orphanRaw:        orphanSkip,
+		ocrCommand:       tesseract,
+		onUnresolved:     unresolvedEmpty,
+	}
+
+	op.setOptions(opts)
+
+	err := op.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	err = op.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// Execute renames the files in the operation. The renaming operation is
+// recorded in the history so that it can be reverted. If the context is
+// cancelled, the files that were renamed up to that point are recorded
+func (op *Operation) Execute(ctx context.Context) error {
+	op.reset()
+	op.exec = true
+
+	return op.run(ctx)
+}
+
+// Changes returns the changes of the operation after it has been
+// planned or executed. Changes that failed are not included after
+// the operation has been executed
+func (op *Operation) Changes() []Change {
+	return append([]Change(nil), op.matches...)
+}
@@ -0,0 +1,144 @@
+package f2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLibraryAPI(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt", "c.md"})
+
+	var renamed int
+
+	op, err := New(Options{
+		Paths:   []string{testDir},
+		Find:    []string{"txt$"},
+		Replace: []string{"log"},
+		Events: Events{
+			OnRename: func(ch Change) {
+				renamed++
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := op.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan) != 2 {
+		t.Fatalf("Expected 2 planned changes, but got: %v", plan)
+	}
+
+	for _, v := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); err != nil {
+			t.Fatalf("Expected planning not to rename any files: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = op.Execute(ctx)
+	if err != context.Canceled {
+		t.Fatalf("Expected error %v, but got: %v", context.Canceled, err)
+	}
+
+	err = op.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if renamed != 2 || len(op.Changes()) != 2 {
+		t.Fatalf("Expected 2 renamed files, but got %d", renamed)
+	}
+
+	for _, v := range []string{"a.log", "b.log", "c.md"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); err != nil {
+			t.Fatalf("Expected %s to exist: %v", v, err)
+		}
+	}
+}
+
+func TestLibraryAPIInvalidOptions(t *testing.T) {
+	_, err := New(Options{})
+	if err != errInvalidArgument {
+		t.Fatalf("Expected error %v, but got: %v", errInvalidArgument, err)
+	}
+}
+
+func TestLibraryAPIOptions(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.TXT", "b.txt", ".c.txt", "d.e.f.txt"})
+
+	cases := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "Sort the matches in reverse order",
+			opts: Options{
+				Find:        []string{"txt"},
+				Replace:     []string{"md"},
+				Sort:        "default",
+				ReverseSort: true,
+			},
+			want: []string{"d.e.f.md", "b.md"},
+		},
+		{
+			name: "Ignore case and include hidden files",
+			opts: Options{
+				Find:          []string{"txt"},
+				Replace:       []string{"md"},
+				IgnoreCase:    true,
+				IncludeHidden: true,
+			},
+			want: []string{".c.md", "a.md", "b.md", "d.e.f.md"},
+		},
+		{
+			name: "Treat the pattern as a string and limit the replacements",
+			opts: Options{
+				Find:         []string{"."},
+				Replace:      []string{"_"},
+				StringMode:   true,
+				ReplaceLimit: 1,
+				IgnoreExt:    true,
+			},
+			want: []string{"d_e.f.txt"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc.opts.Paths = []string{testDir}
+
+		op, err := New(tc.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		plan, err := op.Plan()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		var got []string
+		for _, ch := range plan {
+			got = append(got, ch.Target)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: expected targets %v, but got: %v", tc.name, tc.want, got)
+		}
+	}
+
+	_, err := New(Options{Find: []string{"("}, Paths: []string{testDir}})
+	if err == nil {
+		t.Fatal("Expected an error for an invalid find pattern")
+	}
+}
@@ -3,6 +3,8 @@ package f2
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
 	"time"
 
 	"github.com/urfave/cli/v2"
@@ -30,6 +32,11 @@ OPTIONS:{{range .VisibleFlags}}{{ if not (eq .Name "find" "replace" "undo") }}
 		 {{if .Aliases}}-{{range $element := .Aliases}}{{$element}},{{end}}{{end}} --{{.Name}} {{ .DefaultText }}
 				 {{.Usage}}
 		 {{end}}{{end}}{{end}}
+{{if .VisibleCommands}}
+COMMANDS:{{range .VisibleCommands}}
+		 {{.Name}}
+				 {{.Usage}}
+		 {{end}}{{end}}
 DOCUMENTATION:
 	https://github.com/ayoisaiah/f2/wiki
 
@@ -78,6 +85,27 @@ func checkForUpdates(app *cli.App) {
 	}
 }
 
+// Run runs the f2 app with the specified arguments
+func Run(args []string) error {
+	return runApp(GetApp(), args)
+}
+
+// runApp runs the app with the specified arguments. A command is only
+// recognised as the first argument so that a path that shares its name is
+// never mistaken for it (e.g. `f2 -f a -r b check` renames the files in
+// ./check). Use `--` to rename a path that is named after a command
+// without any flags (e.g. `f2 -- check`)
+func runApp(app *cli.App, args []string) error {
+	if len(args) < 2 || app.Command(args[1]) == nil {
+		app.Before = func(c *cli.Context) error {
+			c.App.Commands = nil
+			return nil
+		}
+	}
+
+	return app.Run(args)
+}
+
 // GetApp retrieves the f2 app instance
 func GetApp() *cli.App {
 	return &cli.App{
@@ -89,20 +117,20 @@ func GetApp() *cli.App {
 			},
 		},
 		Usage:                "F2 is a command-line tool for batch renaming multiple files and directories quickly and safely",
-		UsageText:            "FLAGS [OPTIONS] [PATHS...]",
+		UsageText:            "FLAGS [OPTIONS] [--] [PATHS...] | COMMAND [OPTIONS]",
 		Version:              "v1.6.4",
 		EnableBashCompletion: true,
 		Flags: []cli.Flag{
 			&cli.StringSliceFlag{
 				Name:        "find",
 				Aliases:     []string{"f"},
-				Usage:       "Search pattern. Treated as a regular expression by default unless --string-mode is also used. If omitted, it defaults to the entire file name (including the extension).",
+				Usage:       "Search pattern. Treated as a regular expression by default unless --string-mode is also used. If omitted, it defaults to the entire file name (including the extension). Can be repeated along with --replace to apply several find and replace pairs to each file name in order. The shorthands \\dte (a date such as 2021-04-05 or 05-04-2021), \\num (a run of digits) and \\ws (a run of whitespace) may be used in the pattern.",
 				DefaultText: "<pattern>",
 			},
 			&cli.StringSliceFlag{
 				Name:        "replace",
 				Aliases:     []string{"r"},
-				Usage:       "Replacement string. If omitted, defaults to an empty string. Supports built-in and regex capture variables. Learn more about variable support here: https://github.com/ayoisaiah/f2/wiki/Built-in-variables",
+				Usage:       "Replacement string. If omitted, defaults to an empty string. Supports built-in and regex capture variables. Use \\{, \\} and \\$ to include literal braces and dollar signs. Learn more about variable support here: https://github.com/ayoisaiah/f2/wiki/Built-in-variables",
 				DefaultText: "<string>",
 			},
 			&cli.IntFlag{
@@ -117,6 +145,89 @@ func GetApp() *cli.App {
 				Aliases: []string{"s"},
 				Usage:   "Opt into string literal mode. The presence of this flag causes the search pattern to be treated as a non-regex string.",
 			},
+			&cli.StringFlag{
+				Name:        "segments",
+				Usage:       "Rename files by splitting their names into segments by a delimiter and keeping the specified segments in the given order (e.g. '3,1,4-' keeps segment 3, then 1, then 4 to the last one). Append a transformation to a segment to change it (e.g. '2.up'). The extension is preserved and the find pattern (if any) is matched against the name without its extension. Segments are also available in the replacement as {{seg.N}}.",
+				DefaultText: "<positions>",
+			},
+			&cli.StringFlag{
+				Name:        "target-dir",
+				Usage:       "Move the renamed files into the specified directory, creating it if necessary. The directory is relative to the current working directory.",
+				DefaultText: "<dir>",
+			},
+			&cli.StringFlag{
+				Name:        "target-structure",
+				Usage:       "Determines how subdirectories are handled when moving files with --target-dir. Options: 'preserve' recreates the directory structure relative to the searched paths and 'flatten' moves all the files directly into the target directory.",
+				Value:       structurePreserve,
+				DefaultText: "preserve",
+			},
+			&cli.BoolFlag{
+				Name:  "replace-path",
+				Usage: "Find and replace in the path of each file relative to the searched directory (including the names of its parent directories) instead of the file name alone. Use with -R. Files are moved to their new directories and directories left empty are removed.",
+			},
+			&cli.BoolFlag{
+				Name:  "flatten",
+				Usage: "Move the matched files in nested directories into the searched directory (or the --target-dir). Use with -R. Files with the same name are numbered to avoid conflicts.",
+			},
+			&cli.BoolFlag{
+				Name:  "transactional",
+				Usage: "Revert all the renames applied in the operation if any of them fails so that the filesystem is left unchanged.",
+			},
+			&cli.BoolFlag{
+				Name:  "durable",
+				Usage: "Flush the directories affected by the renames to disk (fsync) before exiting so that the new names are not lost if the system crashes right after the operation. Has no effect on Windows.",
+			},
+			&cli.StringFlag{
+				Name:        "transaction-scope",
+				Usage:       "Determines which renames are reverted in transactional mode when a rename fails. Use 'operation' to revert every rename or 'dir' to revert only the renames in the directories where a rename failed so that unrelated directories keep their changes. Setting it to 'dir' enables --transactional.",
+				Value:       scopeOperation,
+				DefaultText: "<operation|dir>",
+			},
+			&cli.BoolFlag{
+				Name:  "unmatched",
+				Usage: "List the scanned files that were not matched along with the reason (filtered, hidden or no match) instead of renaming the matches. Use it to confirm the coverage of the find pattern.",
+			},
+			&cli.BoolFlag{
+				Name:  "preview-segments",
+				Usage: "Display the matched file names split into numbered segments instead of renaming them. Use it to find the segment positions for --segments.",
+			},
+			&cli.StringFlag{
+				Name:        "delimiter",
+				Usage:       "The delimiter used to split file names into segments. Defaults to the first of '-', '_' or a space present in the file name.",
+				DefaultText: "<string>",
+			},
+			&cli.BoolFlag{
+				Name:    "glob",
+				Aliases: []string{"g"},
+				Usage:   "Interpret the find pattern as a shell glob that matches the entire file name (e.g. '*.JPG' or 'IMG_??.{png,jpg}') instead of a regular expression. Each wildcard is captured so that it can be referenced in the replacement as $1, $2 and so on.",
+			},
+			&cli.BoolFlag{
+				Name:  "whole-word",
+				Usage: "Only match the find pattern as a whole word so that 'cat' matches 'cat.jpg' and 'the cat.jpg' but not 'concat.jpg'. Letters, digits and underscores are word characters.",
+			},
+			&cli.StringFlag{
+				Name:        "anchor",
+				Usage:       "Anchor the find pattern to the 'start' or 'end' of the file name (or 'both' to match the entire name) without writing '^' or '$'. Works in string mode too.",
+				DefaultText: "<start|end|both>",
+			},
+			&cli.StringFlag{
+				Name:        "starts-with",
+				Usage:       "Match file names that start with the specified string (matched literally). The matched prefix is replaced. Cannot be combined with --find.",
+				DefaultText: "<string>",
+			},
+			&cli.StringFlag{
+				Name:        "ends-with",
+				Usage:       "Match file names that end with the specified string (matched literally). The matched suffix is replaced. Cannot be combined with --find. If used with --starts-with, the entire file name is replaced.",
+				DefaultText: "<string>",
+			},
+			&cli.BoolFlag{
+				Name:  "auto-escape",
+				Usage: "Match the find pattern literally if it is not a valid regular expression, or if it contains special characters (such as parentheses) and does not match any files as a regular expression.",
+			},
+			&cli.BoolFlag{
+				Name:  "literal-dot",
+				Usage: "Treat every period in the find pattern as a literal period instead of a regular expression that matches any character (e.g. -f .jpg).",
+			},
 			&cli.StringSliceFlag{
 				Name:        "exclude",
 				Aliases:     []string{"E"},
@@ -143,7 +254,7 @@ func GetApp() *cli.App {
 			&cli.BoolFlag{
 				Name:    "undo",
 				Aliases: []string{"u"},
-				Usage:   "Undo the last operation performed in the current working directory if possible. Learn more: https://github.com/ayoisaiah/f2/wiki/Undoing-a-renaming-operation",
+				Usage:   "Undo the last operation performed in the current working directory if possible. Use 'f2 history undo' to undo the most recent operation from another directory. Learn more: https://github.com/ayoisaiah/f2/wiki/Undoing-a-renaming-operation",
 			},
 			&cli.StringFlag{
 				Name: "sort",
@@ -154,7 +265,8 @@ func GetApp() *cli.App {
 						'mtime': file last modified time
 						'btime': file creation time (Windows and macOS only)
 						'atime': file last access time
-						'ctime': file metadata last change time`,
+						'ctime': file metadata last change time
+						'duration': audio or video duration (requires exiftool)`,
 				DefaultText: "<sort>",
 			},
 			&cli.StringFlag{
@@ -167,10 +279,53 @@ func GetApp() *cli.App {
 				Aliases: []string{"i"},
 				Usage:   "When this flag is provided, the given pattern will be searched case insensitively.",
 			},
+			&cli.BoolFlag{
+				Name:  "ignore-ext-case",
+				Usage: "Search for the extension at the end of the pattern (e.g. '.jpg' or '\\.jpg$') case insensitively while the rest of the pattern remains case sensitive. The original case of the text that is not replaced is preserved.",
+			},
+			&cli.StringFlag{
+				Name:        "ext-case",
+				Usage:       "Change the case of file extensions to 'lower' or 'upper'. The rest of the file name is left unchanged unless a replacement is specified. Compound extensions such as .tar.gz are changed as a whole.",
+				DefaultText: "<lower|upper>",
+			},
+			&cli.StringSliceFlag{
+				Name:        "ext-case-exclude",
+				Usage:       "Extensions that --ext-case should leave unchanged (e.g. '.C' to keep C++ source files distinct from C files). Extensions are matched case sensitively. Can be repeated or separated by commas.",
+				DefaultText: "<extensions>",
+			},
+			&cli.StringFlag{
+				Name:        "split",
+				Usage:       "Assign each file to one of the named groups in the specified proportions (e.g. 'train=0.8,val=0.1,test=0.1') and use {{split}} in the replacement to insert the name of its group. The assignment is derived from a hash of the file name so it is the same on every run.",
+				DefaultText: "<name=ratio,...>",
+			},
+			&cli.StringFlag{
+				Name:        "split-seed",
+				Usage:       "Change the assignment of files to the --split groups while keeping it reproducible",
+				DefaultText: "<string>",
+			},
+			&cli.StringFlag{
+				Name:        "normalize",
+				Usage:       "Convert the new file names to the specified Unicode normalization form. File names are left otherwise unchanged unless a replacement is specified so that names created on macOS (NFD) can be converted to NFC for other systems with 'f2 --normalize nfc -R'.",
+				DefaultText: "<nfc|nfd|nfkc|nfkd>",
+			},
 			&cli.BoolFlag{
 				Name:    "quiet",
 				Aliases: []string{"q"},
-				Usage:   "Activate silent mode which doesn't print out any information including errors",
+				Usage:   "Don't print the changes or any other information to stdout. A one-line summary of the operation and any errors are still printed to stderr.",
+			},
+			&cli.BoolFlag{
+				Name:  "silent",
+				Usage: "Like --quiet but the summary and errors are not printed either.",
+			},
+			&cli.StringFlag{
+				Name:        "rules",
+				Usage:       "Apply the ordered find and replace steps in a rules file instead of -f and -r. Each step is a [[rule]] table with a 'find' and 'replace' key and its own 'string-mode', 'ignore-case', 'ignore-ext' and 'replace-limit' options.",
+				DefaultText: "<file>",
+			},
+			&cli.StringSliceFlag{
+				Name:        "expr",
+				Usage:       "Find and replace with a sed-style expression such as 's/find/replace/gi' instead of -f and -r. Only the first occurrence is replaced unless the 'g' flag is present. The 'i' flag ignores case and a number (e.g. 's/a/b/2') replaces that occurrence (or every occurrence from it with 'g'). '&' and '\\1' in the replacement refer to the match and its capture groups. Can be repeated to apply several expressions in sequence.",
+				DefaultText: "<s/find/replace/flags>",
 			},
 			&cli.BoolFlag{
 				Name:    "ignore-ext",
@@ -187,30 +342,548 @@ func GetApp() *cli.App {
 				Aliases: []string{"D"},
 				Usage:   "Rename only directories, not files (implies --include-dir)",
 			},
+			&cli.StringFlag{
+				Name:        "descendants",
+				Usage:       "Show how many paths within each renamed directory will change ('count') or list them ('list') when previewing directory renames",
+				DefaultText: "<count|list>",
+			},
 			&cli.BoolFlag{
 				Name:    "hidden",
 				Aliases: []string{"H"},
-				Usage:   "Include hidden directories and files in the matches (they are skipped by default). A hidden file or directory is one whose name starts with a period (all operating systems) or one whose hidden attribute is set to true (Windows only)",
+				Usage:   "Include hidden directories and files in the matches (they are skipped by default). A hidden file or directory is one whose name starts with a period (all operating systems) or one whose hidden attribute is set to true (Windows only). Equivalent to --hidden-files --hidden-dirs",
+			},
+			&cli.BoolFlag{
+				Name:  "hidden-files",
+				Usage: "Include hidden files in the matches without descending into hidden directories",
+			},
+			&cli.BoolFlag{
+				Name:  "hidden-dirs",
+				Usage: "Descend into hidden directories (and match them with --include-dir) without including hidden files",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include-dotdirs",
+				Usage: "Include the hidden directories whose names match the specified glob pattern (such as '.config') even when other hidden directories are skipped. Can be repeated",
+			},
+			&cli.BoolFlag{
+				Name:  "sanitize",
+				Usage: "Make the new file names valid on the current operating system by removing control and forbidden characters, trimming trailing periods and spaces on Windows and renaming reserved names (such as CON). Path separators in variable values are replaced with underscores",
+			},
+			&cli.BoolFlag{
+				Name:  "transliterate",
+				Usage: "Convert the new file names to ASCII by removing diacritics (é becomes e) and romanising other letters (ß becomes ss and ж becomes zh). Characters without an ASCII approximation are replaced with underscores. Use {{tr.ascii}} or the 'translit' filter to transliterate a single value",
 			},
 			&cli.BoolFlag{
 				Name:    "fix-conflicts",
 				Aliases: []string{"F"},
 				Usage:   "Automatically fix conflicts based on predefined rules. Learn more: https://github.com/ayoisaiah/f2/wiki/Validation-and-conflict-detection",
 			},
+			&cli.BoolFlag{
+				Name:  "match-subtitles",
+				Usage: "Pair subtitle files with the video files in the same directory (by episode number or similar names) and rename them to match their videos. Language suffixes such as '.en' or '.pt-BR.forced' are preserved.",
+			},
+			&cli.BoolFlag{
+				Name:  "chapters",
+				Usage: "Order audio files by their embedded disc and track numbers (falling back to 'CD 1' style directory names and numbers in the file name) so that audiobooks and podcasts spread across several discs are numbered sequentially. If --replace is omitted, files are renamed to 'Chapter 01', 'Chapter 02', e.t.c.",
+			},
+			&cli.BoolFlag{
+				Name:  "raw-pairs",
+				Usage: "Treat RAW and JPEG files that share the same name as a unit. Only the JPEG is matched against the find pattern and its paired RAW files receive the same new name (including serial numbers). RAW files without a JPEG are handled according to --orphan-raw.",
+			},
+			&cli.BoolFlag{
+				Name:  "pair",
+				Usage: "Rename the files that share the same name as a renamed file but have a different extension (such as .xmp sidecars or .srt subtitles) even if they don't match the find pattern.",
+			},
+			&cli.StringSliceFlag{
+				Name:        "xcmd",
+				Usage:       "Define a command for the {{xcmd.<name>}} variable which is replaced with the command's output for each file. The command receives the file path as its final argument and on the standard input (e.g. --xcmd 'duration=ffprobe -v quiet -show_entries format=duration -of csv=p=0'). Can be repeated.",
+				DefaultText: "<name=command>",
+			},
+			&cli.StringFlag{
+				Name:        "orphan-raw",
+				Usage:       "Determines how RAW files without a paired JPEG are handled in --raw-pairs mode. Use 'skip' to exclude them from the operation or 'flag' to report them without renaming.",
+				Value:       orphanSkip,
+				DefaultText: "<skip|flag>",
+			},
+			&cli.StringFlag{
+				Name:        "ocr-cmd",
+				Usage:       "Command used to extract text for the {{ocr.firstwords}} variable. It receives the file path as its final argument and must print the recognised text to the standard output. Uses tesseract by default.",
+				Value:       tesseract,
+				DefaultText: "<command>",
+			},
+			&cli.StringFlag{
+				Name:        "write-manifest",
+				Usage:       "Write a sha256sum compatible manifest of the renamed files (using their new names) to the specified file. It can be verified with 'sha256sum -c <file>'.",
+				DefaultText: "<file>",
+			},
+			&cli.StringFlag{
+				Name:        "link",
+				Usage:       "Create hard links or symbolic links at the new paths instead of renaming the files. Set to 'hard' or 'sym'. Undoing the operation removes the links.",
+				DefaultText: "<hard|sym>",
+			},
+			&cli.StringFlag{
+				Name:        "report",
+				Usage:       "Write a standalone HTML report of the executed operation (with a sortable and filterable table of the changes and any errors) to the specified file.",
+				DefaultText: "<file>",
+			},
+			&cli.BoolFlag{
+				Name:  "retarget-symlinks",
+				Usage: "Rewrite symbolic links in the scanned paths that point to a renamed file or directory so that they continue to resolve. The rewritten links are restored when the operation is undone.",
+			},
+			&cli.StringSliceFlag{
+				Name:        "update-refs",
+				Usage:       "Rewrite references to renamed files in the text files within the scanned paths whose names match the given pattern (e.g. '*.md', '*.m3u'). Can be specified multiple times.",
+				DefaultText: "<pattern>",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Keep running and apply the renaming operation to files that are created in the target directories after f2 starts. Combine with -x to rename the files, otherwise the planned changes for each new batch of files are printed.",
+			},
+			&cli.DurationFlag{
+				Name:  "watch-interval",
+				Usage: "How often the target directories are checked for new files in watch mode.",
+				Value: time.Second,
+			},
+			&cli.DurationFlag{
+				Name:  "debounce",
+				Usage: "How long a new file must remain unchanged before it is renamed in watch mode. Prevents renaming files that are still being downloaded or written.",
+				Value: 2 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:        "watch-rules",
+				Usage:       "Rename the new files in watch mode according to the rules in the specified file instead of -f and -r. Each rule is a [[watch]] table with a 'match' glob or 'regex' pattern, a 'replace' template and an optional 'ignore-case' key (e.g. match = '*.pdf' and replace = 'scans/{{mtime.YYYY}}/{{f}}{{ext}}'). The rules are evaluated in order and each file is renamed by the first rule that matches its name.",
+				DefaultText: "<path>",
+			},
+			&cli.DurationFlag{
+				Name:  "batch-window",
+				Usage: "How long new files are collected in watch mode before they are renamed together. By default, each file is renamed as soon as it is ready.",
+			},
+			&cli.IntFlag{
+				Name:        "max-renames-per-minute",
+				Usage:       "Rename at most the specified number of files within a minute in watch mode. Files beyond the limit are held until they can be renamed without exceeding it. Guards against a runaway producer or a misconfigured pattern. Set to 0 for no limit.",
+				DefaultText: "<integer>",
+			},
+			&cli.StringFlag{
+				Name:        "quiet-hours",
+				Usage:       "Hold back renames in watch mode during the specified daily period (e.g. 22:00-07:00). The files are renamed once the period ends.",
+				DefaultText: "<HH:MM-HH:MM>",
+			},
+			&cli.StringFlag{
+				Name:        "metrics-addr",
+				Usage:       "Expose Prometheus metrics for watch mode at /metrics on the specified address (e.g. localhost:9090). The metrics count the files processed, renamed and failed along with the time taken to rename each batch.",
+				DefaultText: "<address>",
+			},
+			&cli.DurationFlag{
+				Name:  "max-runtime",
+				Usage: "Stop watch mode after the specified duration (e.g. 8h). Set to 0 to keep running until interrupted.",
+			},
+			&cli.StringFlag{
+				Name:        "message",
+				Usage:       "Describe the renaming operation (e.g. --message 'normalize season 3'). The message is stored with the operation and displayed by 'f2 history'.",
+				DefaultText: "<text>",
+			},
+			&cli.BoolFlag{
+				Name:  "notify",
+				Usage: "Send a desktop notification when the renaming operation is executed successfully or fails. Useful for long running operations.",
+			},
+			&cli.StringFlag{
+				Name:        "webhook",
+				Usage:       "Send a POST request containing a JSON summary of the operation to the specified URL once it completes.",
+				DefaultText: "<url>",
+			},
+			&cli.StringFlag{
+				Name: "on-unresolved",
+				Usage: `Determines what happens when a variable resolves to an empty value and no default is provided (e.g. {{id3.artist|Unknown Artist}}).
+					Allowed values:
+						'empty': replace the variable with an empty string
+						'error': abort the operation with an error
+						'skip': leave the file unchanged`,
+				Value:       unresolvedEmpty,
+				DefaultText: "<policy>",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Reject replacement strings that contain unknown variables (such as a misspelt {{mtme.YYYY}}) before any files are searched instead of leaving them in the new file names.",
+			},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"I"},
+				Usage:   "Confirm each change before it is applied. Every change can be accepted, skipped or edited, and the remaining changes can be accepted or skipped at once (implies --exec).",
+			},
+			&cli.BoolFlag{
+				Name:  "edit",
+				Usage: "Open the new names in a text editor ($VISUAL or $EDITOR) before they are checked for conflicts so that they can be corrected by hand. Delete a line to leave the file unchanged.",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the planned changes along with any detected conflicts as JSON instead of a table in dry-run mode.",
+			},
+			&cli.StringFlag{
+				Name:        "on-duplicate",
+				Usage:       "Resolve conflicts caused by files with the same name from different directories being renamed to the same path. Set to 'prefix' (prefix the name of the source directory), 'counter' (append a number) or 'skip' (leave the files unchanged).",
+				DefaultText: "<prefix|counter|skip>",
+			},
+			&cli.StringFlag{
+				Name:        "csv",
+				Usage:       "Rename the files listed in the first column of a CSV file (relative to the CSV file's directory). The second column provides the new name unless a replacement is specified, and each column can be used in the replacement as {{csv.N}} (e.g. {{csv.3}}). A header row is skipped, while other rows that list missing files are reported as errors.",
+				DefaultText: "<file>",
+			},
+			&cli.StringFlag{
+				Name:        "script",
+				Usage:       "Compute the new name of each matched file with the 'transform(name, meta)' function of a Lua 5.1 script. The meta table provides the name, stem, ext, dir, path, index, is_dir, size and mtime of the file. Returning nil or false leaves the file unchanged. The base, string, table and math libraries are available along with os.date, os.time, os.clock and os.difftime. Each call must return within 10 seconds.",
+				DefaultText: "<file>",
+			},
+			&cli.StringFlag{
+				Name:        "export",
+				Usage:       "Print the changes in a format understood by other renaming tools instead of a table so that the plan can be handed off. Set to 'mmv' (a 'from to' pair on each line) or 'qmv' (the dual-column format of qmv edit lists). Whitespace, backslashes and newlines in paths are escaped with a backslash.",
+				DefaultText: "<mmv|qmv>",
+			},
+			&cli.StringFlag{
+				Name:        "export-script",
+				Usage:       "Print the changes as a shell script ('sh') containing mv commands or a PowerShell script ('ps1') containing Move-Item commands instead of a table so that the operation can be reviewed, versioned or run later without f2. The commands are ordered so that chains and cycles of renames are handled correctly.",
+				DefaultText: "<sh|ps1>",
+			},
+			&cli.BoolFlag{
+				Name:  "print0",
+				Usage: "Print the source and target of each change terminated by a NUL character (source\\0target\\0) instead of a table so that the plan can be piped safely into tools such as xargs -0 even when file names contain newlines",
+			},
+			&cli.StringFlag{
+				Name:        "import",
+				Usage:       "Rename the files listed in a plan exported with --export (or written by hand in the mmv or qmv format). Relative paths are resolved from the plan's directory and the plan is checked for conflicts before any file is renamed.",
+				DefaultText: "<file>",
+			},
+			&cli.StringSliceFlag{
+				Name:        "map-root",
+				Usage:       "Replace the root of the paths in an imported plan or backup file so that a plan reviewed on one machine can be applied on another where the files live elsewhere (e.g. --map-root old=/mnt/a,new=/data/a). Can be repeated to map several roots.",
+				DefaultText: "old=<path>,new=<path>",
+			},
+			&cli.StringFlag{
+				Name:        "preset",
+				Usage:       "Use the options saved in a preset with 'f2 preset save'. Flags specified on the command line take precedence over those in the preset.",
+				DefaultText: "<name>",
+			},
+			&cli.StringSliceFlag{
+				Name:        "param",
+				Usage:       "Fill in a placeholder in the preset such as {{param.event}} (e.g. --param event=Wedding). Can be repeated.",
+				DefaultText: "<name=value>",
+			},
+			&cli.Float64Flag{
+				Name:        "fuzzy",
+				Usage:       "Match file names that contain text approximately equal to the find pattern (which is treated as a literal string). The threshold is a number between 0 and 1 where 1 requires an exact match (e.g. 0.8 tolerates roughly one typo in every five characters).",
+				DefaultText: "<threshold>",
+			},
+			&cli.Int64Flag{
+				Name:        "seed",
+				Usage:       "Seed the random variables (such as {{r}}) so that they produce the same values each time the command is run.",
+				DefaultText: "<number>",
+			},
+			&cli.BoolFlag{
+				Name:  "no-metadata-cache",
+				Usage: "Do not cache the hashes, EXIF data and ID3 tags extracted from files. By default, the metadata resolved while previewing the changes is reused when they are applied with -x unless the files were modified in the meantime.",
+			},
+			&cli.BoolFlag{
+				Name:  "offline",
+				Usage: "Prevent variables that depend on online providers (such as {{exif.place}} which looks up the place name for the GPS coordinates of an image) from accessing the network. Previously cached data is used if available, otherwise an error is reported.",
+			},
+			&cli.StringSliceFlag{
+				Name:        "rate-limit",
+				Usage:       "Maximum number of requests per second that can be made to an online provider in the form '<provider>=<rate>' (e.g. 'nominatim=1'). Set the rate to 0 to disable rate limiting. Defaults to one request per second.",
+				DefaultText: "<provider=rate>",
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:      "apply",
+				Usage:     "Apply the renames in a plan produced by the --json output or a backup file without finding or replacing anything. The plan is checked for conflicts again before it is applied. Append the -x flag to apply the changes.",
+				ArgsUsage: "<PLAN>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "exec",
+						Aliases: []string{"x"},
+						Usage:   "Apply the plan instead of previewing it.",
+					},
+					&cli.BoolFlag{
+						Name:    "quiet",
+						Aliases: []string{"q"},
+						Usage:   "Don't print anything to stdout.",
+					},
+					&cli.BoolFlag{
+						Name:  "notify",
+						Usage: "Send a desktop notification when the plan is executed successfully or fails.",
+					},
+					&cli.StringFlag{
+						Name:        "webhook",
+						Usage:       "Send a POST request containing a JSON summary of the plan to the specified URL once it completes.",
+						DefaultText: "<url>",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the result as JSON.",
+					},
+					&cli.StringSliceFlag{
+						Name:        "map-root",
+						Usage:       "Replace the root of the paths in the plan (e.g. --map-root old=/mnt/a,new=/data/a). Can be repeated to map several roots.",
+						DefaultText: "old=<path>,new=<path>",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					err := applyPlan(c)
+					if err != nil {
+						printError(c.Bool("quiet"), err)
+					}
+
+					return err
+				},
+			},
+			{
+				Name:  "preset",
+				Usage: "Manage the presets that store commonly used combinations of flags.",
+				Subcommands: []*cli.Command{
+					{
+						Name:            "save",
+						Usage:           "Save the flags that follow the name as a preset which can be used with --preset. Placeholders such as {{param.event}} are filled in with --param when the preset is used (e.g. f2 preset save photo-import -f '.*' -r '{{param.event}}-%03d{{ext}}').",
+						ArgsUsage:       "<NAME> [FLAGS...]",
+						SkipFlagParsing: true,
+						Action: func(c *cli.Context) error {
+							err := savePreset(c)
+							if err != nil {
+								printError(false, err)
+							}
+
+							return err
+						},
+					},
+				},
+			},
+			{
+				Name:  "service",
+				Usage: "Run f2 in watch mode unattended as a systemd user service on Linux or a scheduled task that starts at logon on Windows.",
+				Subcommands: []*cli.Command{
+					{
+						Name:            "install",
+						Usage:           "Install the service that runs f2 in watch mode with the flags and paths that follow from the current directory (e.g. f2 service install --watch-rules rules.toml -x ~/Downloads). Send SIGHUP to the service (systemctl --user reload f2) to reload the --watch-rules file.",
+						ArgsUsage:       "[FLAGS...] [PATHS...]",
+						SkipFlagParsing: true,
+						Action: func(c *cli.Context) error {
+							err := installService(c)
+							if err != nil {
+								printError(false, err)
+							}
+
+							return err
+						},
+					},
+					{
+						Name:  "start",
+						Usage: "Start the installed service.",
+						Action: func(c *cli.Context) error {
+							err := controlService("start")
+							if err != nil {
+								printError(false, err)
+							}
+
+							return err
+						},
+					},
+					{
+						Name:  "stop",
+						Usage: "Stop the running service.",
+						Action: func(c *cli.Context) error {
+							err := controlService("stop")
+							if err != nil {
+								printError(false, err)
+							}
+
+							return err
+						},
+					},
+				},
+			},
+			{
+				Name:   "history",
+				Usage:  "List the renaming operations in the history starting with the most recent one. Use 'f2 history undo' and 'f2 history redo' to revert or reapply them from any directory.",
+				Action: printHistory,
+				Subcommands: []*cli.Command{
+					{
+						Name:  "undo",
+						Usage: "Revert the most recent renaming operation in the history in the same way as -u but from any directory. Append the -x flag to apply the changes.",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "exec",
+								Aliases: []string{"x"},
+								Usage:   "Revert the operation instead of previewing it.",
+							},
+							&cli.BoolFlag{
+								Name:    "quiet",
+								Aliases: []string{"q"},
+								Usage:   "Don't print anything to stdout.",
+							},
+							&cli.BoolFlag{
+								Name:  "notify",
+								Usage: "Send a desktop notification when the operation is reverted successfully or fails.",
+							},
+							&cli.StringFlag{
+								Name:        "webhook",
+								Usage:       "Send a POST request containing a JSON summary of the reverted operation to the specified URL once it completes.",
+								DefaultText: "<url>",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							err := undoLatest(c)
+							if err != nil {
+								printError(c.Bool("quiet"), err)
+							}
+
+							return err
+						},
+					},
+					{
+						Name:  "redo",
+						Usage: "Reapply the most recently reverted operation. Append the -x flag to apply the changes.",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "exec",
+								Aliases: []string{"x"},
+								Usage:   "Reapply the operation instead of previewing it.",
+							},
+							&cli.BoolFlag{
+								Name:    "quiet",
+								Aliases: []string{"q"},
+								Usage:   "Don't print anything to stdout.",
+							},
+							&cli.BoolFlag{
+								Name:  "notify",
+								Usage: "Send a desktop notification when the operation is reapplied successfully or fails.",
+							},
+							&cli.StringFlag{
+								Name:        "webhook",
+								Usage:       "Send a POST request containing a JSON summary of the reapplied operation to the specified URL once it completes.",
+								DefaultText: "<url>",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							err := redoLatest(c)
+							if err != nil {
+								printError(c.Bool("quiet"), err)
+							}
+
+							return err
+						},
+					},
+				},
+			},
+			{
+				Name:      "dupes",
+				Usage:     "Report the existing file names that collide case-insensitively or after Unicode normalization. Such files cannot coexist on case-insensitive filesystems.",
+				ArgsUsage: "[PATHS...]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"R"},
+						Usage:   "Scan subdirectories recursively.",
+					},
+					&cli.BoolFlag{
+						Name:    "hidden",
+						Aliases: []string{"H"},
+						Usage:   "Include hidden files and directories.",
+					},
+					&cli.IntFlag{
+						Name:    "max-depth",
+						Aliases: []string{"m"},
+						Usage:   "Positive integer indicating the maximum depth for a recursive scan. Set to 0 for no limit.",
+						Value:   0,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					err := printDupes(c)
+					if err != nil {
+						printError(false, err)
+					}
+
+					return err
+				},
+			},
+			{
+				Name:      "check",
+				Usage:     "Report the file names that do not conform to a naming schema. The schema is a JSON or YAML file that describes the fields, separator, letter case and extensions of the expected names.",
+				ArgsUsage: "[PATHS...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "schema",
+						Usage: "Path to the naming schema (a .json, .yaml or .yml file).",
+					},
+					&cli.BoolFlag{
+						Name:  "conform",
+						Usage: "Rename the files that do not conform to the schema where the conforming name can be derived (e.g. by changing the letter case or the separators). The renames are previewed unless --exec is set.",
+					},
+					&cli.BoolFlag{
+						Name:    "exec",
+						Aliases: []string{"x"},
+						Usage:   "Execute the renames generated by --conform.",
+					},
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"R"},
+						Usage:   "Check subdirectories recursively.",
+					},
+					&cli.BoolFlag{
+						Name:    "hidden",
+						Aliases: []string{"H"},
+						Usage:   "Include hidden files and directories.",
+					},
+					&cli.IntFlag{
+						Name:    "max-depth",
+						Aliases: []string{"m"},
+						Usage:   "Positive integer indicating the maximum depth for a recursive check. Set to 0 for no limit.",
+						Value:   0,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					err := checkSchema(c)
+					if err != nil {
+						printError(false, err)
+					}
+
+					return err
+				},
+			},
 		},
+		HideHelpCommand:        true,
 		UseShortOptionHandling: true,
 		Action: func(c *cli.Context) error {
-			op, err := newOperation(c)
+			err := applyConfig(c)
 			if err != nil {
 				printError(false, err)
 				return err
 			}
 
-			err = op.run()
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+			defer stop()
+
+			if c.Bool("watch") {
+				err = watch(ctx, c)
+				if err != nil {
+					printError(false, err)
+				}
+
+				return err
+			}
+
+			op, err := newOperation(c, osFS{})
 			if err != nil {
+				printError(false, err)
+				return err
+			}
+
+			err = op.run(ctx)
+			if op.quiet && !op.silent {
+				op.printQuietSummary(os.Stderr, err)
+			} else if err != nil {
 				printError(op.quiet, err)
 			}
 
+			op.notify(err)
+			op.postWebhook(err)
+
 			return err
 		},
 	}
@@ -0,0 +1,66 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandNamesAsPaths(t *testing.T) {
+	testDir := setupFiles(t, []string{"check/a.txt", "undo/a.txt"})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	cases := []struct {
+		args []string
+		want []Change
+	}{
+		{
+			args: []string{"-f", "a", "-r", "b", "check"},
+			want: []Change{{BaseDir: "check", Source: "a.txt", Target: "b.txt"}},
+		},
+		{
+			args: []string{"-f", "a", "-r", "b", "-x", "undo"},
+			want: []Change{{BaseDir: "undo", Source: "a.txt", Target: "b.txt"}},
+		},
+		{
+			args: []string{"-f", "a", "-r", "b", "--", "check"},
+			want: []Change{{BaseDir: "check", Source: "a.txt", Target: "b.txt"}},
+		},
+	}
+
+	for _, tc := range cases {
+		result, err := action(append([]string{os.Args[0]}, tc.args...))
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.args, err)
+		}
+
+		if result.applyError != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.args, result.applyError)
+		}
+
+		if len(result.changes) != len(tc.want) ||
+			result.changes[0].BaseDir != tc.want[0].BaseDir ||
+			result.changes[0].Target != tc.want[0].Target {
+			t.Fatalf("%v: expected changes %v, but got: %v", tc.args, tc.want, result.changes)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "undo", "b.txt")); err != nil {
+		t.Fatalf("Expected the file in ./undo to be renamed: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package f2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+)
+
+var errMissingPlan = errors.New(
+	"Invalid argument: the path to a plan file must be specified",
+)
+
+// planFile is a plan produced by the JSON output (which lists the
+// `changes`) or a backup file (which lists the `operations`)
+type planFile struct {
+	WorkingDir string   `json:"working_dir"`
+	Changes    []Change `json:"changes"`
+	Operations []Change `json:"operations"`
+	Link       string   `json:"link"`
+}
+
+// readPlan returns the changes in the plan file. Relative base
+// directories are resolved from the directory in which the plan
+// was created
+func readPlan(path string) ([]Change, string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var pf planFile
+	err = json.Unmarshal(b, &pf)
+	if err != nil {
+		return nil, "", fmt.Errorf("Unable to parse plan: %w", err)
+	}
+
+	changes := pf.Changes
+	if len(changes) == 0 {
+		changes = pf.Operations
+	}
+
+	for i, v := range changes {
+		if !filepath.IsAbs(v.BaseDir) && pf.WorkingDir != "" {
+			changes[i].BaseDir = filepath.Join(pf.WorkingDir, v.BaseDir)
+		}
+
+		changes[i].Source = localPath(v.Source)
+		changes[i].Target = localPath(v.Target)
+		changes[i].originalSource = changes[i].Source
+	}
+
+	return changes, pf.Link, nil
+}
+
+// applyPlan executes exactly the renames in a plan file produced by the
+// JSON output or a backup file without finding or replacing anything.
+// The plan is checked for conflicts again before any file is renamed
+func applyPlan(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return errMissingPlan
+	}
+
+	changes, link, err := readPlan(path)
+	if err != nil {
+		return err
+	}
+
+	rootMappings, err := parseRootMappings(c.StringSlice("map-root"))
+	if err != nil {
+		return err
+	}
+
+	workingDir, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	op := &Operation{
+		exec:         c.Bool("exec"),
+		quiet:        c.Bool("quiet"),
+		json:         c.Bool("json"),
+		workingDir:   workingDir,
+		matches:      changes,
+		linkMode:     link,
+		rootMappings: rootMappings,
+		notifyOnDone: c.Bool("notify"),
+		webhook:      c.String("webhook"),
+	}
+
+	op.mapRoots()
+
+	for _, v := range op.matches {
+		// directories are renamed after their contents
+		if v.IsDir {
+			op.includeDir = true
+		}
+	}
+
+	err = op.apply(c.Context)
+
+	op.notify(err)
+	op.postWebhook(err)
+
+	return err
+}
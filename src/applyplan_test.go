@@ -0,0 +1,60 @@
+package f2
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyPlan(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt", "c.txt"})
+
+	plan := filepath.Join(testDir, "plan.json")
+	content := `{
+    "working_dir": "` + filepath.ToSlash(testDir) + `",
+    "changes": [
+        {"base_dir": ".", "source": "a.txt", "target": "one/a.txt"},
+        {"base_dir": ".", "source": "b.txt", "target": "two.txt"}
+    ]
+}`
+
+	err := ioutil.WriteFile(plan, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = GetApp().Run(append(os.Args[0:1], "apply", "-x", "-q", plan))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, f := range []string{filepath.Join("one", "a.txt"), "two.txt", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, f)); err != nil {
+			t.Fatalf("Expected %s to exist: %v", f, err)
+		}
+	}
+
+	// the plan is checked for conflicts before it is applied
+	content = `{
+    "working_dir": "` + filepath.ToSlash(testDir) + `",
+    "operations": [
+        {"base_dir": ".", "source": "two.txt", "target": "c.txt"}
+    ]
+}`
+
+	err = ioutil.WriteFile(plan, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = GetApp().Run(append(os.Args[0:1], "apply", "-x", "-q", plan))
+	if !errors.Is(err, errConflictDetected) {
+		t.Fatalf("Expected a conflict, but got: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "two.txt")); err != nil {
+		t.Fatalf("Expected two.txt to be left unchanged: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// arithRegex matches an arithmetic expression on integers such as
+	// `{{05+100}}` which is what `{{$1+100}}` becomes once the capture
+	// variable is expanded
+	arithRegex = regexp.MustCompile(`{{([-+*/%()\d\s]*\d[-+*/%()\d\s]*)}}`)
+
+	// arithOperandRegex matches the capture variables and numbering
+	// schemes that may be used as operands in the replacement string
+	arithOperandRegex = regexp.MustCompile(`\$\{?\w+\}?|\d*%\d*d`)
+
+	errInvalidArithmetic = errors.New("Invalid arithmetic expression")
+)
+
+// arithParser evaluates integer expressions with the usual precedence
+// of the `+`, `-`, `*`, `/` and `%` operators and parentheses
+type arithParser struct {
+	expr string
+	pos  int
+}
+
+func (p *arithParser) peek() byte {
+	for p.pos < len(p.expr) && p.expr[p.pos] == ' ' {
+		p.pos++
+	}
+
+	if p.pos == len(p.expr) {
+		return 0
+	}
+
+	return p.expr[p.pos]
+}
+
+func (p *arithParser) parseExpr() (int64, error) {
+	n, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return n, nil
+		}
+
+		p.pos++
+
+		m, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+
+		if op == '+' {
+			n += m
+		} else {
+			n -= m
+		}
+	}
+}
+
+func (p *arithParser) parseTerm() (int64, error) {
+	n, err := p.parseFactor()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return n, nil
+		}
+
+		p.pos++
+
+		m, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case op == '*':
+			n *= m
+		case m == 0:
+			return 0, fmt.Errorf("%w: division by zero", errInvalidArithmetic)
+		case op == '/':
+			n /= m
+		default:
+			n %= m
+		}
+	}
+}
+
+func (p *arithParser) parseFactor() (int64, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+
+		n, err := p.parseFactor()
+
+		return -n, err
+	case c == '(':
+		p.pos++
+
+		n, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+
+		if p.peek() != ')' {
+			return 0, errInvalidArithmetic
+		}
+
+		p.pos++
+
+		return n, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+		p.pos++
+	}
+
+	if start == p.pos {
+		return 0, errInvalidArithmetic
+	}
+
+	return strconv.ParseInt(p.expr[start:p.pos], 10, 64)
+}
+
+// evaluateArithmetic evaluates the integer expression. The result is padded
+// with zeros to the width of the first number if it has leading zeros so
+// that `05+1` becomes `06`
+func evaluateArithmetic(expr string) (string, error) {
+	expr = strings.Join(strings.Fields(expr), " ")
+
+	p := &arithParser{expr: expr}
+
+	n, err := p.parseExpr()
+	if err != nil {
+		return "", fmt.Errorf("%w in %s", err, expr)
+	}
+
+	if p.peek() != 0 {
+		return "", fmt.Errorf("%w: %s", errInvalidArithmetic, expr)
+	}
+
+	var width int
+	if first := strings.TrimLeft(expr, "-( "); len(first) > 1 && first[0] == '0' {
+		width = strings.IndexFunc(first, func(r rune) bool {
+			return r < '0' || r > '9'
+		})
+		if width == -1 {
+			width = len(first)
+		}
+	}
+
+	return fmt.Sprintf("%0*d", width, n), nil
+}
+
+// replaceArithmetic replaces each arithmetic expression in the input with
+// its result. Expressions without an operator (such as `{{5}}`) are left
+// unchanged
+func replaceArithmetic(input string) (string, error) {
+	var err error
+
+	out := arithRegex.ReplaceAllStringFunc(input, func(token string) string {
+		if err != nil {
+			return token
+		}
+
+		expr := arithRegex.FindStringSubmatch(token)[1]
+		if !strings.ContainsAny(strings.TrimLeft(expr, "- "), "+-*/%") {
+			return token
+		}
+
+		result, aerr := evaluateArithmetic(expr)
+		if aerr != nil {
+			err = aerr
+			return token
+		}
+
+		return result
+	})
+
+	return out, err
+}
+
+// isArithmeticTemplate reports whether the token is an arithmetic
+// expression on capture variables, numbering schemes or numbers
+// (such as `{{$1+100}}`)
+func isArithmeticTemplate(token string) bool {
+	return arithRegex.MatchString(arithOperandRegex.ReplaceAllString(token, "1"))
+}
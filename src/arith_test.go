@@ -0,0 +1,80 @@
+package f2
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvaluateArithmetic(t *testing.T) {
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"05+100", "105"},
+		{"05+1", "06"},
+		{"007 - 2", "005"},
+		{"2+3*4", "14"},
+		{"(2+3)*4", "20"},
+		{"17/5", "3"},
+		{"17%5", "2"},
+		{"-3+10", "7"},
+	}
+
+	for _, tc := range cases {
+		got, err := evaluateArithmetic(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.expr, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("Expected %s to be %s, but got: %s", tc.expr, tc.want, got)
+		}
+	}
+
+	for _, expr := range []string{"1/0", "1+", "(1+2", "1 2"} {
+		if _, err := evaluateArithmetic(expr); !errors.Is(err, errInvalidArithmetic) {
+			t.Fatalf("Expected %s to be invalid, but got: %v", expr, err)
+		}
+	}
+}
+
+func TestArithmeticVariables(t *testing.T) {
+	testDir := setupFiles(t, []string{"Show S01E05.mkv", "Show S01E12.mkv"})
+
+	cases := []testCase{
+		{
+			name: "Offset captured numbers",
+			want: []Change{
+				{
+					Source:  "Show S01E05.mkv",
+					BaseDir: testDir,
+					Target:  "Show S02E15.mkv",
+				},
+				{
+					Source:  "Show S01E12.mkv",
+					BaseDir: testDir,
+					Target:  "Show S02E22.mkv",
+				},
+			},
+			args: []string{"-f", "S01E(\\d+)", "-r", "S02E{{$1+10}}", "--strict", testDir},
+		},
+		{
+			name: "Apply arithmetic to the numbering scheme",
+			want: []Change{
+				{
+					Source:  "Show S01E05.mkv",
+					BaseDir: testDir,
+					Target:  "Show 010.mkv",
+				},
+				{
+					Source:  "Show S01E12.mkv",
+					BaseDir: testDir,
+					Target:  "Show 020.mkv",
+				},
+			},
+			args: []string{"-f", "S01E\\d+", "-r", "{{%03d*10}}", "--strict", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,153 @@
+package f2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultCacheTTL is how long a cached provider response is
+	// considered fresh
+	defaultCacheTTL = 30 * 24 * time.Hour
+	// defaultCacheSize is the maximum number of bytes that the cache
+	// for a single provider may occupy on disk
+	defaultCacheSize = 50 * 1024 * 1024
+)
+
+// providerCache is an on-disk cache for responses from online metadata
+// providers (such as the Nominatim geocoder) so that repeated runs over
+// the same files do not re-query the remote API
+type providerCache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+}
+
+// newProviderCache returns a cache for the specified provider.
+// Entries are stored in `~/.f2/cache/<provider>`
+func newProviderCache(
+	provider string,
+	ttl time.Duration,
+	maxSize int64,
+) (*providerCache, error) {
+	dirname, err := createBackupDir(filepath.Join("cache", provider))
+	if err != nil {
+		return nil, err
+	}
+
+	return &providerCache{
+		dir:     filepath.Join(dirname, ".f2", "cache", provider),
+		ttl:     ttl,
+		maxSize: maxSize,
+	}, nil
+}
+
+// path returns the location of the cache entry for the given key
+func (c *providerCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// get retrieves the cached value for the given key. Expired entries
+// are removed and reported as a cache miss
+func (c *providerCache) get(key string) ([]byte, bool) {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+// set stores the value for the given key in the cache and evicts the
+// oldest entries if the size limit is exceeded
+func (c *providerCache) set(key string, value []byte) error {
+	err := c.write(key, value)
+	if err != nil {
+		return err
+	}
+
+	return c.prune()
+}
+
+// write stores the value for the given key in the cache without evicting
+// any entries. It is used when many entries are stored at once so that
+// the cache is pruned only after all of them are written
+func (c *providerCache) write(key string, value []byte) error {
+	return os.WriteFile(c.path(key), value, 0600)
+}
+
+// prune removes expired entries and then the least recently written
+// entries until the cache fits within its size limit
+func (c *providerCache) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	var infos []os.FileInfo
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+			_ = os.Remove(filepath.Join(c.dir, info.Name()))
+			continue
+		}
+
+		total += info.Size()
+		infos = append(infos, info)
+	}
+
+	if c.maxSize <= 0 || total <= c.maxSize {
+		return nil
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	for _, info := range infos {
+		if total <= c.maxSize {
+			break
+		}
+
+		err = os.Remove(filepath.Join(c.dir, info.Name()))
+		if err != nil {
+			return err
+		}
+
+		total -= info.Size()
+	}
+
+	return nil
+}
+
+// clear removes all the entries in the cache
+func (c *providerCache) clear() error {
+	return os.RemoveAll(c.dir)
+}
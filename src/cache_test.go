@@ -0,0 +1,86 @@
+package f2
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, ttl time.Duration, size int64) *providerCache {
+	t.Helper()
+
+	return &providerCache{
+		dir:     t.TempDir(),
+		ttl:     ttl,
+		maxSize: size,
+	}
+}
+
+func TestProviderCache(t *testing.T) {
+	c := newTestCache(t, time.Hour, 0)
+
+	if _, ok := c.get("missing"); ok {
+		t.Fatal("Expected a cache miss for a missing key")
+	}
+
+	err := c.set("https://example.com/a", []byte("value"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, ok := c.get("https://example.com/a")
+	if !ok || string(got) != "value" {
+		t.Fatalf("Expected: value, but got: %s (hit: %t)", got, ok)
+	}
+}
+
+func TestProviderCacheExpiry(t *testing.T) {
+	c := newTestCache(t, time.Minute, 0)
+
+	err := c.set("key", []byte("value"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	err = os.Chtimes(c.path("key"), past, past)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := c.get("key"); ok {
+		t.Fatal("Expected expired entry to be a cache miss")
+	}
+
+	if _, err := os.Stat(c.path("key")); err == nil {
+		t.Fatal("Expected expired entry to be removed from disk")
+	}
+}
+
+func TestProviderCacheSizeLimit(t *testing.T) {
+	c := newTestCache(t, 0, 10)
+
+	err := c.set("old", []byte("123456"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	err = os.Chtimes(c.path("old"), past, past)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = c.set("new", []byte("123456"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := c.get("old"); ok {
+		t.Fatal("Expected the oldest entry to be evicted")
+	}
+
+	if _, ok := c.get("new"); !ok {
+		t.Fatal("Expected the newest entry to be retained")
+	}
+}
@@ -0,0 +1,121 @@
+package f2
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	audioExtensions = []string{
+		".mp3", ".m4a", ".m4b", ".aac", ".flac",
+		".ogg", ".opus", ".wav", ".wma",
+	}
+	// discDirRegex extracts the disc number from directory names such as
+	// `CD 2`, `Disc 02` or `Part3`
+	discDirRegex = regexp.MustCompile(`(?i)\b(?:cd|disc|disk|part)\s*(\d+)`)
+	// leadingNumberRegex extracts the first number in a file name
+	leadingNumberRegex = regexp.MustCompile(`\d+`)
+)
+
+// minChapterDigits is the minimum width of the zero-padded chapter number
+const minChapterDigits = 2
+
+// chapterPosition represents the position of an audio file
+// within an audiobook or podcast
+type chapterPosition struct {
+	disc  int
+	track int
+}
+
+// getChapterPosition determines the disc and track number of an audio file
+// using its embedded tags. The disc number falls back to the parent
+// directory name (e.g `CD 2`) and the track number to the first number in
+// the file name
+func getChapterPosition(ch Change) (chapterPosition, error) {
+	var pos chapterPosition
+
+	tags, err := getID3Tags(filepath.Join(ch.BaseDir, ch.Source))
+	if err != nil {
+		return pos, err
+	}
+
+	pos.disc, pos.track = tags.Disc, tags.Track
+
+	if pos.disc == 0 {
+		match := discDirRegex.FindStringSubmatch(filepath.Base(ch.BaseDir))
+		if match != nil {
+			pos.disc, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	if pos.track == 0 {
+		num := leadingNumberRegex.FindString(ch.Source)
+		if num != "" {
+			pos.track, _ = strconv.Atoi(num)
+		}
+	}
+
+	return pos, nil
+}
+
+// sortChapters excludes files that are not audio files and orders the
+// rest by disc and track number so that they are numbered sequentially
+// even when spread across several disc directories
+func (op *Operation) sortChapters() error {
+	var audio []Change
+	positions := make(map[string]chapterPosition)
+
+	for _, ch := range op.matches {
+		ext := strings.ToLower(filepath.Ext(ch.Source))
+		if ch.IsDir || !contains(audioExtensions, ext) {
+			continue
+		}
+
+		pos, err := getChapterPosition(ch)
+		if err != nil {
+			return err
+		}
+
+		positions[filepath.Join(ch.BaseDir, ch.Source)] = pos
+		audio = append(audio, ch)
+	}
+
+	sort.SliceStable(audio, func(i, j int) bool {
+		ipos := positions[filepath.Join(audio[i].BaseDir, audio[i].Source)]
+		jpos := positions[filepath.Join(audio[j].BaseDir, audio[j].Source)]
+
+		if ipos.disc != jpos.disc {
+			return ipos.disc < jpos.disc
+		}
+
+		if audio[i].BaseDir != audio[j].BaseDir {
+			return audio[i].BaseDir < audio[j].BaseDir
+		}
+
+		if ipos.track != jpos.track {
+			return ipos.track < jpos.track
+		}
+
+		return audio[i].Source < audio[j].Source
+	})
+
+	op.matches = audio
+
+	return nil
+}
+
+// defaultChapterTemplate returns the replacement string used in chapter
+// mode when none is provided. The chapter number is padded according to
+// the number of chapters
+func defaultChapterTemplate(count int) string {
+	width := len(strconv.Itoa(count))
+	if width < minChapterDigits {
+		width = minChapterDigits
+	}
+
+	return fmt.Sprintf("Chapter %%0%dd{{ext}}", width)
+}
@@ -0,0 +1,44 @@
+package f2
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChapters(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"CD 2/01 Track.mp3",
+		"CD 2/02 Track.mp3",
+		"CD 1/10 Track.mp3",
+		"CD 1/9 Track.mp3",
+		"CD 1/cover.jpg",
+	})
+
+	disc1 := filepath.Join(testDir, "CD 1")
+	disc2 := filepath.Join(testDir, "CD 2")
+
+	cases := []testCase{
+		{
+			name: "Number chapters across discs with the default template",
+			want: []Change{
+				{Source: "9 Track.mp3", BaseDir: disc1, Target: "Chapter 01.mp3"},
+				{Source: "10 Track.mp3", BaseDir: disc1, Target: "Chapter 02.mp3"},
+				{Source: "01 Track.mp3", BaseDir: disc2, Target: "Chapter 03.mp3"},
+				{Source: "02 Track.mp3", BaseDir: disc2, Target: "Chapter 04.mp3"},
+			},
+			args: []string{"--chapters", "-R", testDir},
+		},
+		{
+			name: "Number chapters with a custom template",
+			want: []Change{
+				{Source: "9 Track.mp3", BaseDir: disc1, Target: "Book - 001.mp3"},
+				{Source: "10 Track.mp3", BaseDir: disc1, Target: "Book - 002.mp3"},
+				{Source: "01 Track.mp3", BaseDir: disc2, Target: "Book - 003.mp3"},
+				{Source: "02 Track.mp3", BaseDir: disc2, Target: "Book - 004.mp3"},
+			},
+			args: []string{"--chapters", "-r", "Book - %03d{{ext}}", "-R", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
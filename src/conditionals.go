@@ -0,0 +1,114 @@
+package f2
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	ifToken   = "{{if "
+	elseToken = "{{else}}"
+	endToken  = "{{end}}"
+)
+
+var (
+	errUnclosedConditional = errors.New(
+		"Invalid replacement: every {{if <variable>}} must be closed with {{end}}",
+	)
+
+	// conditionRegex matches the condition of a conditional block
+	conditionRegex = regexp.MustCompile(`{{if ([^{}]*)}}`)
+)
+
+// isVariableCondition reports whether the condition names a variable in the
+// replacement string rather than being the value of an expanded capture
+// variable (such as `{{if $2}}`)
+func (op *Operation) isVariableCondition(cond string) bool {
+	for _, m := range conditionRegex.FindAllStringSubmatch(op.replacement, -1) {
+		raw := strings.TrimSpace(m[1])
+		if raw == cond && !strings.HasPrefix(raw, "$") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// evaluateCondition reports whether the condition of a conditional block is
+// satisfied. A condition naming a variable (e.g `exif.dt.YYYY`) is true if the
+// variable resolves to a non-empty value so an unknown or misspelled variable
+// is false. Any other condition (such as an expanded capture variable) is true
+// if it is non-empty
+func (op *Operation) evaluateCondition(cond string, ch Change) (bool, error) {
+	cond = strings.TrimSpace(cond)
+	if cond == "" {
+		return false, nil
+	}
+
+	variable := "{{" + cond + "}}"
+
+	vars, err := getAllVariables(variable)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := op.handleVariables(variable, ch, &vars)
+	if err != nil {
+		return false, err
+	}
+
+	if out == variable {
+		return !op.isVariableCondition(cond), nil
+	}
+
+	return strings.TrimSpace(out) != "", nil
+}
+
+// replaceConditionals evaluates the conditional blocks in the input
+// (`{{if <variable>}}...{{else}}...{{end}}`) and replaces each one with the
+// appropriate branch. Nested blocks are evaluated from the inside out
+func (op *Operation) replaceConditionals(
+	input string,
+	ch Change,
+) (string, error) {
+	for {
+		start := strings.LastIndex(input, ifToken)
+		if start == -1 {
+			if strings.Contains(input, endToken) {
+				return "", errUnclosedConditional
+			}
+
+			return input, nil
+		}
+
+		condEnd := strings.Index(input[start:], "}}")
+		end := strings.Index(input[start:], endToken)
+		if condEnd == -1 || end == -1 || end < condEnd {
+			return "", errUnclosedConditional
+		}
+
+		condEnd += start
+		end += start
+
+		cond := input[start+len(ifToken) : condEnd]
+		body := input[condEnd+len("}}") : end]
+
+		ifBranch, elseBranch := body, ""
+		if i := strings.Index(body, elseToken); i != -1 {
+			ifBranch, elseBranch = body[:i], body[i+len(elseToken):]
+		}
+
+		ok, err := op.evaluateCondition(cond, ch)
+		if err != nil {
+			return "", err
+		}
+
+		branch := elseBranch
+		if ok {
+			branch = ifBranch
+		}
+
+		input = input[:start] + branch + input[end+len(endToken):]
+	}
+}
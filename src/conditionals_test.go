@@ -0,0 +1,117 @@
+package f2
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestConditionals(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "images")
+
+	cases := []testCase{
+		{
+			name: "Use the if branch when the variable has a value",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "samsung_2020.jpeg",
+				},
+				{
+					Source:  "bike.json",
+					BaseDir: testDir,
+					Target:  "unknown_bike.json",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike",
+				"-r",
+				"{{if exif.make}}{{exif.make}}_{{exif.dt.YYYY}}{{else}}unknown_{{f}}{{end}}",
+				"-e",
+				testDir,
+			},
+		},
+		{
+			name: "Conditionals without an else branch and with captures",
+			want: []Change{
+				{
+					Source:  "proraw.dng",
+					BaseDir: testDir,
+					Target:  "proraw-raw.dng",
+				},
+				{
+					Source:  "proraw.json",
+					BaseDir: testDir,
+					Target:  "proraw.json",
+				},
+				{
+					Source:  "proraw_exiftool.json",
+					BaseDir: testDir,
+					Target:  "proraw_exiftool.json",
+				},
+			},
+			args: []string{
+				"-f",
+				"(proraw)(\\.dng)?",
+				"-r",
+				"$1{{if $2}}-raw{{end}}$2",
+				testDir,
+			},
+		},
+		{
+			name: "Nested conditionals",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "samsung-SM-G975F.jpeg",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike",
+				"-r",
+				"{{if exif.make}}{{exif.make}}{{if exif.model}}-{{exif.model}}{{else}}-none{{end}}{{end}}",
+				"-e",
+				"-E",
+				"json",
+				testDir,
+			},
+		},
+		{
+			name: "Unknown variables take the else branch",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "untitled.jpeg",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike",
+				"-r",
+				"{{if id3.titel}}{{id3.title}}{{else}}untitled{{end}}",
+				"-e",
+				"-E",
+				"json",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestUnclosedConditional(t *testing.T) {
+	op := &Operation{}
+
+	for _, v := range []string{"{{if f}}abc", "abc{{end}}", "{{if f abc{{end}}"} {
+		_, err := op.replaceConditionals(v, Change{Source: "a.txt"})
+		if !errors.Is(err, errUnclosedConditional) {
+			t.Fatalf("Test (%s) — Expected an unclosed conditional error", v)
+		}
+	}
+}
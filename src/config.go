@@ -0,0 +1,358 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gookit/color"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	configFileName = "config.toml"
+	dirConfigName  = ".f2.toml"
+	colorsSection  = "colors"
+	aliasSection   = "alias"
+)
+
+var (
+	errInvalidConfigValue = errors.New(
+		"values must be strings, booleans, numbers or arrays of strings",
+	)
+
+	hexColorRegex = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+	// dirConfigOptions are the only options that may be set in the
+	// .f2.toml file in the current directory (along with the colors table)
+	// since the file comes with the files being renamed. Options such as
+	// exec, xcmd, editor or webhook must not be set by a directory that may
+	// have been downloaded or cloned from elsewhere
+	dirConfigOptions = []string{"hidden", "sort", "exclude", "fix-conflicts"}
+
+	// configFlagGroups are flags that cannot be combined or that replace
+	// each other. When a flag in a group is set on the command line, the
+	// configuration values of every flag in the group are ignored so that
+	// a configured --sort does not take precedence over --sortr, for example
+	configFlagGroups = [][]string{
+		{"sort", "sortr"},
+		{"replace", "expr", "rules", "segments", "script"},
+		{"find", "expr", "rules"},
+	}
+)
+
+// configDir returns the directory that contains the configuration file.
+// It is located in $XDG_CONFIG_HOME (or ~/.config) on Unix and %APPDATA%
+// on Windows
+func configDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" && runtime.GOOS == windows {
+		dir = os.Getenv("APPDATA")
+	}
+
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		dir = filepath.Join(homeDir, ".config")
+	}
+
+	return filepath.Join(dir, "f2"), nil
+}
+
+// flattenConfig adds the values in a decoded TOML table to the config.
+// Keys within a table are prefixed with the name of the table (e.g.
+// `colors.ok`) and the tables in an array of tables (e.g. `[[rule]]`)
+// are numbered from 1 so that their keys are distinct (e.g. "rule.1.find")
+func flattenConfig(
+	config map[string][]string,
+	prefix string,
+	table map[string]interface{},
+) error {
+	for key, value := range table {
+		key = prefix + key
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			err := flattenConfig(config, key+".", v)
+			if err != nil {
+				return err
+			}
+		case []map[string]interface{}:
+			for i, t := range v {
+				err := flattenConfig(config, key+"."+strconv.Itoa(i+1)+".", t)
+				if err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := configScalar(item)
+				if !ok {
+					return fmt.Errorf("'%s': %w", key, errInvalidConfigValue)
+				}
+
+				values = append(values, s)
+			}
+
+			config[key] = values
+		default:
+			s, ok := configScalar(v)
+			if !ok {
+				return fmt.Errorf("'%s': %w", key, errInvalidConfigValue)
+			}
+
+			config[key] = []string{s}
+		}
+	}
+
+	return nil
+}
+
+// configScalar formats a string, boolean or number as a flag value
+func configScalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// parseConfig decodes a TOML configuration file into the values of each
+// key. Values must be strings, booleans, numbers or arrays of them
+func parseConfig(r io.Reader) (map[string][]string, error) {
+	var table map[string]interface{}
+
+	_, err := toml.NewDecoder(r).Decode(&table)
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string][]string)
+
+	err = flattenConfig(config, "", table)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// readConfig parses the configuration file at the specified path.
+// A missing file is not an error
+func readConfig(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// setColors replaces the colours used for the status of each change
+// with those specified in the colors table
+func setColors(config map[string][]string) error {
+	for key, values := range config {
+		name := strings.TrimPrefix(key, colorsSection+".")
+
+		var c *color.RGBColor
+		switch name {
+		case "ok":
+			c = &green
+		case "warning":
+			c = &yellow
+		case "error":
+			c = &red
+		default:
+			return fmt.Errorf("Unknown color '%s' in the configuration", name)
+		}
+
+		if !hexColorRegex.MatchString(values[0]) {
+			return fmt.Errorf(
+				"Invalid value for color '%s': expected a hex code such as #23D160",
+				name,
+			)
+		}
+
+		*c = color.HEX(values[0])
+	}
+
+	return nil
+}
+
+// checkDirConfig reports an error if the .f2.toml file sets
+// an option that is not in dirConfigOptions
+func checkDirConfig(config map[string][]string) error {
+	for key := range config {
+		if !contains(dirConfigOptions, key) &&
+			!strings.HasPrefix(key, colorsSection+".") {
+			return fmt.Errorf(
+				"Option '%s' cannot be set in %s: only %s and the %s table are allowed",
+				key,
+				dirConfigName,
+				strings.Join(dirConfigOptions, ", "),
+				colorsSection,
+			)
+		}
+	}
+
+	return nil
+}
+
+// readConfigFiles merges the options in the global configuration file and
+// the .f2.toml file in the current directory which takes precedence
+func readConfigFiles() (map[string][]string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := readConfig(filepath.Join(dir, configFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config = make(map[string][]string)
+	}
+
+	dirConfig, err := readConfig(dirConfigName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDirConfig(dirConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range dirConfig {
+		config[k] = v
+	}
+
+	return config, nil
+}
+
+// applyConfig uses the options in the global configuration file, the
+// .f2.toml file in the current directory and the preset specified with
+// --preset (in increasing order of precedence) as the default values of the
+// flags. Flags specified on the command line take precedence over all of
+// them
+func applyConfig(c *cli.Context) error {
+	config, err := readConfigFiles()
+	if err != nil {
+		return err
+	}
+
+	if name := c.String("preset"); name != "" {
+		params, err := parseParams(c.StringSlice("param"))
+		if err != nil {
+			return err
+		}
+
+		preset, err := readPreset(name, params)
+		if err != nil {
+			return err
+		}
+
+		for k, v := range preset {
+			config[k] = v
+		}
+	}
+
+	colors := make(map[string][]string)
+	for key, values := range config {
+		if strings.HasPrefix(key, aliasSection+".") {
+			delete(config, key)
+			continue
+		}
+
+		if strings.HasPrefix(key, colorsSection+".") {
+			colors[key] = values
+			delete(config, key)
+		}
+	}
+
+	err = setColors(colors)
+	if err != nil {
+		return err
+	}
+
+	flags := make(map[string][]string)
+	for _, f := range c.App.Flags {
+		for _, name := range f.Names() {
+			flags[name] = f.Names()
+		}
+	}
+
+	// isSet reports whether the flag is set on the command line
+	// under any of its names
+	isSet := func(key string) bool {
+		for _, name := range flags[key] {
+			if c.IsSet(name) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for key, values := range config {
+		names, ok := flags[key]
+		if !ok {
+			return fmt.Errorf("Unknown option '%s' in the configuration", key)
+		}
+
+		set := isSet(key)
+
+		for _, group := range configFlagGroups {
+			if !contains(group, names[0]) {
+				continue
+			}
+
+			for _, name := range group {
+				set = set || isSet(name)
+			}
+		}
+
+		if set {
+			continue
+		}
+
+		for _, v := range values {
+			err = c.Set(key, v)
+			if err != nil {
+				return fmt.Errorf("Invalid value for '%s' in the configuration: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package f2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+)
+
+func TestParseConfig(t *testing.T) {
+	content := `# defaults for the team
+hidden = true
+sort = "mtime"
+max-depth = 3
+exclude = ["node_modules", 'C:\tmp', # comment
+  ".git"]
+
+[colors]
+ok = "#00FF00" # green
+
+[[rule]]
+find = "a"
+
+[[rule]]
+find = "b"
+ignore-case = true
+`
+
+	got, err := parseConfig(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"hidden":             {"true"},
+		"sort":               {"mtime"},
+		"max-depth":          {"3"},
+		"exclude":            {"node_modules", `C:\tmp`, ".git"},
+		"colors.ok":          {"#00FF00"},
+		"rule.1.find":        {"a"},
+		"rule.2.find":        {"b"},
+		"rule.2.ignore-case": {"true"},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Unexpected configuration: %s", cmp.Diff(want, got))
+	}
+
+	invalid := []string{
+		"sort = mtime",
+		`sort = "mtime`,
+		`exclude = ["a", "b"`,
+		"hidden",
+		`sort = "mtime" extra`,
+		"sort = 2021-03-04",
+		"exclude = [[1], [2]]",
+	}
+
+	for _, v := range invalid {
+		_, err = parseConfig(strings.NewReader(v))
+		if err == nil {
+			t.Fatalf("Expected %q to be rejected", v)
+		}
+	}
+}
+
+// setConfigHome points the configuration directory
+// to a temporary directory for the duration of the test
+func setConfigHome(t *testing.T) string {
+	t.Helper()
+
+	configHome, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldConfigHome, hadConfigHome := os.LookupEnv("XDG_CONFIG_HOME")
+
+	t.Cleanup(func() {
+		if hadConfigHome {
+			os.Setenv("XDG_CONFIG_HOME", oldConfigHome)
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME")
+		}
+
+		os.RemoveAll(configHome)
+	})
+
+	err = os.Setenv("XDG_CONFIG_HOME", configHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Join(configHome, "f2"), 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return configHome
+}
+
+// runWithConfig runs the app with the configuration applied
+// and returns the resulting context
+func runWithConfig(t *testing.T, args ...string) *cli.Context {
+	t.Helper()
+
+	var ctx *cli.Context
+
+	app := GetApp()
+	app.Action = func(c *cli.Context) error {
+		ctx = c
+		return applyConfig(c)
+	}
+
+	err := app.Run(append([]string{"f2"}, args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ctx
+}
+
+func TestApplyConfig(t *testing.T) {
+	configHome := setConfigHome(t)
+
+	workDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+
+		os.RemoveAll(workDir)
+	})
+
+	global := "hidden = true\nsort = \"size\"\nexclude = [\"a\", \"b\"]\n"
+	err = ioutil.WriteFile(
+		filepath.Join(configHome, "f2", configFileName),
+		[]byte(global),
+		0600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(
+		filepath.Join(workDir, dirConfigName),
+		[]byte("sort = \"mtime\"\n"),
+		0600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := runWithConfig(t, "-f", "x")
+	if !c.Bool("hidden") || c.String("sort") != "mtime" ||
+		!cmp.Equal(c.StringSlice("exclude"), []string{"a", "b"}) {
+		t.Fatalf(
+			"Expected the configuration to be applied, but got: %v %s %v",
+			c.Bool("hidden"),
+			c.String("sort"),
+			c.StringSlice("exclude"),
+		)
+	}
+
+	// flags on the command line take precedence
+	c = runWithConfig(t, "-f", "x", "--sort", "default", "-E", "c")
+	if c.String("sort") != "default" ||
+		!cmp.Equal(c.StringSlice("exclude"), []string{"c"}) {
+		t.Fatalf(
+			"Expected the command line flags to be used, but got: %s %v",
+			c.String("sort"),
+			c.StringSlice("exclude"),
+		)
+	}
+
+	// a flag that replaces a configured flag takes precedence over it
+	c = runWithConfig(t, "-f", "x", "--sortr", "size")
+	if c.String("sort") != "" || c.String("sortr") != "size" {
+		t.Fatalf(
+			"Expected --sortr to replace the configured sort, but got: %q %q",
+			c.String("sort"),
+			c.String("sortr"),
+		)
+	}
+}
+
+func TestDirConfigAllowlist(t *testing.T) {
+	setConfigHome(t)
+
+	workDir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	err = os.Chdir(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rejected := []string{
+		"exec = true",
+		"replace = \"{{xcmd.p}}\"\nxcmd = [\"p=touch PWNED\"]",
+		"editor = \"vim\"",
+		"webhook = \"http://localhost\"",
+		"undo = true",
+		"[alias]\nphotos = \"-f a -r b\"",
+	}
+
+	for _, v := range rejected {
+		err = ioutil.WriteFile(dirConfigName, []byte(v), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = readConfigFiles()
+		if err == nil {
+			t.Fatalf("Expected %q to be rejected in %s", v, dirConfigName)
+		}
+	}
+
+	allowed := "hidden = true\nsort = \"size\"\nexclude = [\"a\"]\n" +
+		"fix-conflicts = true\n[colors]\nok = \"#00FF00\"\n"
+
+	err = ioutil.WriteFile(dirConfigName, []byte(allowed), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,123 @@
+package f2
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	errEmptyCSV = errors.New("The CSV file does not contain any rows")
+
+	errMissingCSVSource = errors.New(
+		"The following rows in the CSV file refer to paths that do not exist",
+	)
+)
+
+// readCSV returns the rows in the specified CSV file. Rows may have
+// a different number of columns
+func readCSV(csvFile string) ([][]string, error) {
+	f, err := os.Open(csvFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse CSV file: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, errEmptyCSV
+	}
+
+	return records, nil
+}
+
+// csvPaths creates a Change for each row in the CSV file whose first column
+// points to an existing file or directory. Relative paths are resolved
+// from the directory that contains the CSV file. The first row is ignored
+// if it does not refer to an existing path since it is likely to be a
+// header row. Other rows that refer to missing paths are reported
+func (op *Operation) csvPaths() error {
+	records, err := readCSV(op.csvFile)
+	if err != nil {
+		return err
+	}
+
+	csvDir := filepath.Dir(op.csvFile)
+
+	var missing []string
+
+	for i, row := range records {
+		source := strings.TrimSpace(row[0])
+		if source == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(csvDir, source)
+		}
+
+		info, err := os.Stat(source)
+		if err != nil {
+			if i > 0 {
+				missing = append(missing, fmt.Sprintf("row %d (%s)", i+1, row[0]))
+			}
+
+			continue
+		}
+
+		name := filepath.Base(source)
+
+		op.paths = append(op.paths, Change{
+			BaseDir:        filepath.Dir(source),
+			Source:         name,
+			originalSource: name,
+			IsDir:          info.IsDir(),
+			csvRow:         row,
+		})
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", errMissingCSVSource, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// csvTargets sets the target of each match to the new name
+// in the second column of its CSV row. Matches without a new name
+// are left unchanged
+func (op *Operation) csvTargets() {
+	for i, ch := range op.matches {
+		ch.Target = ch.Source
+		if len(ch.csvRow) > 1 && strings.TrimSpace(ch.csvRow[1]) != "" {
+			ch.Target = filepath.Join(strings.TrimSpace(ch.csvRow[1]))
+		}
+
+		op.matches[i] = ch
+	}
+}
+
+// replaceCSVVariables replaces `{{csv.N}}` with the value in
+// the Nth column of the CSV row that refers to the file
+func replaceCSVVariables(input string, row []string) string {
+	return csvRegex.ReplaceAllStringFunc(input, func(token string) string {
+		n, err := strconv.Atoi(csvRegex.FindStringSubmatch(token)[1])
+		if err != nil || n < 1 || n > len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[n-1])
+	})
+}
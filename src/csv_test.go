@@ -0,0 +1,133 @@
+package f2
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"scan001.tif",
+		"scan002.tif",
+		"scan003.tif",
+	})
+
+	csvFile := filepath.Join(testDir, "assets.csv")
+	content := `filename,title,asset_id
+scan001.tif,Harbour at dawn,A-1001
+scan002.tif,,A-1002
+"scan003.tif","Market, Lagos",A-1003
+`
+
+	err := ioutil.WriteFile(csvFile, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []testCase{
+		{
+			name: "Rename using the second column",
+			want: []Change{
+				{
+					Source:  "scan001.tif",
+					BaseDir: testDir,
+					Target:  "Harbour at dawn",
+				},
+				{
+					Source:  "scan002.tif",
+					BaseDir: testDir,
+					Target:  "scan002.tif",
+				},
+				{
+					Source:  "scan003.tif",
+					BaseDir: testDir,
+					Target:  "Market, Lagos",
+				},
+			},
+			args: []string{"--csv", csvFile},
+		},
+		{
+			name: "Use CSV columns in the replacement",
+			want: []Change{
+				{
+					Source:  "scan001.tif",
+					BaseDir: testDir,
+					Target:  "A-1001 Harbour at dawn.tif",
+				},
+				{
+					Source:  "scan002.tif",
+					BaseDir: testDir,
+					Target:  "A-1002 Untitled.tif",
+				},
+				{
+					Source:  "scan003.tif",
+					BaseDir: testDir,
+					Target:  "A-1003 Market, Lagos.tif",
+				},
+			},
+			args: []string{
+				"--csv",
+				csvFile,
+				"-f",
+				".*",
+				"-r",
+				"{{csv.3}} {{csv.2|Untitled}}{{ext}}",
+			},
+		},
+		{
+			name: "Filter CSV rows with the find pattern",
+			want: []Change{
+				{
+					Source:  "scan003.tif",
+					BaseDir: testDir,
+					Target:  "A-1003.tif",
+				},
+			},
+			args: []string{
+				"--csv",
+				csvFile,
+				"-f",
+				"scan003",
+				"-r",
+				"{{csv.3}}",
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestCSVMissingSource(t *testing.T) {
+	testDir := setupFiles(t, []string{"scan001.tif"})
+
+	csvFile := filepath.Join(testDir, "assets.csv")
+	content := "filename,title\nscan001.tif,Harbour\nscan01.tif,Market\n"
+
+	err := ioutil.WriteFile(csvFile, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := &Operation{csvFile: csvFile}
+
+	err = op.csvPaths()
+	if !errors.Is(err, errMissingCSVSource) {
+		t.Fatalf("Expected error %v, but got: %v", errMissingCSVSource, err)
+	}
+
+	if !strings.Contains(err.Error(), "row 3 (scan01.tif)") {
+		t.Fatalf("Expected the missing row to be reported, but got: %v", err)
+	}
+}
+
+func TestReplaceCSVVariables(t *testing.T) {
+	row := []string{"a.jpg", " Title ", "42"}
+
+	got := replaceCSVVariables("{{csv.3}}-{{csv.2}}-{{csv.9}}", row)
+	if got != "42-Title-" {
+		t.Fatalf("Expected: %q, but got: %q", "42-Title-", got)
+	}
+}
@@ -0,0 +1,191 @@
+package f2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+var (
+	errTargetNotVacated = errors.New(
+		"the target path could not be vacated because it failed to be renamed",
+	)
+
+	errTempName = errors.New(
+		"an unused temporary name could not be found to break the rename cycle",
+	)
+)
+
+// renamer performs the renames in an order that vacates each target path
+// before another file is renamed to it. This allows chains such as
+// `a → b, b → c` and cycles such as `a → b, b → a` to be renamed in a
+// single operation. Cycles are broken by moving one of the files to a
+// temporary name first
+type renamer struct {
+	op      *Operation
+	sources map[string]int
+	current []string
+	state   []int
+	failed  []bool
+	renamed []Change
+	errs    []renameError
+}
+
+func newRenamer(op *Operation) *renamer {
+	r := &renamer{
+		op:      op,
+		sources: make(map[string]int),
+		current: make([]string, len(op.matches)),
+		state:   make([]int, len(op.matches)),
+		failed:  make([]bool, len(op.matches)),
+	}
+
+	for i, ch := range op.matches {
+		r.current[i] = filepath.Join(ch.BaseDir, ch.Source)
+
+		// links do not vacate the source path
+		if op.linkMode == "" && op.unlinkMode == "" {
+			r.sources[r.current[i]] = i
+		}
+	}
+
+	return r
+}
+
+// maxTempAttempts is the number of temporary names that are tried
+// before a file in a rename cycle is reported as failed
+const maxTempAttempts = 100
+
+// tempName returns a temporary name for the path. It includes the process
+// ID and a random suffix so that concurrent f2 processes and other programs
+// writing to the same directory are unlikely to choose the same name
+func tempName(path string) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("%s.f2tmp-%d-%s", path, os.Getpid(), hex.EncodeToString(b))
+}
+
+// moveToTemp moves a file in a rename cycle to an unused temporary path in
+// the same directory. Regular files on the operating system's filesystem are
+// hard linked to the temporary path first since this fails if the path
+// already exists, so a file created at the same time is never overwritten
+func moveToTemp(fsys FS, path string) (string, error) {
+	for n := 0; n < maxTempAttempts; n++ {
+		tmp := tempName(path)
+
+		if _, ok := fsys.(osFS); ok {
+			info, err := os.Lstat(path)
+			if err == nil && info.Mode().IsRegular() {
+				err = os.Link(path, tmp)
+				if err == nil {
+					err = os.Remove(path)
+					if err != nil {
+						_ = os.Remove(tmp)
+						return "", err
+					}
+
+					return tmp, nil
+				}
+
+				if errors.Is(err, os.ErrExist) {
+					continue
+				}
+
+				// fall back to renaming if hard links are not supported
+			}
+		}
+
+		_, err := fsys.Lstat(tmp)
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		return tmp, fsys.Rename(path, tmp)
+	}
+
+	return "", errTempName
+}
+
+// fail records the error that occurred while renaming the change
+func (r *renamer) fail(i int, err error) {
+	r.failed[i] = true
+	r.errs = append(r.errs, renameError{
+		entry: r.op.matches[i],
+		err:   err,
+	})
+
+	r.op.emitError(r.op.matches[i], err)
+}
+
+// visit renames the change at the specified index after the change whose
+// source is its target (if any) has been renamed
+func (r *renamer) visit(i int) {
+	if r.state[i] != unvisited {
+		if r.state[i] == visiting {
+			// break the cycle by moving the file out of the way
+			tmp, err := moveToTemp(r.op.filesystem(), r.current[i])
+			if err != nil {
+				r.fail(i, err)
+				return
+			}
+
+			delete(r.sources, r.current[i])
+			r.current[i] = tmp
+		}
+
+		return
+	}
+
+	ch := r.op.matches[i]
+	target := filepath.Join(ch.BaseDir, ch.Target)
+
+	// skip unchanged file names
+	if r.current[i] == target {
+		r.state[i] = visited
+		return
+	}
+
+	r.state[i] = visiting
+
+	if j, ok := r.sources[target]; ok && j != i {
+		r.visit(j)
+
+		if r.failed[j] {
+			r.state[i] = visited
+			r.fail(i, errTargetNotVacated)
+
+			return
+		}
+	}
+
+	r.state[i] = visited
+
+	if r.failed[i] {
+		return
+	}
+
+	delete(r.sources, r.current[i])
+
+	err := r.op.renameChange(ch, r.current[i], target)
+	if err != nil {
+		r.fail(i, err)
+	} else {
+		r.op.emitRename(ch)
+	}
+
+	r.renamed = append(r.renamed, ch)
+}
@@ -0,0 +1,119 @@
+package f2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameCycles(t *testing.T) {
+	testDir := setupFiles(t, nil)
+
+	files := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}
+	for _, v := range files {
+		err := os.WriteFile(filepath.Join(testDir, v), []byte(v), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	op := &Operation{
+		exec: true,
+		matches: []Change{
+			// a three way cycle
+			{Source: "a.txt", BaseDir: testDir, Target: "b.txt"},
+			{Source: "b.txt", BaseDir: testDir, Target: "c.txt"},
+			{Source: "c.txt", BaseDir: testDir, Target: "a.txt"},
+			// a chain
+			{Source: "d.txt", BaseDir: testDir, Target: "e.txt"},
+			{Source: "e.txt", BaseDir: testDir, Target: "f.txt"},
+		},
+	}
+
+	op.validate()
+	if len(op.conflicts) > 0 {
+		t.Fatalf("Expected no conflicts, but got: %v", op.conflicts)
+	}
+
+	op.rename(context.Background())
+	if len(op.errors) > 0 {
+		t.Fatalf("Expected no errors, but got: %v", op.errors)
+	}
+
+	want := map[string]string{
+		"a.txt": "c.txt",
+		"b.txt": "a.txt",
+		"c.txt": "b.txt",
+		"e.txt": "d.txt",
+		"f.txt": "e.txt",
+	}
+
+	entries, err := os.ReadDir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d files, but got %d", len(want), len(entries))
+	}
+
+	for name, content := range want {
+		b, err := os.ReadFile(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != content {
+			t.Fatalf(
+				"Expected %s to contain %s, but got: %s",
+				name,
+				content,
+				string(b),
+			)
+		}
+	}
+}
+
+func TestMoveToTemp(t *testing.T) {
+	testDir := setupFiles(t, nil)
+
+	file := filepath.Join(testDir, "a.txt")
+	dir := filepath.Join(testDir, "dir")
+
+	err := os.WriteFile(file, []byte("a"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Mkdir(dir, 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range []string{file, dir} {
+		tmp, err := moveToTemp(osFS{}, path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !tempSuffixRegex.MatchString(tmp) || filepath.Dir(tmp) != testDir {
+			t.Fatalf("Unexpected temporary path: %s", tmp)
+		}
+
+		if seen[tmp] {
+			t.Fatalf("Temporary path %s was allocated twice", tmp)
+		}
+
+		seen[tmp] = true
+
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Fatalf("Expected %s to be moved: %v", path, err)
+		}
+
+		if _, err := os.Lstat(tmp); err != nil {
+			t.Fatalf("Expected %s to exist: %v", tmp, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package f2
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// dateTokenRegex matches the date tokens in a layout that is passed to
+// the date filter such as `{{$1|date:DD-MM-YYYY>YYYY-MM-DD}}`
+var dateTokenRegex *regexp.Regexp
+
+func init() {
+	tokens := make([]string, 0, len(dateTokens))
+	for key := range dateTokens {
+		tokens = append(tokens, key)
+	}
+
+	// prefer the longest token (e.g. `MMMM` over `MM`)
+	sort.Slice(tokens, func(i, j int) bool {
+		return len(tokens[i]) > len(tokens[j])
+	})
+
+	dateTokenRegex = regexp.MustCompile(strings.Join(tokens, "|"))
+}
+
+// dateLayout converts a layout made up of date tokens (such as
+// `DD-MM-YYYY`) to the equivalent Go layout. Layouts that contain
+// digits are assumed to be Go layouts (such as `02-01-2006`)
+func dateLayout(layout string) string {
+	if strings.IndexFunc(layout, unicode.IsDigit) != -1 {
+		return layout
+	}
+
+	return dateTokenRegex.ReplaceAllStringFunc(layout, func(token string) string {
+		return dateTokens[token]
+	})
+}
+
+// reformatDate parses the value with the first layout and
+// formats it with the second one
+func reformatDate(value, from, to string) (string, error) {
+	t, err := time.Parse(dateLayout(from), strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf(
+			"Unable to parse '%s' as a date in the '%s' format",
+			value,
+			from,
+		)
+	}
+
+	return t.Format(dateLayout(to)), nil
+}
@@ -0,0 +1,61 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestReformatDate(t *testing.T) {
+	cases := []struct {
+		value string
+		from  string
+		to    string
+		want  string
+	}{
+		{"05-04-2021", "DD-MM-YYYY", "YYYY-MM-DD", "2021-04-05"},
+		{"05-04-2021", "02-01-2006", "2006-01-02", "2021-04-05"},
+		{"April 5 2021", "MMMM D YYYY", "YYYYMMDD", "20210405"},
+		{"20210405", "YYYYMMDD", "DDD, MMM D YY", "Mon, Apr 5 21"},
+	}
+
+	for _, tc := range cases {
+		got, err := reformatDate(tc.value, tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.value, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("Expected %s to be reformatted to %s, but got: %s", tc.value, tc.want, got)
+		}
+	}
+
+	if _, err := reformatDate("31-31-2021", "DD-MM-YYYY", "YYYY"); err == nil {
+		t.Fatal("Expected an error for an invalid date")
+	}
+}
+
+func TestDateFormatVariables(t *testing.T) {
+	testDir := setupFiles(t, []string{"report 05-04-2021.pdf"})
+
+	cases := []testCase{
+		{
+			name: "Reformat a captured date",
+			want: []Change{
+				{
+					Source:  "report 05-04-2021.pdf",
+					BaseDir: testDir,
+					Target:  "2021-04-05 report.pdf",
+				},
+			},
+			args: []string{
+				"-f",
+				"(\\w+) (\\d\\d-\\d\\d-\\d{4})",
+				"-r",
+				"{{$2|date:DD-MM-YYYY>YYYY-MM-DD}} $1",
+				"--strict",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,131 @@
+package f2
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	descendantsCount = "count"
+	descendantsList  = "list"
+)
+
+var errInvalidDescendants = errors.New(
+	"Invalid argument: --descendants must be set to 'count' or 'list'",
+)
+
+// descendantChange represents a path within a renamed directory
+// that changes as a result of the rename
+type descendantChange struct {
+	source string
+	target string
+}
+
+// dirDescendants returns the paths within each renamed directory along
+// with their new location. The paths are ordered by renamed directory
+func (op *Operation) dirDescendants() (map[string][]descendantChange, []string, error) {
+	descendants := make(map[string][]descendantChange)
+
+	var dirs []string
+
+	for _, ch := range op.matches {
+		if !ch.IsDir || ch.Source == ch.Target {
+			continue
+		}
+
+		dir, err := filepath.Abs(filepath.Join(ch.BaseDir, ch.Source))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dirs = append(dirs, dir)
+		descendants[dir] = []descendantChange{}
+
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if path == dir {
+				return nil
+			}
+
+			descendants[dir] = append(descendants[dir], descendantChange{
+				source: path,
+				target: op.renamedPath(path),
+			})
+
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return descendants, dirs, nil
+}
+
+// relativeToCwd returns the path relative to the current directory
+// if it is located within it
+func relativeToCwd(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
+}
+
+// printDescendants displays the number of paths within each renamed
+// directory that will change or lists the paths themselves so that
+// the extent of directory renames can be assessed before executing
+func (op *Operation) printDescendants(w io.Writer) error {
+	descendants, dirs, err := op.dirDescendants()
+	if err != nil || len(dirs) == 0 {
+		return err
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+
+	if op.descendants == descendantsList {
+		table.SetHeader([]string{"Descendant", "New path"})
+	} else {
+		table.SetHeader([]string{"Directory", "New directory", "Descendants"})
+	}
+
+	for _, dir := range dirs {
+		if op.descendants == descendantsList {
+			for _, v := range descendants[dir] {
+				table.Append([]string{
+					displayName(relativeToCwd(v.source)),
+					displayName(relativeToCwd(v.target)),
+				})
+			}
+
+			continue
+		}
+
+		table.Append([]string{
+			displayName(relativeToCwd(dir)),
+			displayName(relativeToCwd(op.renamedPath(dir))),
+			strconv.Itoa(len(descendants[dir])),
+		})
+	}
+
+	table.Render()
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package f2
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirDescendants(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"photos/2021/b.jpg",
+		"photos/a.jpg",
+		"other/c.jpg",
+	})
+
+	op := &Operation{
+		matches: []Change{
+			{BaseDir: testDir, Source: "photos", Target: "pictures", IsDir: true},
+			{BaseDir: testDir, Source: "other", Target: "other", IsDir: true},
+		},
+		descendants: descendantsList,
+	}
+
+	descendants, dirs, err := op.dirDescendants()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	photos := filepath.Join(testDir, "photos")
+	if len(dirs) != 1 || dirs[0] != photos {
+		t.Fatalf("Expected only %s to be renamed, but got: %v", photos, dirs)
+	}
+
+	want := filepath.Join(testDir, "pictures", "2021", "b.jpg")
+	if got := descendants[photos]; len(got) != 3 || got[1].target != want {
+		t.Fatalf("Expected 3 descendants including %s, but got: %v", want, got)
+	}
+
+	var buf bytes.Buffer
+	if err := op.printDescendants(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), relativeToCwd(want)) {
+		t.Fatalf("Expected the descendants to be listed, but got:\n%s", buf.String())
+	}
+}
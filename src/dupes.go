@@ -0,0 +1,166 @@
+package f2
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	caseCollision          = "case"
+	normalizationCollision = "normalization"
+	bothCollision          = "case and normalization"
+)
+
+// collision represents file names in a directory that refer to the
+// same file on a case-insensitive or normalization-insensitive filesystem
+type collision struct {
+	dir   string
+	names []string
+	kind  string
+}
+
+// collisionKey returns the form of the file name that is
+// compared when looking for collisions
+func collisionKey(name string) string {
+	return norm.NFC.String(strings.ToLower(name))
+}
+
+// collisionKind describes how the colliding names differ from one another
+func collisionKind(names []string) string {
+	sameNorm, sameCase := true, true
+	for _, v := range names[1:] {
+		if norm.NFC.String(v) != norm.NFC.String(names[0]) {
+			sameNorm = false
+		}
+
+		if strings.ToLower(v) != strings.ToLower(names[0]) {
+			sameCase = false
+		}
+	}
+
+	switch {
+	case sameNorm:
+		return normalizationCollision
+	case sameCase:
+		return caseCollision
+	}
+
+	return bothCollision
+}
+
+// findCollisions reports the existing file names in each of the
+// directories that collide case-insensitively or normalization-insensitively
+func findCollisions(
+	roots []string,
+	recursive, includeHidden bool,
+	maxDepth int,
+) ([]collision, error) {
+	paths := make(map[string][]os.DirEntry)
+	for _, v := range roots {
+		de, err := os.ReadDir(v)
+		if err != nil {
+			return nil, err
+		}
+
+		paths[v] = de
+	}
+
+	hidden := hiddenPolicy{files: includeHidden, dirs: includeHidden}
+
+	var err error
+	if recursive {
+		paths, err = walk(osFS{}, paths, hidden, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var collisions []collision
+	for dir, entries := range paths {
+		entries, err = removeHidden(entries, dir, hidden)
+		if err != nil {
+			return nil, err
+		}
+
+		groups := make(map[string][]string)
+		for _, e := range entries {
+			key := collisionKey(e.Name())
+			groups[key] = append(groups[key], e.Name())
+		}
+
+		for _, names := range groups {
+			if len(names) < 2 {
+				continue
+			}
+
+			sort.Strings(names)
+			collisions = append(collisions, collision{
+				dir:   dir,
+				names: names,
+				kind:  collisionKind(names),
+			})
+		}
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		if collisions[i].dir != collisions[j].dir {
+			return collisions[i].dir < collisions[j].dir
+		}
+
+		return collisions[i].names[0] < collisions[j].names[0]
+	})
+
+	return collisions, nil
+}
+
+// printCollisions displays the colliding file names in a table
+func printCollisions(w io.Writer, collisions []collision) {
+	if len(collisions) == 0 {
+		fmt.Fprintln(w, "No colliding file names found")
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Directory", "Names", "Collision"})
+	table.SetAutoWrapText(false)
+
+	for _, v := range collisions {
+		table.Append([]string{
+			v.dir,
+			strings.Join(v.names, ", "),
+			v.kind,
+		})
+	}
+
+	table.Render()
+}
+
+// printDupes reports the existing file names that collide in
+// the specified directories (or the current directory)
+func printDupes(c *cli.Context) error {
+	roots := c.Args().Slice()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	collisions, err := findCollisions(
+		roots,
+		c.Bool("recursive"),
+		c.Bool("hidden"),
+		c.Int("max-depth"),
+	)
+	if err != nil {
+		return err
+	}
+
+	printCollisions(os.Stdout, collisions)
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package f2
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindCollisions(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"report.pdf",
+		"Report.PDF",
+		"caf\u00e9.txt",
+		"cafe\u0301.txt",
+		"R\u00e9sum\u00e9.doc",
+		"re\u0301sume\u0301.doc",
+		"unique.txt",
+		"sub/photo.jpg",
+		"sub/PHOTO.jpg",
+		".hidden/a.txt",
+		".hidden/A.txt",
+	})
+
+	topLevel := []collision{
+		{
+			dir:   testDir,
+			names: []string{"Report.PDF", "report.pdf"},
+			kind:  caseCollision,
+		},
+		{
+			dir:   testDir,
+			names: []string{"R\u00e9sum\u00e9.doc", "re\u0301sume\u0301.doc"},
+			kind:  bothCollision,
+		},
+		{
+			dir:   testDir,
+			names: []string{"cafe\u0301.txt", "caf\u00e9.txt"},
+			kind:  normalizationCollision,
+		},
+	}
+
+	hidden := collision{
+		dir:   filepath.Join(testDir, ".hidden"),
+		names: []string{"A.txt", "a.txt"},
+		kind:  caseCollision,
+	}
+
+	sub := collision{
+		dir:   filepath.Join(testDir, "sub"),
+		names: []string{"PHOTO.jpg", "photo.jpg"},
+		kind:  caseCollision,
+	}
+
+	cases := []struct {
+		name      string
+		recursive bool
+		hidden    bool
+		want      []collision
+	}{
+		{
+			name: "Report the collisions in the top-level directory",
+			want: topLevel,
+		},
+		{
+			name:      "Report the collisions in subdirectories",
+			recursive: true,
+			want:      append(append([]collision{}, topLevel...), sub),
+		},
+		{
+			name:      "Report the collisions in hidden directories",
+			recursive: true,
+			hidden:    true,
+			want:      append(append([]collision{}, topLevel...), hidden, sub),
+		},
+	}
+
+	for _, tc := range cases {
+		got, err := findCollisions([]string{testDir}, tc.recursive, tc.hidden, 0)
+		if err != nil {
+			t.Fatalf("Test (%s) — Unexpected error: %v", tc.name, err)
+		}
+
+		if !cmp.Equal(tc.want, got, cmp.AllowUnexported(collision{})) {
+			t.Fatalf(
+				"Test (%s) — Collisions: %s",
+				tc.name,
+				cmp.Diff(tc.want, got, cmp.AllowUnexported(collision{})),
+			)
+		}
+	}
+}
+
+// captureStdout returns what is written to the standard output
+// while the command line arguments are run
+func captureStdout(t *testing.T, args []string) (string, error) {
+	t.Helper()
+
+	rescueStdout := os.Stdout
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	os.Stdout = w
+
+	out := make(chan []byte)
+
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- b
+	}()
+
+	runErr := runApp(GetApp(), args)
+
+	w.Close()
+	os.Stdout = rescueStdout
+
+	return string(<-out), runErr
+}
+
+func TestPrintDupes(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"report.pdf",
+		"Report.PDF",
+		"caf\u00e9.txt",
+		"cafe\u0301.txt",
+		"unique.txt",
+	})
+
+	cleanDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"dupes", testDir},
+			want: []string{
+				"DIRECTORY",
+				"NAMES",
+				"COLLISION",
+				"Report.PDF, report.pdf",
+				"cafe\u0301.txt, caf\u00e9.txt",
+				caseCollision,
+				normalizationCollision,
+			},
+		},
+		{
+			args: []string{"dupes", cleanDir},
+			want: []string{"No colliding file names found"},
+		},
+	}
+
+	for _, tc := range cases {
+		out, err := captureStdout(t, append([]string{os.Args[0]}, tc.args...))
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.args, err)
+		}
+
+		for _, v := range tc.want {
+			if !strings.Contains(out, v) {
+				t.Fatalf("%v: expected output to contain %q, but got:\n%s", tc.args, v, out)
+			}
+		}
+
+		if strings.Contains(out, "unique.txt") {
+			t.Fatalf("%v: expected unique.txt to be left out of the output:\n%s", tc.args, out)
+		}
+	}
+}
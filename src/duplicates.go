@@ -0,0 +1,92 @@
+package f2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+var errInvalidOnDuplicate = errors.New(
+	"Invalid argument: --on-duplicate must be set to 'prefix', 'counter' or 'skip'",
+)
+
+const (
+	duplicatePrefix  = "prefix"
+	duplicateCounter = "counter"
+	duplicateSkip    = "skip"
+)
+
+// targetSource represents a match that is renamed to a particular target
+type targetSource struct {
+	source string
+	index  int
+}
+
+// isDuplicateBaseName reports whether the sources that share a target are
+// files with the same name from different directories (such as when
+// moving `2020/IMG_0001.jpg` and `2021/IMG_0001.jpg` into one directory)
+func isDuplicateBaseName(sources []targetSource) bool {
+	dirs := make(map[string]bool)
+	for _, v := range sources {
+		if filepath.Base(v.source) != filepath.Base(sources[0].source) {
+			return false
+		}
+
+		dirs[filepath.Dir(v.source)] = true
+	}
+
+	return len(dirs) > 1
+}
+
+// resolveDuplicateBaseNames resolves a duplicate base name conflict
+// according to the --on-duplicate option. Files are prefixed with the name
+// of their source directory, suffixed with a counter, or left unchanged
+func (op *Operation) resolveDuplicateBaseNames(
+	sources []targetSource,
+	m map[string][]struct {
+		source string
+		index  int
+	},
+) {
+	for i, v := range sources {
+		ch := op.matches[v.index]
+
+		switch op.onDuplicate {
+		case duplicateSkip:
+			op.matches[v.index].Target = ch.Source
+			continue
+		case duplicateCounter:
+			if i == 0 {
+				continue
+			}
+		case duplicatePrefix:
+			prefix := filepath.Base(filepath.Dir(v.source))
+			ch.Target = filepath.Join(
+				filepath.Dir(ch.Target),
+				prefix+"_"+filepath.Base(ch.Target),
+			)
+
+			target := filepath.Join(ch.BaseDir, ch.Target)
+			if _, ok := m[target]; !ok {
+				_, err := op.filesystem().Stat(target)
+				if errors.Is(err, os.ErrNotExist) {
+					m[target] = nil
+					op.matches[v.index].Target = ch.Target
+					continue
+				}
+			}
+		}
+
+		// fall back to a numbered suffix if the target is still taken
+		dir := filepath.Dir(ch.Target)
+		str := getNewPath(
+			op.filesystem(),
+			filepath.Base(ch.Target),
+			ch.BaseDir,
+			m,
+		)
+		str = filepath.Join(dir, str)
+		m[filepath.Join(ch.BaseDir, str)] = nil
+		op.matches[v.index].Target = str
+	}
+}
@@ -0,0 +1,74 @@
+package f2
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// syncDir flushes the entries of the directory to disk
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// durableDirs returns the directories whose entries were modified by the
+// successful renames. The parents of the directories created during the
+// operation are included since new entries were added to them
+func (op *Operation) durableDirs() []string {
+	set := make(map[string]bool)
+	for _, ch := range op.matches {
+		if op.failed(ch) {
+			continue
+		}
+
+		set[filepath.Dir(filepath.Join(ch.BaseDir, ch.Source))] = true
+
+		dir := filepath.Dir(filepath.Join(ch.BaseDir, ch.Target))
+		for {
+			set[dir] = true
+
+			parent := filepath.Dir(dir)
+			if parent == dir || !op.createdDir(dir) {
+				break
+			}
+
+			dir = parent
+		}
+	}
+
+	dirs := make([]string, 0, len(set))
+	for k := range set {
+		dirs = append(dirs, k)
+	}
+
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// syncDirs flushes the directories affected by the renames to disk so that
+// the new names survive a crash that occurs right after the operation.
+// Directories cannot be synced on Windows where NTFS journals the
+// metadata changes instead
+func (op *Operation) syncDirs() error {
+	if _, ok := op.filesystem().(osFS); !ok || runtime.GOOS == windows {
+		return nil
+	}
+
+	for _, dir := range op.durableDirs() {
+		err := syncDir(dir)
+		if err != nil {
+			return fmt.Errorf("Unable to sync directory '%s': %w", dir, err)
+		}
+	}
+
+	return nil
+}
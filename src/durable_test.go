@@ -0,0 +1,45 @@
+package f2
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDurableDirs(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	op := &Operation{
+		exec:          true,
+		quiet:         true,
+		transactional: true,
+		durable:       true,
+		workingDir:    testDir,
+		matches: []Change{
+			{Source: "a.txt", BaseDir: testDir, Target: "new/nested/a.txt"},
+			{Source: "b.txt", BaseDir: testDir, Target: "b2.txt"},
+		},
+	}
+
+	op.rename(context.Background())
+	if len(op.errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", op.errors)
+	}
+
+	want := []string{
+		testDir,
+		filepath.Join(testDir, "new"),
+		filepath.Join(testDir, "new", "nested"),
+	}
+
+	got := op.durableDirs()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected the affected directories to be %v, but got: %v", want, got)
+	}
+
+	err := op.syncDirs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package f2
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var errInvalidEditLine = errors.New(
+	"each line must contain the number of a file followed by its new name",
+)
+
+const editListHeader = `# Edit the new names below and save the file to continue.
+# Each line contains the number of a file followed by its new name.
+# Spaces, backslashes and newlines in names are escaped with a backslash.
+# Delete a line to leave the file unchanged.
+`
+
+// editorCommand returns the command used to edit the plan which is
+// taken from $VISUAL or $EDITOR if set
+func editorCommand() string {
+	for _, v := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(v); editor != "" {
+			return editor
+		}
+	}
+
+	if runtime.GOOS == windows {
+		return "notepad"
+	}
+
+	return "vi"
+}
+
+// writeEditList writes the target of each match on a separate line
+// preceded by its number
+func writeEditList(w io.Writer, matches []Change) error {
+	bw := bufio.NewWriter(w)
+
+	_, err := bw.WriteString(editListHeader)
+	if err != nil {
+		return err
+	}
+
+	for i, ch := range matches {
+		_, err = fmt.Fprintf(bw, "%d\t%s\n", i+1, escapePlanPath(ch.Target, formatQmv))
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// parseEditList returns the targets in the edited list mapped to the
+// index of their match. Blank lines and comments are ignored
+func parseEditList(r io.Reader, n int) (map[int]string, error) {
+	targets := make(map[int]string)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" ||
+			strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+
+		fields := splitPlanLine(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: %w", line, errInvalidEditLine)
+		}
+
+		num, err := strconv.Atoi(fields[0])
+		if err != nil || num < 1 || num > n {
+			return nil, fmt.Errorf("line %d: %w", line, errInvalidEditLine)
+		}
+
+		targets[num-1] = fields[1]
+	}
+
+	return targets, scanner.Err()
+}
+
+// editTargets opens the computed targets in a text editor and replaces
+// them with the names saved by the user so that the few names that the
+// find and replace patterns got wrong can be fixed by hand. Files whose
+// line was deleted are left unchanged
+func (op *Operation) editTargets() error {
+	f, err := os.CreateTemp("", "f2-edit-*.txt")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	err = writeEditList(f, op.matches)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(op.editor)
+	if len(fields) == 0 {
+		fields = []string{editorCommand()}
+	}
+
+	cmd := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("Editor '%s' failed: %w", op.editor, err)
+	}
+
+	edited, err := os.Open(f.Name())
+	if err != nil {
+		return err
+	}
+	defer edited.Close()
+
+	targets, err := parseEditList(edited, len(op.matches))
+	if err != nil {
+		return fmt.Errorf("Unable to parse the edited names: %w", err)
+	}
+
+	for i, ch := range op.matches {
+		target, ok := targets[i]
+		if !ok {
+			target = ch.Source
+		}
+
+		op.matches[i].Target = target
+	}
+
+	return nil
+}
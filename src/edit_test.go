@@ -0,0 +1,80 @@
+package f2
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEditList(t *testing.T) {
+	matches := []Change{
+		{Source: "a.txt", Target: "one two.txt"},
+		{Source: "b.txt", Target: "b.txt"},
+		{Source: "c.txt", Target: "c.md"},
+	}
+
+	var buf bytes.Buffer
+	err := writeEditList(&buf, matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(buf.String(), "1\tone\\ two.txt\n2\tb.txt\n3\tc.md\n") {
+		t.Fatalf("Unexpected edit list:\n%s", buf.String())
+	}
+
+	edited := strings.Replace(buf.String(), "2\tb.txt\n", "", 1)
+	edited = strings.Replace(edited, "c.md", "three.md", 1)
+
+	targets, err := parseEditList(strings.NewReader(edited), len(matches))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]string{0: "one two.txt", 2: "three.md"}
+	if len(targets) != len(want) {
+		t.Fatalf("Expected %v, but got %v", want, targets)
+	}
+
+	for k, v := range want {
+		if targets[k] != v {
+			t.Fatalf("Expected %v, but got %v", want, targets)
+		}
+	}
+
+	for _, v := range []string{"4\tx.txt\n", "x\ty.txt\n", "1\n"} {
+		_, err = parseEditList(strings.NewReader(v), len(matches))
+		if err == nil {
+			t.Fatalf("Expected %q to be rejected", v)
+		}
+	}
+}
+
+func TestEditTargets(t *testing.T) {
+	// the editor is simulated with GNU sed
+	if _, err := exec.LookPath("sed"); err != nil || runtime.GOOS != "linux" {
+		t.Skip("GNU sed is not available")
+	}
+
+	op := &Operation{
+		editor: "sed -i -e s/b.md/fixed.md/ -e /c.md/d",
+		matches: []Change{
+			{Source: "a.txt", Target: "a.md"},
+			{Source: "b.txt", Target: "b.md"},
+			{Source: "c.txt", Target: "c.md"},
+		},
+	}
+
+	err := op.editTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"a.md", "fixed.md", "c.txt"} {
+		if op.matches[i].Target != want {
+			t.Fatalf("Expected %s, but got %s", want, op.matches[i].Target)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package f2
+
+import "strings"
+
+// Escape sequences allow literal braces and dollar signs to appear in the
+// new file names without being interpreted as variables or capture
+// references:
+//
+//	\{ produces a literal '{'
+//	\} produces a literal '}'
+//	\$ produces a literal '$'
+//
+// Each escape sequence is swapped for a placeholder from the Unicode
+// private use area before any expansion takes place and restored
+// afterwards
+var (
+	escapeReplacer = strings.NewReplacer(
+		`\{`, "\uE000",
+		`\}`, "\uE001",
+		`\$`, "\uE002",
+	)
+	unescapeReplacer = strings.NewReplacer(
+		"\uE000", "{",
+		"\uE001", "}",
+		"\uE002", "$",
+	)
+)
+
+// escapeLiterals replaces the escape sequences in the replacement string
+// with placeholders that are ignored by the variable and regex expansion
+func escapeLiterals(replacement string) string {
+	return escapeReplacer.Replace(replacement)
+}
+
+// unescapeLiterals restores the characters represented by the placeholders
+func unescapeLiterals(str string) string {
+	return unescapeReplacer.Replace(str)
+}
@@ -0,0 +1,50 @@
+package f2
+
+// Events contains the callbacks that are invoked as an operation
+// progresses so that programs embedding f2 can display live progress.
+// Any of the callbacks may be nil
+type Events struct {
+	// OnMatch is called for each file that matches the find pattern
+	OnMatch func(ch Change)
+	// OnRename is called after a file is renamed successfully
+	OnRename func(ch Change)
+	// OnError is called when a file could not be renamed
+	OnError func(ch Change, err error)
+	// OnConflict is called for each conflict detected in the operation.
+	// The kind is the conflict type used in JSON output (e.g. file_exists)
+	OnConflict func(kind string, sources []string, target string)
+}
+
+// SetEvents registers the callbacks for the events of the operation
+func (op *Operation) SetEvents(events Events) {
+	op.events = events
+}
+
+func (op *Operation) emitMatch(ch Change) {
+	if op.events.OnMatch != nil {
+		op.events.OnMatch(ch)
+	}
+}
+
+func (op *Operation) emitRename(ch Change) {
+	if op.events.OnRename != nil {
+		op.events.OnRename(ch)
+	}
+}
+
+func (op *Operation) emitError(ch Change, err error) {
+	if op.events.OnError != nil {
+		op.events.OnError(ch, err)
+	}
+}
+
+// emitConflicts reports the detected conflicts ordered by their type
+func (op *Operation) emitConflicts() {
+	if op.events.OnConflict == nil {
+		return
+	}
+
+	for _, v := range op.summaryConflicts() {
+		op.events.OnConflict(v.Type, v.Sources, v.Target)
+	}
+}
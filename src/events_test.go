@@ -0,0 +1,70 @@
+package f2
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvents(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt", "c.md"})
+
+	var matched, renamed, failed, conflicts []string
+
+	op := &Operation{
+		exec:          true,
+		searchRegexes: []*regexp.Regexp{regexp.MustCompile(`\.txt$`)},
+		paths: []Change{
+			{Source: "a.txt", BaseDir: testDir},
+			{Source: "b.txt", BaseDir: testDir},
+			{Source: "c.md", BaseDir: testDir},
+		},
+	}
+
+	op.SetEvents(Events{
+		OnMatch: func(ch Change) {
+			matched = append(matched, ch.Source)
+		},
+		OnRename: func(ch Change) {
+			renamed = append(renamed, ch.Source)
+		},
+		OnError: func(ch Change, err error) {
+			failed = append(failed, ch.Source)
+		},
+		OnConflict: func(kind string, sources []string, target string) {
+			conflicts = append(conflicts, kind)
+		},
+	})
+
+	err := op.findMatches(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op.matches[0].Target = "c.md"
+	op.matches[1].Target = "d.txt"
+	op.matches = append(op.matches, Change{
+		Source:  "missing.txt",
+		BaseDir: testDir,
+		Target:  "e.txt",
+	})
+
+	op.validate()
+	op.emitConflicts()
+	op.rename(context.Background())
+
+	want := [][]string{
+		{"a.txt", "b.txt"},
+		{"a.txt", "b.txt"},
+		{"missing.txt"},
+		{"file_exists"},
+	}
+
+	got := [][]string{matched, renamed, failed, conflicts}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Events: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,233 @@
+package f2
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	formatMmv = "mmv"
+	formatQmv = "qmv"
+)
+
+var (
+	errInvalidPlanFormat = errors.New(
+		"Invalid argument: the plan format must be set to 'mmv' or 'qmv'",
+	)
+
+	errInvalidPlanLine = errors.New(
+		"Each line in the plan must contain a source and a target",
+	)
+)
+
+// mmvSpecialChars are the characters that must be escaped in mmv
+// patterns so that they are matched literally
+const mmvSpecialChars = `*?[];#`
+
+// escapePlanPath escapes the backslashes, whitespace and newlines in the
+// path (and the wildcard characters in the mmv format) with a backslash
+// so that each line in the plan can be split into a source and target
+func escapePlanPath(path, format string) string {
+	// the escaped characters are ASCII so the path is processed byte by
+	// byte to preserve any bytes that are not valid UTF-8
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\n':
+			b.WriteString(`\n`)
+			continue
+		case c == '\\', c == ' ', c == '\t':
+		case format == formatMmv && strings.IndexByte(mmvSpecialChars, c) != -1:
+		default:
+			b.WriteByte(c)
+			continue
+		}
+
+		b.WriteByte('\\')
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// splitPlanLine splits a line in an mmv or qmv plan into its unescaped
+// fields which are separated by unescaped whitespace
+func splitPlanLine(line string) []string {
+	var fields []string
+	var b strings.Builder
+	var escaped, inField bool
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escaped:
+			if c == 'n' {
+				c = '\n'
+			}
+
+			b.WriteByte(c)
+			escaped, inField = false, true
+		case c == '\\':
+			escaped = true
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+			}
+
+			inField = false
+		default:
+			b.WriteByte(c)
+			inField = true
+		}
+	}
+
+	if inField {
+		fields = append(fields, b.String())
+	}
+
+	return fields
+}
+
+// exportPlan writes the source and target of each change that renames a
+// file in a format understood by other renaming tools. The `mmv` format
+// contains a `from to` pair on each line and the `qmv` format uses the
+// dual-column layout of the edit lists of qmv. Paths are relative to the
+// current working directory
+func (op *Operation) exportPlan(w io.Writer) error {
+	sep := " "
+	if op.exportFormat == formatQmv {
+		sep = "\t"
+	}
+
+	for _, ch := range op.matches {
+		if ch.Source == ch.Target {
+			continue
+		}
+
+		source := filepath.Join(ch.BaseDir, ch.Source)
+		target := filepath.Join(ch.BaseDir, ch.Target)
+
+		_, err := fmt.Fprintf(
+			w,
+			"%s%s%s\n",
+			escapePlanPath(source, op.exportFormat),
+			sep,
+			escapePlanPath(target, op.exportFormat),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printNull writes the source and target of each change that renames a file
+// terminated by NUL characters so that the plan can be consumed by tools
+// such as `xargs -0` even when the file names contain newlines
+func (op *Operation) printNull(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, ch := range op.matches {
+		if ch.Source == ch.Target {
+			continue
+		}
+
+		source := filepath.Join(ch.BaseDir, ch.Source)
+		target := filepath.Join(ch.BaseDir, ch.Target)
+
+		_, err := bw.WriteString(source + "\x00" + target + "\x00")
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// parsePlan returns the changes described by an mmv or qmv plan. Relative
+// paths are resolved from the directory that contains the plan and
+// blank lines are ignored
+func parsePlan(r io.Reader, planDir string) ([]Change, error) {
+	var changes []Change
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := splitPlanLine(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: %w", n, errInvalidPlanLine)
+		}
+
+		source, target := fields[0], fields[1]
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(planDir, source)
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(planDir, target)
+		}
+
+		baseDir := filepath.Dir(source)
+
+		rel, err := filepath.Rel(baseDir, target)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		name := filepath.Base(source)
+
+		changes = append(changes, Change{
+			BaseDir:        baseDir,
+			Source:         name,
+			originalSource: name,
+			Target:         rel,
+		})
+	}
+
+	return changes, scanner.Err()
+}
+
+// importPlan replaces the matches with the changes in the imported plan
+// so that they are subjected to the same conflict checks as any other
+// operation
+func (op *Operation) importPlan() error {
+	f, err := os.Open(op.importFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	changes, err := parsePlan(f, filepath.Dir(op.importFile))
+	if err != nil {
+		return fmt.Errorf("Unable to parse plan: %w", err)
+	}
+
+	op.matches = changes
+	op.mapRoots()
+
+	for i, ch := range op.matches {
+		info, err := op.filesystem().Stat(filepath.Join(ch.BaseDir, ch.Source))
+		if err != nil {
+			return err
+		}
+
+		op.matches[i].IsDir = info.IsDir()
+
+		// directories are renamed after their contents
+		if info.IsDir() {
+			op.includeDir = true
+		}
+	}
+
+	return nil
+}
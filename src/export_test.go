@@ -0,0 +1,119 @@
+package f2
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExportPlan(t *testing.T) {
+	op := &Operation{
+		matches: []Change{
+			{BaseDir: "photos", Source: "a b.jpg", Target: "c*d.jpg"},
+			{BaseDir: "photos", Source: "same.jpg", Target: "same.jpg"},
+			{BaseDir: "docs", Source: "x\ny.txt", Target: `x\y.txt`},
+		},
+	}
+
+	cases := map[string]string{
+		formatMmv: "photos/a\\ b.jpg photos/c\\*d.jpg\ndocs/x\\ny.txt docs/x\\\\y.txt\n",
+		formatQmv: "photos/a\\ b.jpg\tphotos/c*d.jpg\ndocs/x\\ny.txt\tdocs/x\\\\y.txt\n",
+	}
+
+	for format, want := range cases {
+		op.exportFormat = format
+
+		var buf bytes.Buffer
+		err := op.exportPlan(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != want {
+			t.Fatalf("%s: expected %q, but got %q", format, want, buf.String())
+		}
+
+		changes, err := parsePlan(&buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Change{
+			{BaseDir: "photos", Source: "a b.jpg", Target: "c*d.jpg"},
+			{BaseDir: "docs", Source: "x\ny.txt", Target: `x\y.txt`},
+		}
+
+		if !cmp.Equal(want, changes, cmpopts.IgnoreUnexported(Change{})) {
+			t.Fatalf(
+				"%s: round trip failed: %s",
+				format,
+				cmp.Diff(want, changes, cmpopts.IgnoreUnexported(Change{})),
+			)
+		}
+	}
+}
+
+func TestParsePlanInvalidLine(t *testing.T) {
+	_, err := parsePlan(strings.NewReader("a.txt b.txt\nc.txt\n"), "")
+	if err == nil {
+		t.Fatal("Expected an error for a line without a target")
+	}
+}
+
+func TestImportPlan(t *testing.T) {
+	testDir := setupFiles(t, []string{"one.txt", "two words.txt"})
+
+	plan := filepath.Join(testDir, "plan.txt")
+	content := "one.txt\t1.txt\ntwo\\ words.txt 2.txt\n\n"
+
+	err := ioutil.WriteFile(plan, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []testCase{
+		{
+			name: "Import a plan in the qmv and mmv formats",
+			want: []Change{
+				{
+					Source:  "one.txt",
+					BaseDir: testDir,
+					Target:  "1.txt",
+				},
+				{
+					Source:  "two words.txt",
+					BaseDir: testDir,
+					Target:  "2.txt",
+				},
+			},
+			args: []string{"--import", plan},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestPrintNull(t *testing.T) {
+	op := &Operation{
+		matches: []Change{
+			{BaseDir: "docs", Source: "a\nb.txt", Target: "ab.txt"},
+			{BaseDir: "docs", Source: "same.txt", Target: "same.txt"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := op.printNull(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "docs/a\nb.txt\x00docs/ab.txt\x00"
+	if buf.String() != want {
+		t.Fatalf("Expected %q, but got %q", want, buf.String())
+	}
+}
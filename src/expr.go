@@ -0,0 +1,200 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	errExprWithFind = errors.New(
+		"Invalid argument: --expr cannot be combined with -f or -r",
+	)
+
+	exprFlagsRegex = regexp.MustCompile(`^(?:[giI]|\d+)*$`)
+)
+
+// substitution is a sed-style expression such as `s/find/replace/gi`
+type substitution struct {
+	find        string
+	replacement string
+	// replace every occurrence starting from the nth one
+	global     bool
+	ignoreCase bool
+	// the occurrence that is replaced (the first one by default)
+	nth int
+}
+
+// splitExpr splits the expression at each unescaped delimiter. Escaped
+// delimiters are unescaped while other escape sequences are preserved
+func splitExpr(expr string, delim rune) []string {
+	var parts []string
+
+	var b strings.Builder
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+
+		if r == '\\' && i+size < len(expr) {
+			next, nsize := utf8.DecodeRuneInString(expr[i+size:])
+			if next != delim {
+				b.WriteRune(r)
+			}
+
+			b.WriteRune(next)
+			i += size + nsize
+
+			continue
+		}
+
+		if r == delim {
+			parts = append(parts, b.String())
+			b.Reset()
+		} else {
+			b.WriteRune(r)
+		}
+
+		i += size
+	}
+
+	return append(parts, b.String())
+}
+
+// sedReplacement converts a sed replacement string to the syntax used by
+// f2. `&` and `\1`-`\9` refer to the match and its capture groups while a
+// dollar sign is literal
+func sedReplacement(replacement string) string {
+	var b strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+
+		switch {
+		case c == '\\' && i+1 < len(replacement):
+			next := replacement[i+1]
+
+			switch {
+			case next >= '0' && next <= '9':
+				b.WriteString("${" + string(next) + "}")
+			case next == '&' || next == '\\':
+				b.WriteByte(next)
+			default:
+				// f2 escape sequences such as `\{` are preserved
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+
+			i++
+		case c == '&':
+			b.WriteString("${0}")
+		case c == '$':
+			b.WriteString(`\$`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// parseExpr parses a sed-style substitution expression. Any character may
+// be used as the delimiter (e.g. `s|a/b|c|`). The supported flags are `g`
+// (replace all occurrences), `i` or `I` (ignore case) and a number that
+// selects the occurrence to replace
+func parseExpr(expr string) (substitution, error) {
+	var s substitution
+
+	invalid := fmt.Errorf(
+		"Invalid expression %q: expected the form s/find/replace/flags",
+		expr,
+	)
+
+	if len(expr) < 2 || expr[0] != 's' {
+		return s, invalid
+	}
+
+	delim, size := utf8.DecodeRuneInString(expr[1:])
+	if delim == '\\' || delim == '\n' {
+		return s, invalid
+	}
+
+	parts := splitExpr(expr[1+size:], delim)
+	if len(parts) != 3 || parts[0] == "" || !exprFlagsRegex.MatchString(parts[2]) {
+		return s, invalid
+	}
+
+	s.find = parts[0]
+	s.replacement = sedReplacement(parts[1])
+	s.nth = 1
+
+	flags := parts[2]
+	for len(flags) > 0 {
+		switch flags[0] {
+		case 'g':
+			s.global = true
+		case 'i', 'I':
+			s.ignoreCase = true
+		default:
+			end := strings.IndexFunc(flags, func(r rune) bool {
+				return r < '0' || r > '9'
+			})
+			if end == -1 {
+				end = len(flags)
+			}
+
+			n, err := strconv.Atoi(flags[:end])
+			if err != nil || n < 1 {
+				return s, invalid
+			}
+
+			s.nth = n
+			flags = flags[end:]
+
+			continue
+		}
+
+		flags = flags[1:]
+	}
+
+	return s, nil
+}
+
+// parseExprs parses each of the sed-style expressions
+func parseExprs(exprs []string) ([]substitution, error) {
+	substitutions := make([]substitution, len(exprs))
+	for i, v := range exprs {
+		s, err := parseExpr(v)
+		if err != nil {
+			return nil, err
+		}
+
+		substitutions[i] = s
+	}
+
+	return substitutions, nil
+}
+
+// substitute replaces the occurrences of the pattern in the file name that
+// are selected by the flags of the expression
+func (s substitution) substitute(
+	r *regexp.Regexp,
+	fileName, replacement string,
+) string {
+	var output []byte
+	last := 0
+	for i, m := range r.FindAllStringSubmatchIndex(fileName, -1) {
+		n := i + 1
+		if n < s.nth || (n > s.nth && !s.global) {
+			continue
+		}
+
+		output = append(output, fileName[last:m[0]]...)
+		output = r.ExpandString(output, replacement, fileName, m)
+		last = m[1]
+	}
+
+	output = append(output, fileName[last:]...)
+
+	return string(output)
+}
@@ -0,0 +1,99 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	cases := []struct {
+		expr string
+		want substitution
+	}{
+		{
+			expr: "s/a/b/",
+			want: substitution{find: "a", replacement: "b", nth: 1},
+		},
+		{
+			expr: "s/(\\d+)-(\\d+)/\\2-\\1 &/gi",
+			want: substitution{
+				find:        `(\d+)-(\d+)`,
+				replacement: "${2}-${1} ${0}",
+				nth:         1,
+				global:      true,
+				ignoreCase:  true,
+			},
+		},
+		{
+			expr: "s|a/b|c\\|d $1|2g",
+			want: substitution{
+				find:        "a/b",
+				replacement: `c|d \$1`,
+				nth:         2,
+				global:      true,
+			},
+		},
+		{
+			expr: "s/x\\/y/\\&\\{/12",
+			want: substitution{find: "x/y", replacement: `&\{`, nth: 12},
+		},
+	}
+
+	for _, tc := range cases {
+		got, err := parseExpr(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.expr, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("%s: expected %+v, but got %+v", tc.expr, tc.want, got)
+		}
+	}
+
+	for _, v := range []string{"", "s", "y/a/b/", "s/a/b", "s//b/", "s/a/b/c/", "s/a/b/x", "s/a/b/0"} {
+		if _, err := parseExpr(v); err == nil {
+			t.Fatalf("Expected %q to be rejected", v)
+		}
+	}
+}
+
+func TestExprMode(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"a-a-a.txt",
+		"Report 2021-05 FINAL.TXT",
+	})
+
+	cases := []testCase{
+		{
+			name: "Replace the first occurrence only",
+			want: []Change{
+				{Source: "a-a-a.txt", BaseDir: testDir, Target: "b-a-a.txt"},
+			},
+			args: []string{"--expr", "s/a/b/", testDir},
+		},
+		{
+			name: "Replace every occurrence from the second one",
+			want: []Change{
+				{Source: "a-a-a.txt", BaseDir: testDir, Target: "a-b-b.txt"},
+			},
+			args: []string{"--expr", "s/a-?/b/2g", "--expr", "s/bb/b-b/", testDir},
+		},
+		{
+			name: "Apply expressions in sequence with captures and ignore case",
+			want: []Change{
+				{
+					Source:  "Report 2021-05 FINAL.TXT",
+					BaseDir: testDir,
+					Target:  "05.2021 [report].txt",
+				},
+			},
+			args: []string{
+				"--expr", `s/^report (\d+)-(\d+) final/\2.\1 [&]/i`,
+				"--expr", "s/ \\[.*\\]/ [report]/",
+				"--expr", `s/\]\.txt$/].txt/I`,
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
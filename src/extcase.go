@@ -0,0 +1,89 @@
+package f2
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	extLower = "lower"
+	extUpper = "upper"
+)
+
+var (
+	errInvalidExtCase = errors.New(
+		"Invalid argument: --ext-case must be set to 'lower' or 'upper'",
+	)
+
+	// compoundExtensions are the multi-part extensions
+	// that are changed as a whole
+	compoundExtensions = []string{
+		".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.lz", ".tar.z",
+	}
+)
+
+// splitExt returns the name without its extension and the extension.
+// Compound extensions such as `.tar.gz` are returned in full while the
+// names of dotfiles (e.g. `.bashrc`) are not treated as extensions
+func splitExt(name string) (string, string) {
+	lower := strings.ToLower(name)
+	for _, v := range compoundExtensions {
+		if strings.HasSuffix(lower, v) && len(name) > len(v) {
+			return name[:len(name)-len(v)], name[len(name)-len(v):]
+		}
+	}
+
+	ext := filepath.Ext(name)
+	if ext == name {
+		return name, ""
+	}
+
+	return name[:len(name)-len(ext)], ext
+}
+
+// changeExtCase changes the case of the extension of the file name
+// unless it is one of the excluded extensions. Excluded extensions
+// are matched case sensitively so that `.C` can be excluded while `.c`
+// is not
+func changeExtCase(name, mode string, exclude []string) string {
+	base, ext := splitExt(name)
+	if ext == "" {
+		return name
+	}
+
+	for _, v := range exclude {
+		if ext == v || ext == "."+v {
+			return name
+		}
+	}
+
+	if mode == extUpper {
+		return base + strings.ToUpper(ext)
+	}
+
+	return base + strings.ToLower(ext)
+}
+
+// normalizeExtCase changes the case of the extension of each target. The
+// file names are left unchanged if a replacement is not specified
+func (op *Operation) normalizeExtCase() {
+	for i, ch := range op.matches {
+		if ch.IsDir {
+			continue
+		}
+
+		target := ch.Target
+		if len(op.replacementSlice) == 0 && op.csvFile == "" &&
+			op.transform == nil {
+			target = ch.Source
+		}
+
+		dir, name := filepath.Split(target)
+		op.matches[i].Target = dir + changeExtCase(
+			name,
+			op.extCase,
+			op.extCaseExclude,
+		)
+	}
+}
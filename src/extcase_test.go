@@ -0,0 +1,96 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestChangeExtCase(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    string
+		exclude []string
+		want    string
+	}{
+		{name: "IMG_001.JPG", mode: extLower, want: "IMG_001.jpg"},
+		{name: "My.Report.PDF", mode: extLower, want: "My.Report.pdf"},
+		{name: "backup.TAR.GZ", mode: extLower, want: "backup.tar.gz"},
+		{name: "notes.txt", mode: extUpper, want: "notes.TXT"},
+		{name: ".Bashrc", mode: extLower, want: ".Bashrc"},
+		{name: "README", mode: extUpper, want: "README"},
+		{name: "main.C", mode: extLower, exclude: []string{".C"}, want: "main.C"},
+		{name: "main.c", mode: extUpper, exclude: []string{".C"}, want: "main.C"},
+		{name: "util.H", mode: extLower, exclude: []string{"H"}, want: "util.H"},
+	}
+
+	for _, tc := range cases {
+		got := changeExtCase(tc.name, tc.mode, tc.exclude)
+		if got != tc.want {
+			t.Fatalf("Expected %s to become %s, but got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestExtCase(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_001.JPG",
+		"main.C",
+		"archive.TAR.GZ",
+		"notes.txt",
+	})
+
+	cases := []testCase{
+		{
+			name: "Lowercase extensions with exclusions",
+			want: []Change{
+				{
+					Source:  "IMG_001.JPG",
+					BaseDir: testDir,
+					Target:  "IMG_001.jpg",
+				},
+				{
+					Source:  "archive.TAR.GZ",
+					BaseDir: testDir,
+					Target:  "archive.tar.gz",
+				},
+				{
+					Source:  "main.C",
+					BaseDir: testDir,
+					Target:  "main.C",
+				},
+				{
+					Source:  "notes.txt",
+					BaseDir: testDir,
+					Target:  "notes.txt",
+				},
+			},
+			args: []string{
+				"--ext-case",
+				"lower",
+				"--ext-case-exclude",
+				".C,.H",
+				testDir,
+			},
+		},
+		{
+			name: "Change the extension case after replacing",
+			want: []Change{
+				{
+					Source:  "IMG_001.JPG",
+					BaseDir: testDir,
+					Target:  "photo_001.jpg",
+				},
+			},
+			args: []string{
+				"-f",
+				"IMG",
+				"-r",
+				"photo",
+				"--ext-case",
+				"lower",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
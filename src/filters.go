@@ -0,0 +1,262 @@
+package f2
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// filterChainRegex matches a variable (or a captured value) followed by
+	// a chain of filters and an optional default value such as
+	// `{{id3.title|trim|kebab|Untitled}}` or `{{$1|date:DD-MM-YYYY>YYYY-MM-DD}}`
+	filterChainRegex = regexp.MustCompile(`{{([^{}|]+)((?:\|[^{}|]*)+)}}`)
+
+	// filterCallRegex matches a chain segment that is written like a
+	// filter with arguments (e.g. `date:YYYY>YY`)
+	filterCallRegex = regexp.MustCompile(`^[a-z]+:`)
+
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// filterNames lists the filters that can be chained after a variable
+var filterNames = []string{
+	"trim",
+	"squeeze",
+	"nodia",
+	"translit",
+	"slug",
+	"kebab",
+	"snake",
+	"camel",
+	"up",
+	"lw",
+	"ti",
+	"date",
+}
+
+// isFilter reports whether the chain segment is a known filter
+func isFilter(segment string) bool {
+	name := strings.SplitN(segment, ":", 2)[0]
+	for _, v := range filterNames {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseFilterChain splits the segments that follow a variable (such as
+// `|trim|kebab|Untitled`) into the filters that are applied to its value
+// and the default value that is used when it is empty. The default value
+// comes after the filters and everything from the first segment that is
+// not a filter belongs to it, except segments that are written like a
+// filter (`name:args`) which are reported as unknown filters
+func parseFilterChain(chain string) (filters []string, def string, hasDefault bool, err error) {
+	segments := strings.Split(chain, "|")[1:]
+
+	var defParts []string
+
+	for _, seg := range segments {
+		switch {
+		case hasDefault && isFilter(seg):
+			return nil, "", false, fmt.Errorf(
+				"Filter '%s' must come before the default value in '%s'",
+				seg,
+				chain,
+			)
+		case hasDefault:
+			defParts = append(defParts, seg)
+		case isFilter(seg):
+			filters = append(filters, seg)
+		case filterCallRegex.MatchString(seg):
+			return nil, "", false, unknownFilterError(seg)
+		default:
+			hasDefault = true
+			defParts = append(defParts, seg)
+		}
+	}
+
+	return filters, strings.Join(defParts, "|"), hasDefault, nil
+}
+
+func unknownFilterError(filter string) error {
+	return fmt.Errorf(
+		"Unknown filter '%s': use one of %s",
+		filter,
+		strings.Join(filterNames, ", "),
+	)
+}
+
+// words splits the string into words at whitespace, punctuation
+// and the boundaries between lower and upper case letters
+func words(str string) []string {
+	var result []string
+
+	var current []rune
+
+	runes := []rune(str)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				result = append(result, string(current))
+				current = nil
+			}
+
+			continue
+		}
+
+		// split camelCase and PascalCase words
+		if len(current) > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) &&
+					unicode.IsUpper(runes[i-1]))) {
+			result = append(result, string(current))
+			current = nil
+		}
+
+		current = append(current, r)
+	}
+
+	if len(current) > 0 {
+		result = append(result, string(current))
+	}
+
+	return result
+}
+
+// joinWords lower cases the words and joins them with the separator
+func joinWords(str, sep string) string {
+	w := words(str)
+	for i := range w {
+		w[i] = strings.ToLower(w[i])
+	}
+
+	return strings.Join(w, sep)
+}
+
+// applyFilter transforms the string with the filter which is a name
+// optionally followed by its arguments (e.g. `date:DD-MM-YYYY>YYYY-MM-DD`)
+func applyFilter(filter, str string) (string, error) {
+	name, args := filter, ""
+	if i := strings.Index(filter, ":"); i != -1 {
+		name, args = filter[:i], filter[i+1:]
+	}
+
+	switch name {
+	case "trim":
+		return strings.TrimSpace(str), nil
+	case "squeeze":
+		return whitespaceRegex.ReplaceAllString(str, " "), nil
+	case "nodia":
+		return transformString("di", str), nil
+	case "translit":
+		return transliterate(str), nil
+	case "up", "lw", "ti":
+		return transformString(name, str), nil
+	case "slug", "kebab":
+		if name == "slug" {
+			str = transformString("di", str)
+		}
+
+		return joinWords(str, "-"), nil
+	case "snake":
+		return joinWords(str, "_"), nil
+	case "camel":
+		w := words(str)
+		for i := range w {
+			w[i] = strings.ToLower(w[i])
+			if i > 0 {
+				r := []rune(w[i])
+				w[i] = string(unicode.ToUpper(r[0])) + string(r[1:])
+			}
+		}
+
+		return strings.Join(w, ""), nil
+	case "date":
+		layouts := strings.SplitN(args, ">", 2)
+		if len(layouts) != 2 || layouts[0] == "" || layouts[1] == "" {
+			return "", fmt.Errorf(
+				"Invalid filter '%s': the date filter expects the input and output layouts (e.g. date:DD-MM-YYYY>YYYY-MM-DD)",
+				filter,
+			)
+		}
+
+		return reformatDate(str, layouts[0], layouts[1])
+	}
+
+	return "", unknownFilterError(filter)
+}
+
+// variableValue returns the value of the named variable (e.g. `f` or
+// `id3.title`) for the file
+func (op *Operation) variableValue(name string, ch Change) (string, error) {
+	variable := "{{" + strings.TrimSpace(name) + "}}"
+
+	vars, err := getAllVariables(variable)
+	if err != nil {
+		return "", err
+	}
+
+	return op.handleVariables(variable, ch, &vars)
+}
+
+// replaceFilters replaces each variable that is followed by filters (e.g.
+// `{{f|slug}}`) with its value after it is passed through each filter
+// from left to right. A captured value that was already expanded in place
+// of a variable (e.g. `{{$1|date:DD-MM-YYYY>YYYY-MM-DD}}`) is filtered as
+// is. Variables that resolve to an empty value are replaced with the
+// default value if present or left to the --on-unresolved policy
+func (op *Operation) replaceFilters(input string, ch Change) (string, error) {
+	var err error
+
+	out := filterChainRegex.ReplaceAllStringFunc(input, func(token string) string {
+		if err != nil {
+			return token
+		}
+
+		submatch := filterChainRegex.FindStringSubmatch(token)
+
+		filters, def, hasDefault, perr := parseFilterChain(submatch[2])
+		if perr != nil {
+			err = perr
+			return token
+		}
+
+		// only a default value which is handled with unresolved variables
+		if len(filters) == 0 {
+			return token
+		}
+
+		value := submatch[1]
+
+		name := strings.TrimSpace(value)
+		if isKnownVariable("{{" + name + "}}") {
+			value, err = op.variableValue(name, ch)
+			if err != nil {
+				return token
+			}
+
+			if value == "" {
+				if hasDefault {
+					return def
+				}
+
+				return "{{" + name + "}}"
+			}
+		}
+
+		for _, f := range filters {
+			value, err = applyFilter(f, value)
+			if err != nil {
+				return token
+			}
+		}
+
+		return value
+	})
+
+	return out, err
+}
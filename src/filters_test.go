@@ -0,0 +1,152 @@
+package f2
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestApplyFilter(t *testing.T) {
+	cases := []struct {
+		filter string
+		input  string
+		want   string
+	}{
+		{"trim", "  The Title \t", "The Title"},
+		{"squeeze", "The   Big\t\tTitle", "The Big Title"},
+		{"nodia", "Café Noël", "Cafe Noel"},
+		{"slug", "  Café: Noël & Friends (2021)!", "cafe-noel-friends-2021"},
+		{"kebab", "someHTTPServer_v2", "some-http-server-v2"},
+		{"snake", "The Big Title", "the_big_title"},
+		{"snake", "parseXMLFile", "parse_xml_file"},
+		{"camel", "the big-title_2", "theBigTitle2"},
+		{"up", "abc", "ABC"},
+		{"date:DD-MM-YYYY>YYYY-MM-DD", "05-04-2021", "2021-04-05"},
+	}
+
+	for _, tc := range cases {
+		got, err := applyFilter(tc.filter, tc.input)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.filter, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("Expected %s(%q) to be %q, but got: %q", tc.filter, tc.input, tc.want, got)
+		}
+	}
+
+	for _, filter := range []string{"shout", "date:YYYY", "date:>YY"} {
+		if _, err := applyFilter(filter, "abc"); err == nil {
+			t.Fatalf("Expected an error for the %s filter", filter)
+		}
+	}
+}
+
+func TestParseFilterChain(t *testing.T) {
+	cases := []struct {
+		chain      string
+		filters    []string
+		def        string
+		hasDefault bool
+		err        bool
+	}{
+		{chain: "|slug", filters: []string{"slug"}},
+		{chain: "|Unknown Artist", def: "Unknown Artist", hasDefault: true},
+		{
+			chain:      "|trim|date:DD-MM-YYYY>YY|Untitled|Draft",
+			filters:    []string{"trim", "date:DD-MM-YYYY>YY"},
+			def:        "Untitled|Draft",
+			hasDefault: true,
+		},
+		{chain: "|", def: "", hasDefault: true},
+		{chain: "|trim|shout:loud", err: true},
+		{chain: "|Untitled|slug", err: true},
+	}
+
+	for _, tc := range cases {
+		filters, def, hasDefault, err := parseFilterChain(tc.chain)
+		if tc.err {
+			if err == nil {
+				t.Fatalf("%s: expected an error", tc.chain)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.chain, err)
+		}
+
+		if strings.Join(filters, ",") != strings.Join(tc.filters, ",") ||
+			def != tc.def || hasDefault != tc.hasDefault {
+			t.Fatalf(
+				"%s: expected filters %v and default %q (%t), but got: %v and %q (%t)",
+				tc.chain,
+				tc.filters,
+				tc.def,
+				tc.hasDefault,
+				filters,
+				def,
+				hasDefault,
+			)
+		}
+	}
+}
+
+func TestFilterVariables(t *testing.T) {
+	testDir := setupFiles(t, []string{"  My Holiday  Photos (Été 2021).JPG"})
+
+	cases := []testCase{
+		{
+			name: "Pass variables through filters",
+			want: []Change{
+				{
+					Source:  "  My Holiday  Photos (Été 2021).JPG",
+					BaseDir: testDir,
+					Target:  "my-holiday-photos-ete-2021.jpg",
+				},
+			},
+			args: []string{"-f", ".*", "-r", "{{f|slug}}{{ext|lw}}", testDir},
+		},
+		{
+			name: "Chain filters from left to right",
+			want: []Change{
+				{
+					Source:  "  My Holiday  Photos (Été 2021).JPG",
+					BaseDir: testDir,
+					Target:  "My Holiday Photos (Ete 2021).JPG",
+				},
+			},
+			args: []string{"-f", ".*", "-r", "{{f|trim|squeeze|nodia}}{{ext}}", "--strict", testDir},
+		},
+		{
+			name: "Fall back to the default value of an empty variable",
+			want: []Change{
+				{
+					Source:  "  My Holiday  Photos (Été 2021).JPG",
+					BaseDir: testDir,
+					Target:  "untitled-ete-2021.JPG",
+				},
+			},
+			args: []string{
+				"-f",
+				".*\\((.*)\\).*",
+				"-r",
+				"{{id3.title|slug|untitled}}-{{$1|slug}}{{ext}}",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+
+	args := []string{os.Args[0], "-f", ".*", "-r", "{{f|shout:loud}}", testDir}
+	result, err := action(args)
+	if err == nil {
+		err = result.applyError
+	}
+
+	if err == nil {
+		t.Fatal("Expected an error for an unknown filter")
+	}
+}
@@ -0,0 +1,62 @@
+package f2
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FS is the filesystem on which an operation finds and renames files.
+// Paths use the conventions of the operating system (rather than those
+// of io/fs) so that absolute paths and paths relative to the current
+// directory are supported. Metadata variables, links and the history
+// always use the operating system
+type FS interface {
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+}
+
+// osFS is the filesystem of the operating system
+type osFS struct{}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+// filesystem returns the filesystem of the operation
+// which defaults to that of the operating system
+func (op *Operation) filesystem() FS {
+	if op.fs == nil {
+		return osFS{}
+	}
+
+	return op.fs
+}
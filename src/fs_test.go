@@ -0,0 +1,166 @@
+package f2
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// memFS is an in-memory filesystem for tests
+type memFS struct {
+	fstest.MapFS
+}
+
+func memKey(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return m.MapFS.ReadDir(memKey(name))
+}
+
+func (m memFS) Stat(name string) (fs.FileInfo, error) {
+	return m.MapFS.Stat(memKey(name))
+}
+
+func (m memFS) Lstat(name string) (fs.FileInfo, error) {
+	return m.Stat(name)
+}
+
+func (m memFS) Rename(oldpath, newpath string) error {
+	if _, err := m.Stat(oldpath); err != nil {
+		return err
+	}
+
+	o, n := memKey(oldpath), memKey(newpath)
+	for k, v := range m.MapFS {
+		if k == o || strings.HasPrefix(k, o+"/") {
+			m.MapFS[n+k[len(o):]] = v
+			delete(m.MapFS, k)
+		}
+	}
+
+	return nil
+}
+
+func (m memFS) MkdirAll(path string, perm fs.FileMode) error {
+	if _, err := m.Stat(path); err != nil {
+		m.MapFS[memKey(path)] = &fstest.MapFile{Mode: fs.ModeDir | perm}
+	}
+
+	return nil
+}
+
+func (m memFS) Remove(name string) error {
+	delete(m.MapFS, memKey(name))
+	return nil
+}
+
+func (m memFS) RemoveAll(path string) error {
+	p := memKey(path)
+	for k := range m.MapFS {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			delete(m.MapFS, k)
+		}
+	}
+
+	return nil
+}
+
+func TestMemFS(t *testing.T) {
+	mem := memFS{fstest.MapFS{
+		"photos/IMG_1.jpg":        {},
+		"photos/IMG_2.jpg":        {},
+		"photos/holiday_2.jpg":    {},
+		"photos/trip/IMG_3.jpg":   {},
+		"photos/trip/notes.txt":   {},
+		"photos/other/IMG_4.jpeg": {},
+	}}
+
+	op, err := New(Options{
+		Paths:     []string{"/photos"},
+		Find:      []string{`IMG_(\d+)\.jpg`},
+		Replace:   []string{"holiday_$1.jpg"},
+		Recursive: true,
+		FS:        mem,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := op.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan) != 3 {
+		t.Fatalf("Expected 3 changes, but got: %v", plan)
+	}
+
+	conflicts := make(map[string]string)
+	for _, v := range plan {
+		conflicts[v.Source] = v.Conflict
+	}
+
+	if conflicts["IMG_2.jpg"] != "file_exists" || conflicts["IMG_1.jpg"] != "" {
+		t.Fatalf("Unexpected conflicts: %v", conflicts)
+	}
+
+	op.rename(context.Background())
+	if len(op.errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", op.errors)
+	}
+
+	for _, v := range []string{
+		"photos/holiday_1.jpg",
+		"photos/holiday_2.jpg",
+		"photos/trip/holiday_3.jpg",
+		"photos/other/IMG_4.jpeg",
+	} {
+		if _, ok := mem.MapFS[v]; !ok {
+			t.Fatalf("Expected %s to exist: %v", v, mem.MapFS)
+		}
+	}
+}
+
+func TestMemFSSortBySize(t *testing.T) {
+	mem := memFS{fstest.MapFS{
+		"files/small.txt":  {Data: []byte("a")},
+		"files/large.txt":  {Data: []byte("abcdef")},
+		"files/medium.txt": {Data: []byte("abc")},
+	}}
+
+	op, err := New(Options{
+		Paths:   []string{"/files"},
+		Find:    []string{`\.txt$`},
+		Replace: []string{".md"},
+		Sort:    "size",
+		FS:      mem,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := op.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, v := range plan {
+		got = append(got, v.Source)
+	}
+
+	want := []string{"large.txt", "medium.txt", "small.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected %v, but got: %v", want, got)
+	}
+}
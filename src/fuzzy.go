@@ -0,0 +1,87 @@
+package f2
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode"
+)
+
+var errInvalidFuzzyThreshold = errors.New(
+	"Invalid argument: --fuzzy must be set to a number greater than 0 and no more than 1",
+)
+
+// fuzzyFind locates the substring of str that is most similar to pattern.
+// It returns the start and end index of the substring or -1 if no
+// substring reaches the similarity threshold
+func fuzzyFind(
+	str, pattern string,
+	threshold float64,
+	ignoreCase bool,
+) (start, end int) {
+	original := []rune(str)
+	runes := original
+	if ignoreCase {
+		// lower case each rune individually so that the indices
+		// remain valid for the original string
+		runes = []rune(strings.Map(unicode.ToLower, str))
+		pattern = strings.ToLower(pattern)
+	}
+
+	plen := len([]rune(pattern))
+	if plen == 0 {
+		return -1, -1
+	}
+
+	// the number of edits allowed by the threshold bounds
+	// the length of candidate substrings
+	maxEdits := int(math.Floor(float64(plen) * (1 - threshold)))
+
+	start, end = -1, -1
+	var best float64
+	// candidate lengths closest to the pattern length are tried first
+	// so that they are preferred when scores are tied
+	for d := 0; d <= 2*maxEdits; d++ {
+		l := plen + (d+1)/2
+		if d%2 == 1 {
+			l = plen - (d+1)/2
+		}
+
+		if l <= 0 || l > len(runes) {
+			continue
+		}
+
+		for i := 0; i+l <= len(runes); i++ {
+			score := similarity(string(runes[i:i+l]), pattern)
+			if score >= threshold && score > best {
+				best = score
+				start, end = i, i+l
+			}
+		}
+	}
+
+	if start == -1 {
+		return -1, -1
+	}
+
+	// convert rune indices to byte indices
+	return len(string(original[:start])), len(string(original[:end]))
+}
+
+// fuzzyMatch reports whether the file name contains a substring that is
+// approximately equal to the find pattern
+func (op *Operation) fuzzyMatch(fileName string) bool {
+	start, _ := fuzzyFind(fileName, op.fuzzyFind, op.fuzzy, op.ignoreCase)
+	return start != -1
+}
+
+// fuzzyReplace replaces the substring in the file name that is most similar
+// to the find pattern with the replacement string
+func (op *Operation) fuzzyReplace(fileName string) string {
+	start, end := fuzzyFind(fileName, op.fuzzyFind, op.fuzzy, op.ignoreCase)
+	if start == -1 {
+		return fileName
+	}
+
+	return fileName[:start] + escapeLiterals(op.replacement) + fileName[end:]
+}
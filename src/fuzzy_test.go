@@ -0,0 +1,112 @@
+package f2
+
+import "testing"
+
+func TestFuzzyFind(t *testing.T) {
+	cases := []struct {
+		str, pattern string
+		threshold    float64
+		ignoreCase   bool
+		want         string
+	}{
+		{"Annual Reprot 2020.pdf", "Report", 0.6, false, "Reprot"},
+		{"annual report.pdf", "Report", 0.8, true, "report"},
+		{"annual report.pdf", "Report", 1, false, ""},
+		{"invoice.pdf", "Report", 0.8, false, ""},
+		{"résumé final.doc", "resume", 0.6, false, "résumé"},
+	}
+
+	for _, v := range cases {
+		start, end := fuzzyFind(v.str, v.pattern, v.threshold, v.ignoreCase)
+
+		var got string
+		if start != -1 {
+			got = v.str[start:end]
+		}
+
+		if got != v.want {
+			t.Fatalf(
+				"Test (%s, %s) — Expected: %q, but got: %q",
+				v.str,
+				v.pattern,
+				v.want,
+				got,
+			)
+		}
+	}
+}
+
+func TestFuzzyMode(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"Annual Reprot 2020.pdf",
+		"annual report 2021.pdf",
+		"Anual Report 2022.pdf",
+		"invoice.pdf",
+	})
+
+	cases := []testCase{
+		{
+			name: "Replace approximate matches",
+			want: []Change{
+				{
+					Source:  "Annual Reprot 2020.pdf",
+					BaseDir: testDir,
+					Target:  "Annual-Report 2020.pdf",
+				},
+				{
+					Source:  "annual report 2021.pdf",
+					BaseDir: testDir,
+					Target:  "Annual-Report 2021.pdf",
+				},
+				{
+					Source:  "Anual Report 2022.pdf",
+					BaseDir: testDir,
+					Target:  "Annual-Report 2022.pdf",
+				},
+			},
+			args: []string{
+				"-f",
+				"Annual Report",
+				"-r",
+				"Annual-Report",
+				"--fuzzy",
+				"0.8",
+				"-i",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+
+	// the pattern is a literal string rather than a regular expression
+	testDir = setupFiles(t, []string{
+		"song (live).mp3",
+		"song (lvie) 2.mp3",
+		"other.mp3",
+	})
+
+	cases = []testCase{
+		{
+			name: "Treat regex metacharacters in the pattern literally",
+			want: []Change{
+				{
+					Source:  "song (live).mp3",
+					BaseDir: testDir,
+					Target:  "track (live).mp3",
+				},
+			},
+			args: []string{
+				"-f",
+				"song (live",
+				"-r",
+				"track (live",
+				"--fuzzy",
+				"0.9",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,74 @@
+package f2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// geocodeProvider is the name of the online provider used to
+// convert GPS coordinates into place names
+const geocodeProvider = "nominatim"
+
+// geocodeURL is the reverse geocoding endpoint of the provider
+var geocodeURL = "https://nominatim.openstreetmap.org/reverse"
+
+// geocodeResult represents the relevant parts of a reverse geocoding
+// response
+type geocodeResult struct {
+	Address struct {
+		City    string `json:"city"`
+		Town    string `json:"town"`
+		Village string `json:"village"`
+		County  string `json:"county"`
+		State   string `json:"state"`
+		Country string `json:"country"`
+	} `json:"address"`
+}
+
+// place returns the most specific place name in the result
+func (g *geocodeResult) place() string {
+	a := g.Address
+	for _, v := range []string{a.City, a.Town, a.Village, a.County, a.State, a.Country} {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// reverseGeocode returns the name of the place at the specified
+// coordinates. An empty string is returned if the coordinates are not
+// available
+func (op *Operation) reverseGeocode(lat, lon string) (string, error) {
+	if lat == "" || lon == "" {
+		return "", nil
+	}
+
+	p, err := op.getProvider(geocodeProvider)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("format", "jsonv2")
+	query.Set("zoom", "10")
+	query.Set("lat", lat)
+	query.Set("lon", lon)
+
+	b, err := p.fetch(geocodeURL + "?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+
+	var result geocodeResult
+
+	err = json.Unmarshal(b, &result)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", geocodeProvider, err)
+	}
+
+	return strings.ReplaceAll(result.place(), "/", "_"), nil
+}
@@ -0,0 +1,76 @@
+package f2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestProvider(t *testing.T, name string, offline bool) *provider {
+	t.Helper()
+
+	return &provider{
+		name:    name,
+		offline: offline,
+		cache:   newTestCache(t, defaultCacheTTL, 0),
+		limiter: &rateLimiter{},
+	}
+}
+
+func TestReverseGeocode(t *testing.T) {
+	var requests int
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			if r.URL.Query().Get("lat") != "6.45407" ||
+				r.Header.Get("User-Agent") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			_, _ = w.Write(
+				[]byte(`{"address":{"town":"Lagos Island","country":"Nigeria"}}`),
+			)
+		}),
+	)
+	defer server.Close()
+
+	defaultURL := geocodeURL
+	geocodeURL = server.URL
+
+	t.Cleanup(func() {
+		geocodeURL = defaultURL
+	})
+
+	op := &Operation{
+		providers: map[string]*provider{
+			geocodeProvider: newTestProvider(t, geocodeProvider, false),
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := op.reverseGeocode("6.45407", "3.39467")
+		if err != nil || got != "Lagos Island" {
+			t.Fatalf("Expected: Lagos Island, but got: %s (%v)", got, err)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("Expected cached responses to be reused, but made %d requests", requests)
+	}
+
+	got, err := op.reverseGeocode("", "")
+	if err != nil || got != "" || requests != 1 {
+		t.Fatalf("Expected no lookup without coordinates, but got: %s (%v)", got, err)
+	}
+
+	op.providers[geocodeProvider] = newTestProvider(t, geocodeProvider, true)
+
+	_, err = op.reverseGeocode("6.45407", "3.39467")
+	if !errors.Is(err, errOffline) || requests != 1 {
+		t.Fatalf("Expected an offline error without a request, but got: %v", err)
+	}
+}
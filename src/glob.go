@@ -0,0 +1,96 @@
+package f2
+
+import (
+	"regexp"
+	"strings"
+)
+
+// globPattern converts a shell glob to a regular expression that matches
+// the entire file name. Each wildcard is a capture group so that the text
+// it matched can be used in the replacement:
+//
+//	*       any sequence of characters
+//	?       a single character
+//	[abc]   one of the characters in the set ([!abc] negates the set)
+//	{a,b}   one of the comma separated alternatives
+//
+// A wildcard character preceded by a backslash is matched literally
+func globPattern(glob string) string {
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	var inBraces bool
+
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case r == '*':
+			b.WriteString("(.*)")
+		case r == '?':
+			b.WriteString("(.)")
+		case r == '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+
+			// a closing bracket immediately after the opening one
+			// is part of the set
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+
+			if end == len(runes) {
+				b.WriteString(`\[`)
+				continue
+			}
+
+			set := runes[i+1 : end]
+			negate := len(set) > 0 && (set[0] == '!' || set[0] == '^')
+			if negate {
+				set = set[1:]
+			}
+
+			b.WriteString("([")
+			if negate {
+				b.WriteString("^")
+			}
+
+			for _, c := range set {
+				if c == '\\' || c == '[' || c == ']' || c == '^' {
+					b.WriteRune('\\')
+				}
+
+				b.WriteRune(c)
+			}
+
+			b.WriteString("])")
+
+			i = end
+		case r == '{' && !inBraces && strings.ContainsRune(string(runes[i:]), '}'):
+			inBraces = true
+			b.WriteString("(")
+		case r == ',' && inBraces:
+			b.WriteString("|")
+		case r == '}' && inBraces:
+			inBraces = false
+			b.WriteString(")")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestGlobPattern(t *testing.T) {
+	cases := []struct {
+		glob    string
+		want    string
+		match   []string
+		noMatch []string
+	}{
+		{
+			glob:    "*.JPG",
+			want:    `^(.*)\.JPG$`,
+			match:   []string{"a.JPG", ".JPG"},
+			noMatch: []string{"a.jpg", "a.JPG.bak"},
+		},
+		{
+			glob:    "IMG_??.png",
+			want:    `^IMG_(.)(.)\.png$`,
+			match:   []string{"IMG_01.png"},
+			noMatch: []string{"IMG_1.png", "IMG_001.png"},
+		},
+		{
+			glob:    "[!a-c]*.{png,jpg}",
+			want:    `^([^a-c])(.*)\.(png|jpg)$`,
+			match:   []string{"d1.png", "x.jpg"},
+			noMatch: []string{"a1.png", "d1.gif"},
+		},
+		{
+			glob:    `\*[]]\?[x`,
+			want:    `^\*([\]])\?\[x$`,
+			match:   []string{"*]?[x"},
+			noMatch: []string{"a]?[x"},
+		},
+	}
+
+	for _, tc := range cases {
+		got := globPattern(tc.glob)
+		if got != tc.want {
+			t.Fatalf("Expected %s to be converted to %s, but got: %s", tc.glob, tc.want, got)
+		}
+
+		op := &Operation{globMode: true}
+
+		re, err := op.compileFind(tc.glob)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.glob, err)
+		}
+
+		for _, v := range tc.match {
+			if !re.MatchString(v) {
+				t.Fatalf("Expected %s to match %s", tc.glob, v)
+			}
+		}
+
+		for _, v := range tc.noMatch {
+			if re.MatchString(v) {
+				t.Fatalf("Expected %s not to match %s", tc.glob, v)
+			}
+		}
+	}
+}
+
+func TestGlobMode(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_01.JPG",
+		"IMG_02.jpg",
+		"IMG_100.JPG",
+	})
+
+	cases := []testCase{
+		{
+			name: "Reference the wildcards in the replacement",
+			want: []Change{
+				{Source: "IMG_01.JPG", BaseDir: testDir, Target: "photo-01.jpg"},
+				{Source: "IMG_02.jpg", BaseDir: testDir, Target: "photo-02.jpg"},
+			},
+			args: []string{"--glob", "-i", "-f", "IMG_??.jpg", "-r", "photo-$1$2.jpg", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
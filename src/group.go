@@ -0,0 +1,147 @@
+package f2
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// minGroupSimilarity is the minimum similarity between two normalised file
+// names for them to be placed in the same group
+const minGroupSimilarity = 0.75
+
+// minGroupPrefix is the minimum length of a normalised name for names that
+// start with it to be placed in the same group
+const minGroupPrefix = 4
+
+// fileGroup represents a cluster of similar file names
+type fileGroup struct {
+	index int
+	label string
+}
+
+// normaliseGroupName strips the extension, case, digits and punctuation
+// from a file name so that variants of the same name compare as equal
+// (e.g `Logo Final (2).png` and `logo_final_v3.png`)
+func normaliseGroupName(name string) string {
+	name = strings.ToLower(filenameWithoutExtension(name))
+
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	if b.Len() == 0 {
+		return name
+	}
+
+	return b.String()
+}
+
+// sameGroupRune reports whether two runes are equal ignoring case.
+// All word separators are considered equal
+func sameGroupRune(a, b rune) bool {
+	isSeparator := func(r rune) bool {
+		return unicode.IsSpace(r) || r == '_' || r == '-' || r == '.'
+	}
+
+	if isSeparator(a) && isSeparator(b) {
+		return true
+	}
+
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// groupScore returns how similar two normalised names are. Names where one
+// is a prefix of the other (e.g `banner` and `bannercopy`) are considered
+// identical
+func groupScore(a, b string) float64 {
+	shorter, longer := a, b
+	if len(shorter) > len(longer) {
+		shorter, longer = longer, shorter
+	}
+
+	if len([]rune(shorter)) >= minGroupPrefix &&
+		strings.HasPrefix(longer, shorter) {
+		return 1
+	}
+
+	return similarity(a, b)
+}
+
+// commonPrefix returns the longest prefix shared by all the strings
+func commonPrefix(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+
+	prefix := []rune(strs[0])
+	for _, s := range strs[1:] {
+		r := []rune(s)
+		i := 0
+		for i < len(prefix) && i < len(r) && sameGroupRune(prefix[i], r[i]) {
+			i++
+		}
+
+		prefix = prefix[:i]
+	}
+
+	return string(prefix)
+}
+
+// groupMatches clusters the matches by the similarity of their names.
+// Groups are numbered in order of appearance and labelled with the common
+// prefix of their members
+func (op *Operation) groupMatches() {
+	type cluster struct {
+		key     string
+		members []int
+	}
+
+	var clusters []*cluster
+	for i, ch := range op.matches {
+		key := normaliseGroupName(ch.Source)
+
+		var best *cluster
+		var bestScore float64
+		for _, c := range clusters {
+			score := groupScore(key, c.key)
+			if score >= minGroupSimilarity && score > bestScore {
+				best, bestScore = c, score
+			}
+		}
+
+		if best == nil {
+			best = &cluster{key: key}
+			clusters = append(clusters, best)
+		}
+
+		best.members = append(best.members, i)
+	}
+
+	op.groups = make(map[string]fileGroup)
+	for i, c := range clusters {
+		names := make([]string, 0, len(c.members))
+		for _, m := range c.members {
+			names = append(names, filenameWithoutExtension(op.matches[m].Source))
+		}
+
+		label := strings.TrimRightFunc(commonPrefix(names), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+
+		if label == "" {
+			label = names[0]
+		}
+
+		for _, m := range c.members {
+			ch := op.matches[m]
+			op.groups[filepath.Join(ch.BaseDir, ch.originalSource)] = fileGroup{
+				index: i + 1,
+				label: label,
+			}
+		}
+	}
+}
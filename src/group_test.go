@@ -0,0 +1,54 @@
+package f2
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"héllo", "hello", 1},
+	}
+
+	for _, v := range cases {
+		if got := levenshtein(v.a, v.b); got != v.want {
+			t.Fatalf("Test (%s, %s) — Expected: %d, but got: %d", v.a, v.b, v.want, got)
+		}
+	}
+}
+
+func TestGroupVariable(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"logo_final.png",
+		"Logo Final (2).png",
+		"logo_final_v3.png",
+		"banner.png",
+		"banner copy.png",
+	})
+
+	cases := []testCase{
+		{
+			name: "Rename similar files consistently",
+			want: []Change{
+				{Source: "banner copy.png", BaseDir: testDir, Target: "1-banner-01.png"},
+				{Source: "banner.png", BaseDir: testDir, Target: "1-banner-02.png"},
+				{Source: "Logo Final (2).png", BaseDir: testDir, Target: "2-Logo Final-03.png"},
+				{Source: "logo_final.png", BaseDir: testDir, Target: "2-Logo Final-04.png"},
+				{Source: "logo_final_v3.png", BaseDir: testDir, Target: "2-Logo Final-05.png"},
+			},
+			args: []string{
+				"-f",
+				".*",
+				"-r",
+				"{{group}}-{{group.label}}-%02d",
+				"-e",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
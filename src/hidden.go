@@ -0,0 +1,59 @@
+package f2
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// hiddenPolicy determines which hidden files and directories are
+// included in the matches and descended into during a recursive search
+type hiddenPolicy struct {
+	files bool
+	dirs  bool
+	// dotdirs are glob patterns for hidden directories that are
+	// included even when other hidden directories are skipped
+	dotdirs []string
+}
+
+// newHiddenPolicy validates the --include-dotdirs patterns and returns the
+// resulting policy. --hidden includes both hidden files and directories
+func newHiddenPolicy(all, files, dirs bool, dotdirs []string) (hiddenPolicy, error) {
+	for _, v := range dotdirs {
+		if _, err := filepath.Match(v, ""); err != nil {
+			return hiddenPolicy{}, fmt.Errorf(
+				"Invalid --include-dotdirs pattern '%s': %w",
+				v,
+				err,
+			)
+		}
+	}
+
+	return hiddenPolicy{
+		files:   all || files,
+		dirs:    all || dirs,
+		dotdirs: dotdirs,
+	}, nil
+}
+
+// skip reports whether the file or directory with the specified name
+// in baseDir is excluded by the policy
+func (h hiddenPolicy) skip(name, baseDir string, isDir bool) (bool, error) {
+	if (isDir && h.dirs) || (!isDir && h.files) {
+		return false, nil
+	}
+
+	hidden, err := isHidden(name, baseDir)
+	if err != nil || !hidden {
+		return false, err
+	}
+
+	if isDir {
+		for _, v := range h.dotdirs {
+			if ok, _ := filepath.Match(v, name); ok {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
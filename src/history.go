@@ -0,0 +1,379 @@
+package f2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	errNoHistory = errors.New("There are no operations in the history")
+	errNoRedo    = errors.New("There are no reverted operations to redo")
+)
+
+// historyFileFormat is used to name history entries so that
+// they are sorted in the order in which the operations occurred
+const historyFileFormat = "20060102T150405.000000000"
+
+// historyEntry represents a renaming operation in the history
+type historyEntry struct {
+	path string
+	backupFile
+}
+
+// dataDir returns the specified directory within the location where
+// f2 keeps its data, creating it if necessary. It is located in
+// $XDG_DATA_HOME (or ~/.local/share) on Unix and %LOCALAPPDATA% on Windows
+func dataDir(name string) (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" && runtime.GOOS == windows {
+		dataDir = os.Getenv("LOCALAPPDATA")
+	}
+
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		dataDir = filepath.Join(homeDir, ".local", "share")
+	}
+
+	dir := filepath.Join(dataDir, "f2", name)
+
+	return dir, os.MkdirAll(dir, os.ModePerm)
+}
+
+// historyDir returns the directory where the history
+// of renaming operations is kept
+func historyDir() (string, error) {
+	return dataDir("history")
+}
+
+// redoDir returns the directory where reverted operations
+// are kept so that they can be reapplied
+func redoDir() (string, error) {
+	return dataDir("redo")
+}
+
+// recordHistory adds a successful renaming operation to the history and
+// returns the path of its entry. The entry at the specified path is replaced
+// instead if the path is not empty so that an operation whose backup is
+// updated after the renames is recorded once
+func recordHistory(bf backupFile, path string) (string, error) {
+	if path == "" {
+		dir, err := historyDir()
+		if err != nil {
+			return "", err
+		}
+
+		path = entryPath(dir)
+	}
+
+	return path, writeBackupFile(path, bf)
+}
+
+// recordRedo keeps a reverted operation so that it can be reapplied
+func recordRedo(bf backupFile) error {
+	dir, err := redoDir()
+	if err != nil {
+		return err
+	}
+
+	return writeEntry(dir, bf)
+}
+
+// clearRedo removes the reverted operations once another operation is
+// performed since they may no longer apply to the current state of the tree
+func clearRedo() error {
+	dir, err := redoDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := readEntries(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range entries {
+		err = os.Remove(v.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// entryPath returns the path of a new entry in the specified directory
+func entryPath(dir string) string {
+	return filepath.Join(dir, time.Now().Format(historyFileFormat)+".json")
+}
+
+// writeEntry writes the details of an operation to a new file
+// in the specified directory
+func writeEntry(dir string, bf backupFile) error {
+	return writeBackupFile(entryPath(dir), bf)
+}
+
+// readBackupFile reads the details of an operation from the specified file
+func readBackupFile(path string) (backupFile, error) {
+	var bf backupFile
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return bf, err
+	}
+
+	err = json.Unmarshal(b, &bf)
+
+	return bf, err
+}
+
+// historyEntries returns the operations in the history
+// starting with the most recent one
+func historyEntries() ([]historyEntry, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return readEntries(dir)
+}
+
+// redoEntries returns the reverted operations
+// starting with the most recently reverted one
+func redoEntries() ([]historyEntry, error) {
+	dir, err := redoDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return readEntries(dir)
+}
+
+// readEntries returns the operations in the specified
+// directory starting with the most recent one
+func readEntries(dir string) ([]historyEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []historyEntry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, f.Name())
+
+		bf, err := readBackupFile(path)
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, historyEntry{path: path, backupFile: bf})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].path > entries[j].path
+	})
+
+	return entries, nil
+}
+
+// forgetOperation removes every record of an operation that has been
+// reverted (other than the specified path) so that it cannot be
+// reverted a second time
+func forgetOperation(bf backupFile, path string) {
+	var candidates []string
+
+	backup, err := backupPath(bf.WorkingDir)
+	if err == nil {
+		candidates = append(candidates, backup)
+	}
+
+	entries, err := historyEntries()
+	if err == nil {
+		for _, v := range entries {
+			candidates = append(candidates, v.path)
+		}
+	}
+
+	for _, v := range candidates {
+		if v == path {
+			continue
+		}
+
+		other, err := readBackupFile(v)
+		if err != nil {
+			continue
+		}
+
+		if other.WorkingDir == bf.WorkingDir && other.Date == bf.Date &&
+			len(other.Operations) == len(bf.Operations) {
+			_ = os.Remove(v)
+		}
+	}
+}
+
+// printHistory lists the operations in the history
+// starting with the most recent one
+func printHistory(c *cli.Context) error {
+	entries, err := historyEntries()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(errNoHistory)
+		return nil
+	}
+
+	writeHistory(os.Stdout, entries)
+
+	return nil
+}
+
+// writeHistory displays the operations in a table along
+// with the message that describes each one
+func writeHistory(w io.Writer, entries []historyEntry) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"#", "Date", "Directory", "Files", "Message"})
+	table.SetAutoWrapText(false)
+
+	for i, v := range entries {
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			v.Date,
+			v.WorkingDir,
+			strconv.Itoa(len(v.Operations)),
+			v.Message,
+		})
+	}
+
+	table.Render()
+}
+
+// undoLatest reverts the most recent operation in the history. It
+// runs in the same way as -u except that the operation may have
+// been performed in any directory
+func undoLatest(c *cli.Context) error {
+	entries, err := historyEntries()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return errNoHistory
+	}
+
+	op := &Operation{
+		exec:         c.Bool("exec"),
+		quiet:        c.Bool("quiet"),
+		revert:       true,
+		undoFile:     entries[0].path,
+		notifyOnDone: c.Bool("notify"),
+		webhook:      c.String("webhook"),
+	}
+
+	op.workingDir, err = filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	err = op.run(c.Context)
+
+	op.notify(err)
+	op.postWebhook(err)
+
+	return err
+}
+
+// redoLatest reapplies the most recently reverted operation. The operation
+// is added back to the history so that it can be reverted again
+func redoLatest(c *cli.Context) error {
+	entries, err := redoEntries()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return errNoRedo
+	}
+
+	bf := entries[0].backupFile
+
+	op := &Operation{
+		exec:         c.Bool("exec"),
+		quiet:        c.Bool("quiet"),
+		workingDir:   bf.WorkingDir,
+		matches:      append([]Change(nil), bf.Operations...),
+		symlinks:     bf.Symlinks,
+		references:   bf.References,
+		linkMode:     bf.Link,
+		message:      bf.Message,
+		redo:         true,
+		notifyOnDone: c.Bool("notify"),
+		webhook:      c.String("webhook"),
+	}
+
+	err = op.reapply(c.Context, entries[0])
+
+	op.notify(err)
+	op.postWebhook(err)
+
+	return err
+}
+
+// reapply renames the files in a reverted operation again and restores
+// the symbolic links and references that were updated by it. The entry
+// is removed from the redo stack once the operation is reapplied
+func (op *Operation) reapply(ctx context.Context, entry historyEntry) error {
+	// Paths are relative to the directory in which
+	// the operation was performed
+	for i, v := range op.matches {
+		if !filepath.IsAbs(v.BaseDir) {
+			op.matches[i].BaseDir = filepath.Join(entry.WorkingDir, v.BaseDir)
+		}
+
+		op.matches[i].Source = localPath(v.Source)
+		op.matches[i].Target = localPath(v.Target)
+	}
+
+	err := op.apply(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !op.exec {
+		return nil
+	}
+
+	for _, v := range entry.Symlinks {
+		err = replaceSymlink(v.Path, v.NewTarget)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = replaceReferences(entry.References, false)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(entry.path)
+}
@@ -0,0 +1,173 @@
+package f2
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	dir, err := historyDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rdir, err := redoDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{dir, rdir} {
+		err = os.RemoveAll(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	args := append(
+		os.Args[0:1],
+		"-f",
+		"abc",
+		"-r",
+		"xyz",
+		"--message",
+		"normalize season 3",
+		"-x",
+		testDir,
+	)
+
+	result, err := action(args)
+	if err != nil || result.applyError != nil {
+		t.Fatalf("Unexpected error: %v, %v", err, result.applyError)
+	}
+
+	entries, err := historyEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || len(entries[0].Operations) != 2 {
+		t.Fatalf("Expected one operation with two changes, but got: %+v", entries)
+	}
+
+	var buf bytes.Buffer
+	writeHistory(&buf, entries)
+
+	if !strings.Contains(buf.String(), "normalize season 3") {
+		t.Fatalf("Expected the message to be listed in the history:\n%s", buf.String())
+	}
+
+	// the operation is reverted from a different directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(os.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = GetApp().Run(append(os.Args[0:1], "history", "undo", "-x", "-q"))
+
+	if cerr := os.Chdir(wd); cerr != nil {
+		t.Fatal(cerr)
+	}
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, f := range []string{"abc.pdf", "abc.epub"} {
+		if _, err := os.Stat(filepath.Join(testDir, f)); err != nil {
+			t.Fatalf("Expected %s to be restored: %v", f, err)
+		}
+	}
+
+	entries, err = historyEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("Expected the history to be empty, but got: %+v", entries)
+	}
+
+	if _, err := os.Stat(backupFilePath); !os.IsNotExist(err) {
+		t.Fatalf("Expected the backup file to be removed: %v", err)
+	}
+
+	err = GetApp().Run(append(os.Args[0:1], "history", "undo"))
+	if err != errNoHistory {
+		t.Fatalf("Expected: %v, but got: %v", errNoHistory, err)
+	}
+
+	// the reverted operation can be reapplied
+	err = GetApp().Run(append(os.Args[0:1], "history", "redo", "-x", "-q"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, f := range []string{"xyz.pdf", "xyz.epub"} {
+		if _, err := os.Stat(filepath.Join(testDir, f)); err != nil {
+			t.Fatalf("Expected %s to be renamed again: %v", f, err)
+		}
+	}
+
+	entries, err = historyEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected the operation to be in the history: %+v", entries)
+	}
+
+	if entries[0].Message != "normalize season 3" {
+		t.Fatalf("Expected the message to be kept when redoing the operation, but got: %q", entries[0].Message)
+	}
+
+	err = GetApp().Run(append(os.Args[0:1], "history", "redo"))
+	if err != errNoRedo {
+		t.Fatalf("Expected: %v, but got: %v", errNoRedo, err)
+	}
+}
+
+func TestNewOperationClearsRedo(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	rdir, err := redoDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = recordRedo(backupFile{WorkingDir: testDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := action(append(os.Args[0:1], "-f", "abc", "-r", "xyz", "-x", testDir))
+	if err != nil || result.applyError != nil {
+		t.Fatalf("Unexpected error: %v, %v", err, result.applyError)
+	}
+
+	defer os.Remove(result.backupFile)
+
+	entries, err := readEntries(rdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("Expected the reverted operations to be cleared: %+v", entries)
+	}
+
+	err = GetApp().Run(append(os.Args[0:1], "history", "redo"))
+	if err != errNoRedo {
+		t.Fatalf("Expected: %v, but got: %v", errNoRedo, err)
+	}
+}
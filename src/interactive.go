@@ -0,0 +1,93 @@
+package f2
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var errInteractiveInput = errors.New("Interactive mode: unexpected end of input")
+
+// readLine reads a single line of input without the trailing newline
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", errInteractiveInput
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// confirmChanges presents each change to the user one at a time so that it
+// can be accepted, skipped or edited. The user can also accept or skip all
+// the remaining changes. Only the accepted changes are retained
+func (op *Operation) confirmChanges(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+
+	var accepted []Change
+	var acceptAll bool
+
+loop:
+	for i, ch := range op.matches {
+		source := filepath.Join(ch.BaseDir, ch.Source)
+		target := filepath.Join(ch.BaseDir, ch.Target)
+
+		if acceptAll || source == target {
+			accepted = append(accepted, ch)
+			continue
+		}
+
+		for {
+			fmt.Fprintf(
+				w,
+				"[%d/%d] %s ➜ %s\n%s ",
+				i+1,
+				len(op.matches),
+				source,
+				printColor("green", target),
+				printColor("yellow", "Rename? [y]es, [n]o, [e]dit, [a]ll, [q]uit:"),
+			)
+
+			answer, err := readLine(reader)
+			if err != nil {
+				return err
+			}
+
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				accepted = append(accepted, ch)
+			case "n", "no":
+			case "e", "edit":
+				fmt.Fprintf(w, "New name for %s: ", ch.Source)
+
+				name, err := readLine(reader)
+				if err != nil {
+					return err
+				}
+
+				if strings.TrimSpace(name) == "" {
+					continue
+				}
+
+				ch.Target = strings.TrimSpace(name)
+				accepted = append(accepted, ch)
+			case "a", "all":
+				accepted = append(accepted, ch)
+				acceptAll = true
+			case "q", "quit":
+				break loop
+			default:
+				continue
+			}
+
+			break
+		}
+	}
+
+	op.matches = accepted
+
+	return nil
+}
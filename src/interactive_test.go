@@ -0,0 +1,74 @@
+package f2
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestConfirmChanges(t *testing.T) {
+	matches := []Change{
+		{Source: "a.txt", Target: "1.txt"},
+		{Source: "b.txt", Target: "b.txt"},
+		{Source: "c.txt", Target: "3.txt"},
+		{Source: "d.txt", Target: "4.txt"},
+		{Source: "e.txt", Target: "5.txt"},
+		{Source: "f.txt", Target: "6.txt"},
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		want  []Change
+	}{
+		{
+			name:  "Accept, skip, edit and quit",
+			input: "y\nmaybe\nn\ne\nfour.txt\nq\n",
+			want: []Change{
+				{Source: "a.txt", Target: "1.txt"},
+				{Source: "b.txt", Target: "b.txt"},
+				{Source: "d.txt", Target: "four.txt"},
+			},
+		},
+		{
+			name:  "Accept all remaining changes",
+			input: "n\na\n",
+			want: []Change{
+				{Source: "b.txt", Target: "b.txt"},
+				{Source: "c.txt", Target: "3.txt"},
+				{Source: "d.txt", Target: "4.txt"},
+				{Source: "e.txt", Target: "5.txt"},
+				{Source: "f.txt", Target: "6.txt"},
+			},
+		},
+	}
+
+	for _, v := range cases {
+		op := &Operation{}
+		op.matches = append([]Change{}, matches...)
+
+		err := op.confirmChanges(strings.NewReader(v.input), ioutil.Discard)
+		if err != nil {
+			t.Fatalf("Test (%s) — Unexpected error: %v", v.name, err)
+		}
+
+		if !cmp.Equal(v.want, op.matches, cmpopts.IgnoreUnexported(Change{})) {
+			t.Fatalf(
+				"Test (%s) — Expected: %+v, got: %+v",
+				v.name,
+				prettyPrint(v.want),
+				prettyPrint(op.matches),
+			)
+		}
+	}
+
+	op := &Operation{matches: matches}
+	err := op.confirmChanges(strings.NewReader("y\n"), ioutil.Discard)
+	if !errors.Is(err, errInteractiveInput) {
+		t.Fatalf("Expected an error when input ends early, but got: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package f2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// conflictTypes maps each conflict to the name used in JSON output
+var conflictTypes = map[conflict]string{
+	emptyFilename:      "empty_filename",
+	fileExists:         "file_exists",
+	overwritingNewPath: "overwriting_new_path",
+	maxLengthExceeded:  "max_length_exceeded",
+	invalidCharacters:  "invalid_characters",
+	trailingPeriod:     "trailing_period",
+	duplicateBaseName:  "duplicate_base_name",
+}
+
+// summaryConflict represents a conflict detected in the operation
+type summaryConflict struct {
+	Type    string   `json:"type"`
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+	Cause   string   `json:"cause,omitempty"`
+}
+
+// summaryConflicts returns the detected conflicts ordered by their type
+func (op *Operation) summaryConflicts() []summaryConflict {
+	kinds := make([]conflict, 0, len(op.conflicts))
+	for k := range op.conflicts {
+		kinds = append(kinds, k)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i] < kinds[j]
+	})
+
+	var conflicts []summaryConflict
+	for _, k := range kinds {
+		for _, v := range op.conflicts[k] {
+			conflicts = append(conflicts, summaryConflict{
+				Type:    conflictTypes[k],
+				Sources: v.source,
+				Target:  v.target,
+				Cause:   v.cause,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// printJSON writes the summary of the operation as JSON
+// so that it can be consumed by other programs
+func (op *Operation) printJSON(w io.Writer, err error) error {
+	b, merr := json.MarshalIndent(op.summary(err), "", "    ")
+	if merr != nil {
+		return merr
+	}
+
+	_, merr = fmt.Fprintln(w, string(b))
+
+	return merr
+}
@@ -0,0 +1,82 @@
+package f2
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestPrintJSON(t *testing.T) {
+	op := &Operation{
+		workingDir: "/home/user",
+		matches: []Change{
+			{Source: "a.txt", Target: "c.txt", BaseDir: "docs"},
+			{Source: "b.txt", Target: "c.txt", BaseDir: "docs"},
+		},
+		conflicts: map[conflict][]Conflict{
+			overwritingNewPath: {
+				{
+					source: []string{"docs/a.txt", "docs/b.txt"},
+					target: "docs/c.txt",
+				},
+			},
+			emptyFilename: {
+				{
+					source: []string{"docs/d.txt"},
+					target: "docs",
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	err := op.printJSON(&buf, errConflictDetected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got operationSummary
+
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Success || got.Exec || len(got.Changes) != 2 {
+		t.Fatalf("Unexpected summary: %+v", got)
+	}
+
+	want := []summaryConflict{
+		{
+			Type:    "empty_filename",
+			Sources: []string{"docs/d.txt"},
+			Target:  "docs",
+		},
+		{
+			Type:    "overwriting_new_path",
+			Sources: []string{"docs/a.txt", "docs/b.txt"},
+			Target:  "docs/c.txt",
+		},
+	}
+
+	if len(got.Conflicts) != len(want) {
+		t.Fatalf("Expected: %+v, but got: %+v", want, got.Conflicts)
+	}
+
+	for i := range want {
+		if got.Conflicts[i].Type != want[i].Type ||
+			got.Conflicts[i].Target != want[i].Target ||
+			len(got.Conflicts[i].Sources) != len(want[i].Sources) {
+			t.Fatalf("Expected: %+v, but got: %+v", want, got.Conflicts)
+		}
+	}
+}
+
+func TestConflictTypes(t *testing.T) {
+	for k := emptyFilename; k <= duplicateBaseName; k++ {
+		if conflictTypes[k] == "" {
+			t.Fatalf("Conflict %d does not have a JSON type", k)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var errInvalidLinkMode = errors.New(
+	"Invalid argument: --link must be set to 'hard' or 'sym'",
+)
+
+const (
+	linkHard = "hard"
+	linkSym  = "sym"
+)
+
+// createLink creates a hard link or symbolic link to the source at the
+// target path. Symbolic links are relative so that the links continue to
+// resolve if the parent directory is moved
+func createLink(mode, source, target string) error {
+	if mode == linkHard {
+		return os.Link(source, target)
+	}
+
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(absTarget), absSource)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(rel, target)
+}
+
+// removeLink removes a link created by a previous operation. The link is
+// only removed if it still refers to the original file so that unrelated
+// files are never deleted
+func removeLink(mode, link, original string) error {
+	linkInfo, err := os.Lstat(link)
+	if err != nil {
+		return err
+	}
+
+	var info os.FileInfo
+	if mode == linkSym {
+		if linkInfo.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("'%s' is not a symbolic link", link)
+		}
+
+		info, err = os.Stat(link)
+	} else {
+		info = linkInfo
+	}
+
+	if err != nil {
+		return err
+	}
+
+	originalInfo, err := os.Stat(original)
+	if err != nil {
+		return err
+	}
+
+	if !os.SameFile(info, originalInfo) {
+		return fmt.Errorf("'%s' no longer links to '%s'", link, original)
+	}
+
+	return os.Remove(link)
+}
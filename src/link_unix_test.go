@@ -0,0 +1,84 @@
+// +build !windows
+
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkMode(t *testing.T) {
+	for _, mode := range []string{linkSym, linkHard} {
+		testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+		args := append(
+			os.Args[0:1],
+			"-f",
+			"(.*)",
+			"-r",
+			"view/$1",
+			"--link",
+			mode,
+			"-x",
+			testDir,
+		)
+
+		result, err := action(args)
+		if err != nil || result.applyError != nil {
+			t.Fatalf("Unexpected error: %v, %v", err, result.applyError)
+		}
+
+		for _, f := range []string{"a.txt", "b.txt"} {
+			original, err := os.Stat(filepath.Join(testDir, f))
+			if err != nil {
+				t.Fatalf("Expected %s to be left in place: %v", f, err)
+			}
+
+			linked, err := os.Stat(filepath.Join(testDir, "view", f))
+			if err != nil {
+				t.Fatalf("Expected a %s link for %s: %v", mode, f, err)
+			}
+
+			if !os.SameFile(original, linked) {
+				t.Fatalf("Expected the %s link to refer to %s", mode, f)
+			}
+		}
+
+		result, err = action(append(os.Args[0:1], "-u", "-x"))
+		if err != nil || result.applyError != nil {
+			t.Fatalf("Unexpected error: %v, %v", err, result.applyError)
+		}
+
+		for _, f := range []string{"a.txt", "b.txt"} {
+			if _, err := os.Stat(filepath.Join(testDir, f)); err != nil {
+				t.Fatalf("Expected %s to be left in place: %v", f, err)
+			}
+
+			_, err := os.Lstat(filepath.Join(testDir, "view", f))
+			if !os.IsNotExist(err) {
+				t.Fatalf("Expected the %s link for %s to be removed", mode, f)
+			}
+		}
+	}
+}
+
+func TestRemoveLink(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	a, b := filepath.Join(testDir, "a.txt"), filepath.Join(testDir, "b.txt")
+
+	err := removeLink(linkHard, b, a)
+	if err == nil {
+		t.Fatal("Expected an error when the link does not refer to the file")
+	}
+
+	err = removeLink(linkSym, b, a)
+	if err == nil {
+		t.Fatal("Expected an error when the path is not a symbolic link")
+	}
+
+	if _, err := os.Stat(b); err != nil {
+		t.Fatalf("Expected %s to be left in place: %v", b, err)
+	}
+}
@@ -0,0 +1,74 @@
+package f2
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+var errInvalidMapRoot = errors.New(
+	"Invalid argument: --map-root must be in the form old=<path>,new=<path>",
+)
+
+// rootMapping replaces the old root of a path with a new one
+type rootMapping struct {
+	old string
+	new string
+}
+
+// parseRootMappings parses the --map-root values which are in the
+// form `old=<path>,new=<path>`
+func parseRootMappings(values []string) ([]rootMapping, error) {
+	var mappings []rootMapping
+	for _, v := range values {
+		i := strings.LastIndex(v, ",new=")
+		if !strings.HasPrefix(v, "old=") || i == -1 {
+			return nil, errInvalidMapRoot
+		}
+
+		from, to := v[len("old="):i], v[i+len(",new="):]
+		if from == "" || to == "" {
+			return nil, errInvalidMapRoot
+		}
+
+		mappings = append(mappings, rootMapping{
+			old: filepath.Clean(filepath.FromSlash(from)),
+			new: filepath.Clean(filepath.FromSlash(to)),
+		})
+	}
+
+	return mappings, nil
+}
+
+// mapRoot returns the path with the first matching root replaced
+func mapRoot(path string, mappings []rootMapping) string {
+	path = filepath.Clean(path)
+	for _, m := range mappings {
+		if path == m.old {
+			return m.new
+		}
+
+		prefix := m.old
+		if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+			prefix += string(filepath.Separator)
+		}
+
+		if strings.HasPrefix(path, prefix) {
+			return filepath.Join(m.new, path[len(prefix):])
+		}
+	}
+
+	return path
+}
+
+// mapRoots remaps the base directory of each change so that a plan created
+// on another machine can be applied where the files live elsewhere
+func (op *Operation) mapRoots() {
+	if len(op.rootMappings) == 0 {
+		return
+	}
+
+	for i, ch := range op.matches {
+		op.matches[i].BaseDir = mapRoot(ch.BaseDir, op.rootMappings)
+	}
+}
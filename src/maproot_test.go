@@ -0,0 +1,81 @@
+package f2
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapRoot(t *testing.T) {
+	mappings, err := parseRootMappings([]string{
+		"old=/mnt/a,new=/data/a",
+		"old=/mnt/b/,new=/srv/b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"/mnt/a":       "/data/a",
+		"/mnt/a/x/y":   "/data/a/x/y",
+		"/mnt/ab/x":    "/mnt/ab/x",
+		"/mnt/b/photo": "/srv/b/photo",
+		"/home/user":   "/home/user",
+	}
+
+	for path, want := range cases {
+		got := filepath.ToSlash(mapRoot(filepath.FromSlash(path), mappings))
+		if got != want {
+			t.Fatalf("Expected %s to map to %s, but got %s", path, want, got)
+		}
+	}
+}
+
+func TestParseRootMappingsInvalid(t *testing.T) {
+	cases := [][]string{
+		{"old=/mnt/a"},
+		{"new=/data/a,old=/mnt/a"},
+		{"old=,new=/data/a"},
+		{"/mnt/a=/data/a"},
+	}
+
+	for _, v := range cases {
+		_, err := parseRootMappings(v)
+		if err != errInvalidMapRoot {
+			t.Fatalf("Expected %v to be rejected, but got: %v", v, err)
+		}
+	}
+}
+
+func TestImportPlanMapRoot(t *testing.T) {
+	testDir := setupFiles(t, []string{"scan1.tif"})
+
+	plan := filepath.Join(testDir, "plan.txt")
+	content := "/mnt/laptop/scans/scan1.tif /mnt/laptop/scans/archive/001.tif\n"
+
+	err := ioutil.WriteFile(plan, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []testCase{
+		{
+			name: "Remap the root of an imported plan",
+			want: []Change{
+				{
+					Source:  "scan1.tif",
+					BaseDir: testDir,
+					Target:  filepath.Join("archive", "001.tif"),
+				},
+			},
+			args: []string{
+				"--import",
+				plan,
+				"--map-root",
+				"old=/mnt/laptop/scans,new=" + testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,151 @@
+package f2
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// metadataCacheTTL is how long the metadata extracted from
+	// a file is kept after the file was last renamed or previewed
+	metadataCacheTTL = 7 * 24 * time.Hour
+	metadataProvider = "metadata"
+)
+
+// openMetadataCache prepares the on-disk cache for the metadata extracted
+// from files (hashes, EXIF data and ID3 tags) so that the metadata resolved
+// while previewing the changes is reused when they are applied with -x.
+// The operation proceeds without the cache if it cannot be created
+func (op *Operation) openMetadataCache() {
+	if op.noMetadataCache || op.metaCache != nil {
+		return
+	}
+
+	cache, err := newProviderCache(
+		metadataProvider,
+		metadataCacheTTL,
+		defaultCacheSize,
+	)
+	if err == nil && os.MkdirAll(cache.dir, os.ModePerm) == nil {
+		op.metaCache = cache
+	}
+}
+
+// metadataKey identifies a kind of metadata for the file. The key includes
+// the size and modification time of the file so that entries for files
+// that have been modified since are never used
+func metadataKey(kind, path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+
+	return fmt.Sprintf(
+		"%s|%s|%d|%d",
+		abs,
+		kind,
+		info.Size(),
+		info.ModTime().UnixNano(),
+	), true
+}
+
+// loadMetadata decodes the cached metadata of the
+// specified kind for the file into v
+func (op *Operation) loadMetadata(kind, path string, v interface{}) bool {
+	if op.metaCache == nil {
+		return false
+	}
+
+	key, ok := metadataKey(kind, path)
+	if !ok {
+		return false
+	}
+
+	b, ok := op.metaCache.get(key)
+	if !ok {
+		return false
+	}
+
+	return json.Unmarshal(b, v) == nil
+}
+
+// storeMetadata caches the metadata of the specified kind for the file.
+// Failing to write to the cache is not an error
+func (op *Operation) storeMetadata(kind, path string, v interface{}) {
+	if op.metaCache == nil {
+		return
+	}
+
+	key, ok := metadataKey(kind, path)
+	if !ok {
+		return
+	}
+
+	b, err := json.Marshal(v)
+	if err == nil {
+		_ = op.metaCache.write(key, b)
+	}
+}
+
+// fileHash returns the hash of the file using the cached value if present
+func (op *Operation) fileHash(path, hashFn string) (string, error) {
+	kind := "hash." + hashFn
+
+	var value string
+	if op.loadMetadata(kind, path, &value) {
+		return value, nil
+	}
+
+	value, err := getHash(path, hashFn)
+	if err != nil {
+		return "", err
+	}
+
+	op.storeMetadata(kind, path, value)
+
+	return value, nil
+}
+
+// cachedExifData returns the EXIF data of the file using
+// the cached data if present
+func (op *Operation) cachedExifData(path string) (*Exif, error) {
+	var data Exif
+	if op.loadMetadata("exif", path, &data) {
+		return &data, nil
+	}
+
+	exifData, err := getExifData(path)
+	if err != nil {
+		return nil, err
+	}
+
+	op.storeMetadata("exif", path, exifData)
+
+	return exifData, nil
+}
+
+// cachedID3Tags returns the ID3 tags of the file using
+// the cached tags if present
+func (op *Operation) cachedID3Tags(path string) (*ID3, error) {
+	var tags ID3
+	if op.loadMetadata("id3", path, &tags) {
+		return &tags, nil
+	}
+
+	id3, err := getID3Tags(path)
+	if err != nil {
+		return nil, err
+	}
+
+	op.storeMetadata("id3", path, id3)
+
+	return id3, nil
+}
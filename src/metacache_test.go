@@ -0,0 +1,71 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt"})
+	path := filepath.Join(testDir, "a.txt")
+
+	op := &Operation{metaCache: newTestCache(t, time.Hour, 0)}
+
+	want, err := getHash(path, sha1Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := op.fileHash(path, sha1Hash)
+	if err != nil || got != want {
+		t.Fatalf("Expected: %s, but got: %s (%v)", want, got, err)
+	}
+
+	key, ok := metadataKey("hash."+sha1Hash, path)
+	if !ok {
+		t.Fatal("Expected a key for an existing file")
+	}
+
+	// the cached value is used instead of hashing the file again
+	err = op.metaCache.write(key, []byte(`"cached"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = op.fileHash(path, sha1Hash)
+	if err != nil || got != "cached" {
+		t.Fatalf("Expected the cached hash to be used, but got: %s (%v)", got, err)
+	}
+
+	// modified files are not served from the cache
+	err = os.WriteFile(path, []byte("modified"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = op.fileHash(path, sha1Hash)
+	if err != nil || got == "cached" {
+		t.Fatalf("Expected the hash to be recomputed, but got: %s (%v)", got, err)
+	}
+
+	image := filepath.Join("..", "testdata", "images", "bike.jpeg")
+
+	wantExif, err := getExifData(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		gotExif, err := op.cachedExifData(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(gotExif, wantExif) {
+			t.Fatalf("Expected: %+v, but got: %+v", wantExif, gotExif)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package f2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// metaFileRegex matches variables that read a key from a metadata file
+// next to the renamed file such as `{{sidecar:info.json:title}}`. A `*` in
+// the file name is replaced with the name of the renamed file without its
+// extension (e.g. `{{sidecar:*.json:title}}` reads IMG_001.json for
+// IMG_001.jpg)
+var metaFileRegex = regexp.MustCompile(`{{sidecar:([^{}:|]+):([^{}:|]+)}}`)
+
+var errUnsupportedMetaFile = errors.New(
+	"Unsupported metadata file: use a .json, .yaml, .yml, .toml, .ini, .cfg or .conf file",
+)
+
+// flattenJSON adds the scalar values in the decoded JSON value to the map.
+// Keys of nested objects and array indices are joined with a period
+// (e.g. `camera.model` or `tags.0`)
+func flattenJSON(prefix string, value interface{}, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			flattenJSON(join(key), val, out)
+		}
+	case []interface{}:
+		for i, val := range v {
+			flattenJSON(join(strconv.Itoa(i)), val, out)
+		}
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// unquoteMetaValue removes the quotes surrounding a value
+// in an INI or YAML file
+func unquoteMetaValue(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		if s[0] == '"' {
+			if v, err := strconv.Unquote(s); err == nil {
+				return v
+			}
+		}
+
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
+
+// parseINI parses `key = value` (or `key: value`) pairs. Keys within
+// a section are prefixed with the name of the section (e.g. `album.title`)
+func parseINI(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+
+	var section string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i < 1 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:i])
+		if section != "" {
+			key = section + "." + key
+		}
+
+		values[key] = unquoteMetaValue(line[i+1:])
+	}
+
+	return values, scanner.Err()
+}
+
+// parseYAML parses the mappings in a YAML document. Nested mappings
+// are identified by their indentation and their keys are joined with
+// a period. The items of a sequence are numbered from 0 (e.g. `tags.0`
+// or `fields.1.name`). Other YAML constructs such as flow collections
+// and multi-line strings are not supported
+func parseYAML(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+
+	type level struct {
+		indent int
+		key    string
+		item   bool
+		items  int
+	}
+
+	var parents []level
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		line := strings.TrimSpace(text)
+		if line == "" || line[0] == '#' || line == "---" {
+			continue
+		}
+
+		indent := len(text) - len(line)
+
+		if line == "-" || strings.HasPrefix(line, "- ") {
+			for len(parents) > 0 && parents[len(parents)-1].indent > indent {
+				parents = parents[:len(parents)-1]
+			}
+
+			// the items of a sequence may be indented at the
+			// same level as the key of the sequence
+			if len(parents) > 0 && parents[len(parents)-1].item &&
+				parents[len(parents)-1].indent == indent {
+				parents = parents[:len(parents)-1]
+			}
+
+			if len(parents) == 0 {
+				continue
+			}
+
+			parent := &parents[len(parents)-1]
+			key := parent.key + "." + strconv.Itoa(parent.items)
+			parent.items++
+
+			content := strings.TrimSpace(line[1:])
+			if !strings.Contains(content, ": ") && !strings.HasSuffix(content, ":") {
+				if content != "" {
+					values[key] = unquoteMetaValue(content)
+				}
+
+				continue
+			}
+
+			// the item is a mapping whose first key
+			// follows the hyphen
+			parents = append(parents, level{indent: indent, key: key, item: true})
+			indent += len(line) - len(content)
+			line = content
+		}
+
+		i := strings.Index(line, ":")
+		if i < 1 {
+			continue
+		}
+
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+
+		key := unquoteMetaValue(line[:i])
+		if len(parents) > 0 {
+			key = parents[len(parents)-1].key + "." + key
+		}
+
+		value := strings.TrimSpace(line[i+1:])
+		if j := strings.Index(value, " #"); j != -1 {
+			value = strings.TrimSpace(value[:j])
+		}
+
+		if value == "" {
+			parents = append(parents, level{indent: indent, key: key})
+			continue
+		}
+
+		values[key] = unquoteMetaValue(value)
+	}
+
+	return values, scanner.Err()
+}
+
+// readMetaFile reads the keys and values in a metadata file according to
+// its extension. A missing file has no values
+func readMetaFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+
+		return nil, err
+	}
+
+	var values map[string]string
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var v interface{}
+
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+
+		err = d.Decode(&v)
+		if err == nil {
+			values = make(map[string]string)
+			flattenJSON("", v, values)
+		}
+	case ".yaml", ".yml":
+		values, err = parseYAML(bytes.NewReader(b))
+	case ".ini", ".cfg", ".conf":
+		values, err = parseINI(bytes.NewReader(b))
+	case ".toml":
+		var config map[string][]string
+
+		config, err = parseConfig(bytes.NewReader(b))
+		if err == nil {
+			values = make(map[string]string)
+			for k, v := range config {
+				values[k] = strings.Join(v, ", ")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedMetaFile, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// replaceMetaFileVariables replaces each `{{sidecar:<file>:<key>}}`
+// variable with the value of the key in the metadata file which is
+// located relative to the directory of the renamed file. Each metadata
+// file is read once per operation. Missing files and keys produce an
+// empty value so that a default value can be used
+func (op *Operation) replaceMetaFileVariables(
+	input, filePath string,
+) (string, error) {
+	if op.metaFiles == nil {
+		op.metaFiles = make(map[string]map[string]string)
+	}
+
+	name := filenameWithoutExtension(filepath.Base(filePath))
+
+	var err error
+
+	out := metaFileRegex.ReplaceAllStringFunc(input, func(v string) string {
+		if err != nil {
+			return v
+		}
+
+		submatch := metaFileRegex.FindStringSubmatch(v)
+		path := filepath.Join(
+			filepath.Dir(filePath),
+			strings.ReplaceAll(submatch[1], "*", name),
+		)
+
+		values, ok := op.metaFiles[path]
+		if !ok {
+			values, err = readMetaFile(path)
+			if err != nil {
+				return v
+			}
+
+			op.metaFiles[path] = values
+		}
+
+		return values[strings.TrimSpace(submatch[2])]
+	})
+
+	return out, err
+}
@@ -0,0 +1,150 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMetaFiles(t *testing.T) {
+	ini := `; camera dump
+title = "Summer Trip"
+[album]
+artist: Jane Doe
+`
+
+	got, err := parseINI(strings.NewReader(ini))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"title": "Summer Trip", "album.artist": "Jane Doe"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Expected: %v, but got: %v", want, got)
+	}
+
+	yaml := `---
+title: 'Summer Trip' # the trip
+camera:
+  model: X100V
+  lens:
+    focal: 23mm
+tags:
+  - beach
+year: 2021
+`
+
+	got, err = parseYAML(strings.NewReader(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = map[string]string{
+		"title":             "Summer Trip",
+		"camera.model":      "X100V",
+		"camera.lens.focal": "23mm",
+		"tags.0":            "beach",
+		"year":              "2021",
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Expected: %v, but got: %v", want, got)
+	}
+
+	sequences := `fields:
+- name: date
+  values:
+    - a
+    - b
+- name: seq
+nested:
+  - x
+  -   key: 'y'
+      other: z
+`
+
+	got, err = parseYAML(strings.NewReader(sequences))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = map[string]string{
+		"fields.0.name":     "date",
+		"fields.0.values.0": "a",
+		"fields.0.values.1": "b",
+		"fields.1.name":     "seq",
+		"nested.0":          "x",
+		"nested.1.key":      "y",
+		"nested.1.other":    "z",
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Expected: %v, but got: %v", want, got)
+	}
+}
+
+func TestMetaFileVariables(t *testing.T) {
+	testDir := setupFiles(t, []string{"IMG_001.jpg", "IMG_002.jpg"})
+
+	files := map[string]string{
+		"info.json":    `{"title": "Summer Trip", "camera": {"model": "X100V"}, "year": 2021}`,
+		"IMG_001.yaml": "caption: Beach",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(testDir, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []testCase{
+		{
+			name: "Read keys from a per-directory metadata file",
+			want: []Change{
+				{
+					Source:  "IMG_001.jpg",
+					BaseDir: testDir,
+					Target:  "Summer Trip 2021 X100V 001.jpg",
+				},
+				{
+					Source:  "IMG_002.jpg",
+					BaseDir: testDir,
+					Target:  "Summer Trip 2021 X100V 002.jpg",
+				},
+			},
+			args: []string{
+				"-f",
+				"IMG_(\\d+)\\.jpg",
+				"-r",
+				"{{sidecar:info.json:title}} {{sidecar:info.json:year}} {{sidecar:info.json:camera.model}} $1.jpg",
+				"--strict",
+				testDir,
+			},
+		},
+		{
+			name: "Read keys from a sibling metadata file",
+			want: []Change{
+				{
+					Source:  "IMG_001.jpg",
+					BaseDir: testDir,
+					Target:  "Beach.jpg",
+				},
+				{
+					Source:  "IMG_002.jpg",
+					BaseDir: testDir,
+					Target:  "untitled.jpg",
+				},
+			},
+			args: []string{
+				"-f",
+				"IMG_\\d+\\.jpg",
+				"-r",
+				"{{sidecar:*.yaml:caption|untitled}}{{ext}}",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,133 @@
+package f2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// metricsBuckets are the upper bounds in seconds of the
+// histogram buckets for the duration of each batch
+var metricsBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60}
+
+// watchMetrics records the outcome of the batches renamed in watch mode
+// so that they can be scraped by Prometheus
+type watchMetrics struct {
+	mu        sync.Mutex
+	processed int
+	renamed   int
+	errors    int
+	batches   int
+	seconds   float64
+	buckets   []int
+}
+
+// observe records the outcome of a batch
+func (m *watchMetrics) observe(op *Operation, err error, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.buckets == nil {
+		m.buckets = make([]int, len(metricsBuckets))
+	}
+
+	m.processed += len(op.paths)
+	m.errors += len(op.errors)
+
+	if err != nil && len(op.errors) == 0 {
+		m.errors++
+	}
+
+	if op.exec {
+		for _, v := range op.matches {
+			if !op.failed(v) {
+				m.renamed++
+			}
+		}
+	}
+
+	m.batches++
+	m.seconds += d.Seconds()
+
+	for i, v := range metricsBuckets {
+		if d.Seconds() <= v {
+			m.buckets[i]++
+		}
+	}
+}
+
+// write prints the metrics in the Prometheus text exposition format
+func (m *watchMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counters := []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"f2_files_processed_total", "New files handed to the renaming operation.", m.processed},
+		{"f2_renames_total", "Files renamed successfully.", m.renamed},
+		{"f2_errors_total", "Files that could not be renamed and failed batches.", m.errors},
+	}
+
+	for _, v := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n", v.name, v.help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", v.name)
+		fmt.Fprintf(w, "%s %d\n", v.name, v.value)
+	}
+
+	name := "f2_batch_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Time taken to rename each batch of files.\n", name)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+
+	for i, v := range metricsBuckets {
+		var count int
+		if m.buckets != nil {
+			count = m.buckets[i]
+		}
+
+		le := strconv.FormatFloat(v, 'g', -1, 64)
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, le, count)
+	}
+
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, m.batches)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(m.seconds, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, m.batches)
+}
+
+func (m *watchMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.write(w)
+}
+
+// serveMetrics exposes the metrics at /metrics on the specified address
+// until the context is cancelled. It returns the address of the listener
+// which differs from the specified one if the port is 0
+func serveMetrics(ctx context.Context, addr string, m *watchMetrics) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("Unable to serve metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	return listener.Addr().String(), nil
+}
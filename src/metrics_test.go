@@ -0,0 +1,116 @@
+package f2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchMetrics(t *testing.T) {
+	m := &watchMetrics{}
+
+	m.observe(&Operation{
+		exec:  true,
+		paths: []Change{{Source: "a.txt"}, {Source: "b.txt"}, {Source: "c.txt"}},
+		matches: []Change{
+			{Source: "a.txt", Target: "1.txt"},
+			{Source: "b.txt", Target: "2.txt"},
+		},
+		errors: []renameError{
+			{
+				entry: Change{Source: "b.txt", Target: "2.txt"},
+				err:   errors.New("permission denied"),
+			},
+		},
+	}, nil, 20*time.Millisecond)
+
+	m.observe(&Operation{
+		paths: []Change{{Source: "d.txt"}},
+	}, errors.New("conflict detected"), 2*time.Second)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	got := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE f2_files_processed_total counter\nf2_files_processed_total 4\n",
+		"f2_renames_total 1\n",
+		"f2_errors_total 2\n",
+		"# TYPE f2_batch_duration_seconds histogram\n",
+		"f2_batch_duration_seconds_bucket{le=\"0.01\"} 0\n",
+		"f2_batch_duration_seconds_bucket{le=\"0.05\"} 1\n",
+		"f2_batch_duration_seconds_bucket{le=\"5\"} 2\n",
+		"f2_batch_duration_seconds_bucket{le=\"+Inf\"} 2\n",
+		"f2_batch_duration_seconds_sum 2.02\n",
+		"f2_batch_duration_seconds_count 2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("Expected the metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	m := &watchMetrics{}
+	m.observe(&Operation{
+		exec:    true,
+		paths:   []Change{{Source: "a.txt"}},
+		matches: []Change{{Source: "a.txt", Target: "b.txt"}},
+	}, nil, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, err := serveMetrics(ctx, "127.0.0.1:0", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := "http://" + addr + "/metrics"
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK ||
+		!strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") ||
+		!strings.Contains(string(b), "f2_renames_total 1\n") {
+		t.Fatalf("Unexpected response (%d):\n%s", resp.StatusCode, b)
+	}
+
+	if _, err = serveMetrics(ctx, addr, m); err == nil {
+		t.Fatal("Expected an error when the address is in use")
+	}
+
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err = http.Get(url)
+		if err != nil {
+			break
+		}
+
+		resp.Body.Close()
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the server to stop once the context is cancelled")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
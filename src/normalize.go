@@ -0,0 +1,51 @@
+package f2
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var errInvalidNormalization = errors.New(
+	"Invalid argument: --normalize must be set to 'nfc', 'nfd', 'nfkc' or 'nfkd'",
+)
+
+// normalizationForms maps the values accepted by --normalize
+// to the corresponding Unicode normalization forms
+var normalizationForms = map[string]norm.Form{
+	"nfc":  norm.NFC,
+	"nfd":  norm.NFD,
+	"nfkc": norm.NFKC,
+	"nfkd": norm.NFKD,
+}
+
+// parseNormalization returns the normalization form for the
+// value of --normalize
+func parseNormalization(value string) (*norm.Form, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	form, ok := normalizationForms[strings.ToLower(value)]
+	if !ok {
+		return nil, errInvalidNormalization
+	}
+
+	return &form, nil
+}
+
+// normalizeUnicode converts the new file names to the specified Unicode
+// normalization form. The original file names are used if no replacement
+// is specified so that an entire tree can be normalized in one run
+func (op *Operation) normalizeUnicode() {
+	for i, ch := range op.matches {
+		target := ch.Target
+		if len(op.replacementSlice) == 0 && op.csvFile == "" &&
+			op.transform == nil {
+			target = ch.Source
+		}
+
+		op.matches[i].Target = op.normalization.String(target)
+	}
+}
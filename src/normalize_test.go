@@ -0,0 +1,43 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestNormalizeUnicode(t *testing.T) {
+	nfd, nfc := "Cafe\u0301.txt", "Caf\u00e9.txt"
+
+	testDir := setupFiles(t, []string{nfd})
+	nfcDir := setupFiles(t, []string{nfc})
+
+	cases := []testCase{
+		{
+			name: "Convert the file names to NFC",
+			want: []Change{
+				{
+					Source:  nfd,
+					BaseDir: testDir,
+					Target:  nfc,
+				},
+			},
+			args: []string{"--normalize", "nfc", testDir},
+		},
+		{
+			name: "Normalize the new file names",
+			want: []Change{
+				{
+					Source:  nfc,
+					BaseDir: nfcDir,
+					Target:  "Cafe\u0301.text",
+				},
+			},
+			args: []string{"-f", "txt", "-r", "text", "--normalize", "NFD", nfcDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+
+	if _, err := parseNormalization("nfx"); err != errInvalidNormalization {
+		t.Fatalf("Expected: %v, but got: %v", errInvalidNormalization, err)
+	}
+}
@@ -0,0 +1,74 @@
+package f2
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const notificationTitle = "F2"
+
+// notificationCommand returns the command used to display a desktop
+// notification on the specified operating system
+func notificationCommand(goos, title, message string) (string, []string) {
+	switch goos {
+	case darwin:
+		script := fmt.Sprintf(
+			"display notification %q with title %q",
+			message,
+			title,
+		)
+
+		return "osascript", []string{"-e", script}
+	case windows:
+		escape := func(s string) string {
+			return strings.ReplaceAll(s, "'", "''")
+		}
+
+		script := fmt.Sprintf(
+			`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode('%s')) > $null
+$text.Item(1).AppendChild($template.CreateTextNode('%s')) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s').Show($toast)`,
+			escape(title),
+			escape(message),
+			escape(title),
+		)
+
+		return "powershell", []string{"-NoProfile", "-Command", script}
+	default:
+		return "notify-send", []string{title, message}
+	}
+}
+
+// sendNotification displays a desktop notification with the given message
+func sendNotification(message string) error {
+	name, args := notificationCommand(runtime.GOOS, notificationTitle, message)
+	return exec.Command(name, args...).Run()
+}
+
+// notify reports the outcome of an executed operation
+// through a desktop notification
+func (op *Operation) notify(err error) {
+	if !op.notifyOnDone || !op.exec {
+		return
+	}
+
+	message := fmt.Sprintf("Renamed %d file(s) successfully", len(op.matches))
+	if op.revert {
+		message = fmt.Sprintf("Reverted %d file(s) successfully", len(op.matches))
+	}
+
+	if err != nil {
+		message = "Operation failed: " + err.Error()
+	}
+
+	nerr := sendNotification(message)
+	if nerr != nil {
+		printError(op.silent, fmt.Errorf("Failed to send notification: %w", nerr))
+	}
+}
@@ -0,0 +1,24 @@
+package f2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotificationCommand(t *testing.T) {
+	name, args := notificationCommand("linux", "F2", "Renamed 3 file(s)")
+	if name != "notify-send" || len(args) != 2 || args[1] != "Renamed 3 file(s)" {
+		t.Fatalf("Unexpected linux command: %s %v", name, args)
+	}
+
+	name, args = notificationCommand(darwin, "F2", `Say "hi"`)
+	want := `display notification "Say \"hi\"" with title "F2"`
+	if name != "osascript" || args[1] != want {
+		t.Fatalf("Unexpected macOS command: %s %v", name, args)
+	}
+
+	name, args = notificationCommand(windows, "F2", "it's done")
+	if name != "powershell" || !strings.Contains(args[2], "'it''s done'") {
+		t.Fatalf("Unexpected Windows command: %s %v", name, args)
+	}
+}
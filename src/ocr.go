@@ -0,0 +1,118 @@
+package f2
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// defaultOcrWords is the number of words used by `{{ocr.firstwords}}`
+	// when no count is specified
+	defaultOcrWords = 5
+	tesseract       = "tesseract"
+)
+
+// ocrBackend extracts the visible text from a scanned document or image
+type ocrBackend interface {
+	recognize(filePath string) (string, error)
+}
+
+// tesseractBackend performs OCR using the tesseract command line program
+type tesseractBackend struct{}
+
+func (tesseractBackend) recognize(filePath string) (string, error) {
+	return runOcrCommand(tesseract, filePath, "stdout")
+}
+
+// commandBackend performs OCR using a user specified program which receives
+// the file path as its final argument and prints the text to stdout
+type commandBackend struct {
+	name string
+	args []string
+}
+
+func (b commandBackend) recognize(filePath string) (string, error) {
+	args := append(append([]string{}, b.args...), filePath)
+	return runOcrCommand(b.name, args...)
+}
+
+func runOcrCommand(name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf(
+			"OCR backend '%s' failed: %w: %s",
+			name,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return stdout.String(), nil
+}
+
+// newOcrBackend returns the OCR backend identified by the specified command.
+// The tesseract backend is used by default
+func newOcrBackend(command string) ocrBackend {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || (len(fields) == 1 && fields[0] == tesseract) {
+		return tesseractBackend{}
+	}
+
+	return commandBackend{
+		name: fields[0],
+		args: fields[1:],
+	}
+}
+
+// firstWords returns the first n words in the text. Characters that cannot
+// be present in a file name are removed
+func firstWords(text string, n int) string {
+	var words []string
+	for _, w := range strings.Fields(text) {
+		w = fullWindowsForbiddenRegex.ReplaceAllString(w, "")
+		if w == "" {
+			continue
+		}
+
+		words = append(words, w)
+		if len(words) == n {
+			break
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// replaceOcrVariables replaces `{{ocr.firstwords}}` with the first words
+// of the text recognised in the file
+func (op *Operation) replaceOcrVariables(
+	input, filePath string,
+	ov ocrVar,
+) (string, error) {
+	if op.ocr == nil {
+		op.ocr = newOcrBackend(op.ocrCommand)
+	}
+
+	text, err := op.ocr.recognize(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range ov.submatches {
+		current := ov.values[i]
+		input = current.regex.ReplaceAllLiteralString(
+			input,
+			firstWords(text, current.words),
+		)
+	}
+
+	return input, nil
+}
@@ -0,0 +1,76 @@
+package f2
+
+import (
+	"runtime"
+	"testing"
+)
+
+type fakeOcrBackend struct {
+	text string
+}
+
+func (f fakeOcrBackend) recognize(filePath string) (string, error) {
+	return f.text, nil
+}
+
+func TestFirstWords(t *testing.T) {
+	cases := []struct {
+		text  string
+		words int
+		want  string
+	}{
+		{"INVOICE  No. 4521\nDate: 2021/05/04", 3, "INVOICE No. 4521"},
+		{"Date: 2021/05/04 total", 2, "Date 20210504"},
+		{"  one\ttwo  ", 5, "one two"},
+		{"", 5, ""},
+	}
+
+	for _, v := range cases {
+		got := firstWords(v.text, v.words)
+		if got != v.want {
+			t.Fatalf("Test (%q) — Expected: %s, but got: %s", v.text, v.want, got)
+		}
+	}
+}
+
+func TestReplaceOcrVariables(t *testing.T) {
+	op := &Operation{
+		ocr: fakeOcrBackend{text: "Annual Report 2020 Acme $1 Corp Ltd"},
+	}
+
+	input := "{{ocr.firstwords}} - {{ocr.firstwords.2}}"
+	ov, err := getOcrVar(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, err := op.replaceOcrVariables(input, "scan.png", ov)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "Annual Report 2020 Acme $1 - Annual Report"
+	if got != want {
+		t.Fatalf("Expected: %s, but got: %s", want, got)
+	}
+}
+
+func TestOcrCommandBackend(t *testing.T) {
+	if runtime.GOOS == windows {
+		t.Skip("echo is not an executable on Windows")
+	}
+
+	b := newOcrBackend("echo scanned text from")
+	got, err := b.recognize("file.pdf")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if firstWords(got, 5) != "scanned text from file.pdf" {
+		t.Fatalf("Unexpected OCR output: %s", got)
+	}
+
+	if _, ok := newOcrBackend(tesseract).(tesseractBackend); !ok {
+		t.Fatal("Expected the tesseract backend by default")
+	}
+}
@@ -2,18 +2,22 @@ package f2
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gookit/color"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
@@ -35,6 +39,9 @@ var (
 
 var pathSeperator = "/"
 
+// extPatternRegex matches an escaped extension at the end of a find pattern
+var extPatternRegex = regexp.MustCompile(`\\\.(\w+)(\$?)$`)
+
 const (
 	windows = "windows"
 	darwin  = "darwin"
@@ -51,6 +58,8 @@ type Change struct {
 	Source         string `json:"source"`
 	Target         string `json:"target"`
 	IsDir          bool   `json:"is_dir"`
+	csvRow         []string
+	steps          []Step
 }
 
 // renameError represents an error that occurs when
@@ -62,40 +71,135 @@ type renameError struct {
 
 // Operation represents a batch renaming operation
 type Operation struct {
-	paths             []Change
-	matches           []Change
-	conflicts         map[conflict][]Conflict
-	findSlice         []string
-	replacement       string
-	replacementSlice  []string
-	startNumber       int
-	exec              bool
-	fixConflicts      bool
-	includeHidden     bool
-	includeDir        bool
-	onlyDir           bool
-	ignoreCase        bool
-	ignoreExt         bool
-	searchRegex       *regexp.Regexp
-	directories       []string
-	recursive         bool
-	workingDir        string
-	stringLiteralMode bool
-	excludeFilter     []string
-	maxDepth          int
-	sort              string
-	reverseSort       bool
-	quiet             bool
-	errors            []renameError
-	revert            bool
-	numberOffset      []int
-	replaceLimit      int
+	paths              []Change
+	matches            []Change
+	conflicts          map[conflict][]Conflict
+	findSlice          []string
+	replacement        string
+	replacementSlice   []string
+	startNumber        int
+	exec               bool
+	fixConflicts       bool
+	hidden             hiddenPolicy
+	includeDir         bool
+	onlyDir            bool
+	ignoreCase         bool
+	ignoreExtCase      bool
+	ignoreExt          bool
+	searchRegex        *regexp.Regexp
+	searchRegexes      []*regexp.Regexp
+	directories        []string
+	recursive          bool
+	workingDir         string
+	stringLiteralMode  bool
+	excludeFilter      []string
+	maxDepth           int
+	sort               string
+	reverseSort        bool
+	quiet              bool
+	errors             []renameError
+	revert             bool
+	numberOffset       []int
+	replaceLimit       int
+	offline            bool
+	rateLimits         map[string]float64
+	providers          map[string]*provider
+	subtitleMode       bool
+	chapterMode        bool
+	rawPairMode        bool
+	orphanRaw          string
+	rawPairs           map[string][]Change
+	orphans            []Change
+	ocrCommand         string
+	ocr                ocrBackend
+	xcmds              map[string][]string
+	message            string
+	historyFile        string
+	undoFile           string
+	redo               bool
+	substitutions      []substitution
+	substitution       *substitution
+	rules              []rule
+	wholeWord          bool
+	anchor             string
+	durable            bool
+	globMode           bool
+	metadata           map[string]*fileMetadata
+	metaCache          *providerCache
+	noMetadataCache    bool
+	manifestFile       string
+	updateSymlinks     bool
+	symlinks           []symlinkChange
+	references         []referenceChange
+	refPatterns        []string
+	notifyOnDone       bool
+	webhook            string
+	onUnresolved       string
+	strict             bool
+	groups             map[string]fileGroup
+	interactive        bool
+	fuzzy              float64
+	fuzzyFind          string
+	csvFile            string
+	onDuplicate        string
+	json               bool
+	truncations        map[string][]string
+	reportFile         string
+	silent             bool
+	literalDot         bool
+	autoEscape         bool
+	delimiter          string
+	segmentMode        bool
+	linkMode           string
+	unlinkMode         string
+	previewSegments    bool
+	transactional      bool
+	createdDirs        []string
+	rolledBack         bool
+	transactionScope   string
+	rolledBackChanges  []Change
+	targetDir          string
+	targetStructure    string
+	flatten            bool
+	replacePath        bool
+	pairSidecar        bool
+	events             Events
+	planOnly           bool
+	seed               int64
+	seeded             bool
+	rng                *rand.Rand
+	now                func() time.Time
+	startsWith         string
+	endsWith           string
+	fs                 FS
+	sanitize           bool
+	transliterate      bool
+	showUnmatched      bool
+	exportFormat       string
+	importFile         string
+	print0             bool
+	rootMappings       []rootMapping
+	exportScriptFormat string
+	edit               bool
+	editor             string
+	extCase            string
+	extCaseExclude     []string
+	normalization      *norm.Form
+	metaFiles          map[string]map[string]string
+	splits             []datasetSplit
+	splitSeed          string
+	descendants        string
+	transform          *transformScript
 }
 
 type backupFile struct {
-	WorkingDir string   `json:"working_dir"`
-	Date       string   `json:"date"`
-	Operations []Change `json:"operations"`
+	WorkingDir string            `json:"working_dir"`
+	Date       string            `json:"date"`
+	Operations []Change          `json:"operations"`
+	Symlinks   []symlinkChange   `json:"symlinks,omitempty"`
+	References []referenceChange `json:"references,omitempty"`
+	Link       string            `json:"link,omitempty"`
+	Message    string            `json:"message,omitempty"`
 }
 
 func init() {
@@ -115,9 +219,33 @@ func createBackupDir(dir string) (string, error) {
 	return dirname, os.MkdirAll(filepath.Join(dirname, ".f2", dir), os.ModePerm)
 }
 
-// writeToFile writes the details of a successful operation
+// newBackupFile returns the details of a successful operation
+func (op *Operation) newBackupFile() backupFile {
+	operations := make([]Change, 0, len(op.matches))
+	for _, v := range op.matches {
+		if op.failed(v) {
+			continue
+		}
+
+		v.Source = portablePath(v.Source)
+		v.Target = portablePath(v.Target)
+		operations = append(operations, v)
+	}
+
+	return backupFile{
+		WorkingDir: op.workingDir,
+		Date:       time.Now().Format(time.RFC3339),
+		Operations: operations,
+		Symlinks:   op.symlinks,
+		References: op.references,
+		Link:       op.linkMode,
+		Message:    op.message,
+	}
+}
+
+// writeBackupFile writes the details of a successful operation
 // to the specified output file, creating it if necessary.
-func (op *Operation) writeToFile(outputFile string) (err error) {
+func writeBackupFile(outputFile string, bf backupFile) (err error) {
 	// Create or truncate file
 	file, err := os.Create(outputFile)
 	if err != nil {
@@ -131,14 +259,8 @@ func (op *Operation) writeToFile(outputFile string) (err error) {
 		}
 	}()
 
-	mf := backupFile{
-		WorkingDir: op.workingDir,
-		Date:       time.Now().Format(time.RFC3339),
-		Operations: op.matches,
-	}
-
 	writer := bufio.NewWriter(file)
-	b, err := json.MarshalIndent(mf, "", "    ")
+	b, err := json.MarshalIndent(bf, "", "    ")
 	if err != nil {
 		return err
 	}
@@ -153,7 +275,7 @@ func (op *Operation) writeToFile(outputFile string) (err error) {
 // undo reverses a successful renaming operation indicated
 // in the specified map file. The undo file is deleted
 // if the operation is successfully reverted
-func (op *Operation) undo(path string) error {
+func (op *Operation) undo(ctx context.Context, path string) error {
 	file, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -164,16 +286,45 @@ func (op *Operation) undo(path string) error {
 	if err != nil {
 		return err
 	}
-	op.matches = bf.Operations
+	op.matches = append([]Change(nil), bf.Operations...)
+	op.unlinkMode = bf.Link
+
+	// Paths are relative to the directory in which
+	// the operation was performed
+	if bf.WorkingDir != op.workingDir {
+		for i, v := range op.matches {
+			if !filepath.IsAbs(v.BaseDir) {
+				op.matches[i].BaseDir = filepath.Join(bf.WorkingDir, v.BaseDir)
+			}
+		}
+	}
+
+	op.mapRoots()
 
 	for i, v := range op.matches {
 		ch := v
-		ch.Source = v.Target
-		ch.Target = v.Source
+		ch.Source = localPath(v.Target)
+		ch.Target = localPath(v.Source)
 
 		op.matches[i] = ch
 	}
 
+	// Restore symlinks before their targets are moved back
+	if op.exec && len(bf.Symlinks) > 0 {
+		err = restoreSymlinks(bf.Symlinks)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Restore the references in text files before they are moved back
+	if op.exec && len(bf.References) > 0 {
+		err = replaceReferences(bf.References, true)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Sort only in print mode
 	if !op.exec && op.sort != "" {
 		err = op.sortBy()
@@ -182,12 +333,19 @@ func (op *Operation) undo(path string) error {
 		}
 	}
 
-	err = op.apply()
+	err = op.apply(ctx)
 	if err != nil {
 		return err
 	}
 
 	if op.exec {
+		forgetOperation(bf, path)
+
+		err = recordRedo(bf)
+		if err != nil {
+			return err
+		}
+
 		if err = os.Remove(path); err != nil {
 			fmt.Printf(
 				"Unable to remove redundant undo file '%s' after successful operation.",
@@ -211,7 +369,23 @@ func (op *Operation) printChanges() {
 		if source == target {
 			status = printColor("yellow", "unchanged")
 		}
-		d := []string{source, target, status}
+
+		if !utf8.ValidString(v.Target) {
+			status = printColor("yellow", "invalid utf-8")
+		}
+
+		if op.isOrphan(v) {
+			status = printColor("yellow", "orphaned raw")
+		}
+
+		if t, ok := op.truncations[source]; ok {
+			status = printColor(
+				"yellow",
+				"truncated: "+strings.Join(t, ", "),
+			)
+		}
+
+		d := []string{displayName(source), displayName(target), status}
 		data[i] = d
 	}
 
@@ -221,62 +395,124 @@ func (op *Operation) printChanges() {
 // rename iterates over all the matches and renames them on the filesystem
 // directories are auto-created if necessary.
 // Errors are aggregated ins""tead of being reported one by one
-func (op *Operation) rename() {
-	var errs []renameError
+func (op *Operation) rename(ctx context.Context) {
+	r := newRenamer(op)
+	for i := range op.matches {
+		// stop between renames so that chains and cycles are not
+		// left incomplete
+		if ctx.Err() != nil {
+			break
+		}
 
-	var renamed []Change
-	for _, ch := range op.matches {
-		var source, target = ch.Source, ch.Target
-		source = filepath.Join(ch.BaseDir, source)
-		target = filepath.Join(ch.BaseDir, target)
+		r.visit(i)
+	}
 
-		// skip unchanged file names
-		if source == target {
-			continue
+	op.matches = r.renamed
+	op.errors = r.errs
+}
+
+// renameChange renames the source path of the change to its target
+func (op *Operation) renameChange(ch Change, source, target string) error {
+	// If target contains a separator, create all missing
+	// directories before renaming the file
+	if hasDir(ch.Target, runtime.GOOS) {
+		// No need to check if the `dir` exists or if there are several
+		// consecutive slashes since `os.MkdirAll` handles that
+		dir := filepath.Join(ch.BaseDir, filepath.Dir(ch.Target))
+		if op.transactional {
+			if missing := missingDir(op.filesystem(), dir); missing != "" {
+				op.createdDirs = append(op.createdDirs, missing)
+			}
 		}
 
-		renameErr := renameError{
-			entry: ch,
+		err := op.filesystem().MkdirAll(dir, 0750)
+		if err != nil {
+			return err
 		}
+	}
 
-		// If target contains a slash, create all missing
-		// directories before renaming the file
-		if strings.Contains(ch.Target, "/") ||
-			strings.Contains(ch.Target, `\`) && runtime.GOOS == windows {
-			// No need to check if the `dir` exists or if there are several
-			// consecutive slashes since `os.MkdirAll` handles that
-			dir := filepath.Dir(ch.Target)
-			err := os.MkdirAll(filepath.Join(ch.BaseDir, dir), 0750)
-			if err != nil {
-				renameErr.err = err
-				errs = append(errs, renameErr)
-				continue
-			}
+	switch {
+	case op.linkMode != "":
+		return createLink(op.linkMode, source, target)
+	case op.unlinkMode != "":
+		return removeLink(op.unlinkMode, source, target)
+	}
+
+	return op.filesystem().Rename(source, target)
+}
+
+// failed reports whether an error occurred while renaming the change
+func (op *Operation) failed(ch Change) bool {
+	for _, v := range op.errors {
+		if v.entry.BaseDir == ch.BaseDir && v.entry.Source == ch.Source {
+			return true
 		}
+	}
 
-		if err := os.Rename(source, target); err != nil {
-			renameErr.err = err
-			errs = append(errs, renameErr)
+	return false
+}
+
+// writeManifest writes a sha256sum compatible manifest containing
+// the new path of each successfully renamed file
+func (op *Operation) writeManifest() (err error) {
+	file, err := os.Create(op.manifestFile)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		ferr := file.Close()
+		if ferr != nil && err == nil {
+			err = ferr
 		}
+	}()
 
-		renamed = append(renamed, ch)
+	writer := bufio.NewWriter(file)
+
+	for _, ch := range op.matches {
+		if ch.IsDir || op.failed(ch) {
+			continue
+		}
+
+		target := filepath.Join(ch.BaseDir, ch.Target)
+		sum, err := getHash(target, sha256Hash)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(writer, "%s  %s\n", sum, filepath.ToSlash(target))
+		if err != nil {
+			return err
+		}
 	}
 
-	op.matches = renamed
-	op.errors = errs
+	return writer.Flush()
 }
 
 // reportErrors displays the errors that occur during a renaming operation
 func (op *Operation) reportErrors() {
-	var data = make([][]string, len(op.errors)+len(op.matches))
-	for i, v := range op.matches {
+	var data = make([][]string, 0, len(op.errors)+len(op.matches))
+	for _, v := range op.matches {
 		source := filepath.Join(v.BaseDir, v.Source)
 		target := filepath.Join(v.BaseDir, v.Target)
-		d := []string{source, target, printColor("green", "success")}
-		data[i] = d
+		status := printColor("green", "success")
+		if op.rolledBack {
+			status = printColor("yellow", "rolled back")
+		}
+
+		d := []string{source, target, status}
+		data = append(data, d)
 	}
 
-	for i, v := range op.errors {
+	for _, v := range op.rolledBackChanges {
+		data = append(data, []string{
+			filepath.Join(v.BaseDir, v.Source),
+			filepath.Join(v.BaseDir, v.Target),
+			printColor("yellow", "rolled back"),
+		})
+	}
+
+	for _, v := range op.errors {
 		source := filepath.Join(v.entry.BaseDir, v.entry.Source)
 		target := filepath.Join(v.entry.BaseDir, v.entry.Target)
 
@@ -289,7 +525,7 @@ func (op *Operation) reportErrors() {
 			target,
 			printColor("red", strings.TrimPrefix(msg, ": ")),
 		}
-		data[i+len(op.matches)] = d
+		data = append(data, d)
 	}
 
 	printTable(data)
@@ -309,7 +545,9 @@ func (op *Operation) handleErrors() error {
 		}
 	}
 
-	op.reportErrors()
+	if !op.quiet {
+		op.reportErrors()
+	}
 
 	var err error
 	if len(op.matches) > 0 && !op.revert {
@@ -332,45 +570,163 @@ func (op *Operation) handleErrors() error {
 	return fmt.Errorf("The renaming operation failed due to the above errors")
 }
 
-// backup creates the path where the backup file
-// will be written to
+// backup writes the details of the operation to the backup file
+// for the current directory and records it in the history
 func (op *Operation) backup() error {
-	workingDir := strings.ReplaceAll(op.workingDir, pathSeperator, "_")
-	if runtime.GOOS == windows {
-		workingDir = strings.ReplaceAll(workingDir, ":", "_")
+	path, err := backupPath(op.workingDir)
+	if err != nil {
+		return err
 	}
 
-	dirname, err := createBackupDir("backups")
+	bf := op.newBackupFile()
+
+	err = writeBackupFile(path, bf)
 	if err != nil {
 		return err
 	}
 
-	file := workingDir + ".json"
+	op.historyFile, err = recordHistory(bf, op.historyFile)
+	if err != nil || op.redo {
+		return err
+	}
+
+	return clearRedo()
+}
 
-	return op.writeToFile(
-		filepath.Join(dirname, ".f2", "backups", file),
-	)
+// renamed reports whether any of the matches was renamed
+// successfully in a renaming operation that is not an undo
+func (op *Operation) renamed() bool {
+	if op.revert {
+		return false
+	}
+
+	for _, ch := range op.matches {
+		if !op.failed(ch) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// afterRename runs the steps that follow the renames. Each step is run
+// even if a previous one failed and their errors are combined
+func (op *Operation) afterRename(links []symlink) error {
+	var errs []error
+
+	if len(links) > 0 {
+		errs = append(errs, op.retargetSymlinks(links))
+	}
+
+	if op.replacePath && !op.revert {
+		op.removeEmptyDirs()
+	}
+
+	if len(op.refPatterns) > 0 && !op.revert && op.linkMode == "" {
+		errs = append(errs, op.updateReferences())
+	}
+
+	if op.manifestFile != "" && !op.revert {
+		errs = append(errs, op.writeManifest())
+	}
+
+	if op.reportFile != "" && !op.revert {
+		errs = append(errs, op.writeReport())
+	}
+
+	if op.durable {
+		errs = append(errs, op.syncDirs())
+	}
+
+	return combineErrors(errs...)
+}
+
+// backupPath returns the path to the backup file
+// for the specified working directory
+func backupPath(workingDir string) (string, error) {
+	dir := strings.ReplaceAll(workingDir, pathSeperator, "_")
+	if runtime.GOOS == windows {
+		dir = strings.ReplaceAll(dir, ":", "_")
+	}
+
+	dirname, err := createBackupDir("backups")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dirname, ".f2", "backups", dir+".json"), nil
 }
 
 // apply will check for conflicts and print the changes to be made
 // or apply them directly to the filesystem if in execute mode.
 // Conflicts will be ignored if indicated
-func (op *Operation) apply() error {
+func (op *Operation) apply(ctx context.Context) error {
+	op.normalizeTargets()
+
+	// the plan is computed without printing or renaming anything
+	if op.planOnly {
+		if op.transliterate && !op.revert {
+			op.transliterateTargets()
+		}
+
+		if op.sanitize && !op.revert {
+			op.sanitizeTargets()
+		}
+
+		op.validate()
+		return nil
+	}
+
 	if len(op.matches) == 0 {
 		msg := "Failed to match any files"
 		if op.revert {
 			msg = "No operations to undo"
 		}
 
+		if op.json {
+			return op.printJSON(os.Stdout, nil)
+		}
+
 		if !op.quiet {
 			fmt.Println(msg)
 		}
 		return nil
 	}
 
+	if op.edit && !op.revert {
+		err := op.editTargets()
+		if err != nil {
+			return err
+		}
+
+		op.normalizeTargets()
+	}
+
+	if op.interactive {
+		err := op.confirmChanges(os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+	}
+
+	if op.transliterate && !op.revert {
+		op.transliterateTargets()
+	}
+
+	if op.sanitize && !op.revert {
+		op.sanitizeTargets()
+	}
+
 	op.validate()
+	op.emitConflicts()
+
 	if len(op.conflicts) > 0 && !op.fixConflicts {
-		if !op.quiet {
+		if op.json {
+			err := op.printJSON(os.Stdout, errConflictDetected)
+			if err != nil {
+				return err
+			}
+		} else if !op.quiet {
 			op.reportConflicts()
 		}
 
@@ -382,14 +738,54 @@ func (op *Operation) apply() error {
 			op.sortMatches()
 		}
 
-		op.rename()
+		var links []symlink
+		if op.updateSymlinks && !op.revert && op.linkMode == "" {
+			var err error
+			links, err = op.findSymlinks()
+			if err != nil {
+				return err
+			}
+		}
+
+		op.rename(ctx)
 
+		if ctx.Err() != nil {
+			return op.interrupted(ctx.Err())
+		}
+
+		if op.transactional && len(op.errors) > 0 {
+			if op.transactionScope != scopeDir {
+				return op.abortTransaction()
+			}
+
+			err := op.rollbackDirs()
+			if err != nil {
+				return op.rollbackFailed(err)
+			}
+		}
+
+		// record the renames before the steps that follow them so
+		// that they can be reverted even if one of the steps fails
+		if op.renamed() {
+			err := op.backup()
+			if err != nil {
+				return err
+			}
+		}
+
+		err := op.afterRename(links)
+
+		// the backup is updated with the changes made after the renames
 		if len(op.errors) > 0 {
-			return op.handleErrors()
+			return combineErrors(err, op.handleErrors())
 		}
 
-		if len(op.matches) > 0 && !op.revert {
-			return op.backup()
+		if op.renamed() {
+			return combineErrors(err, op.backup())
+		}
+
+		if err != nil {
+			return err
 		}
 
 		if !op.quiet && !op.revert {
@@ -399,11 +795,35 @@ func (op *Operation) apply() error {
 		return nil
 	}
 
+	if op.exportFormat != "" {
+		return op.exportPlan(os.Stdout)
+	}
+
+	if op.print0 {
+		return op.printNull(os.Stdout)
+	}
+
+	if op.exportScriptFormat != "" {
+		return op.exportScript(os.Stdout)
+	}
+
+	if op.json {
+		return op.printJSON(os.Stdout, nil)
+	}
+
 	if op.quiet {
 		return nil
 	}
 
 	op.printChanges()
+
+	if op.descendants != "" {
+		err := op.printDescendants(os.Stdout)
+		if err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf(
 		"Append the %s flag to apply the above changes\n",
 		printColor("yellow", "-x"),
@@ -415,8 +835,12 @@ func (op *Operation) apply() error {
 // findMatches locates matches for the search pattern
 // in each filename. Hidden files and directories are exempted
 // by default
-func (op *Operation) findMatches() error {
+func (op *Operation) findMatches(ctx context.Context) error {
 	for _, v := range op.paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		filename := filepath.Base(v.Source)
 
 		if v.IsDir && !op.includeDir {
@@ -428,24 +852,45 @@ func (op *Operation) findMatches() error {
 		}
 
 		// ignore dotfiles on unix and hidden files on windows
-		if !op.includeHidden {
-			r, err := isHidden(filename, v.BaseDir)
-			if err != nil {
-				return err
-			}
-			if r {
-				continue
-			}
+		r, err := op.hidden.skip(
+			filename,
+			filepath.Dir(filepath.Join(v.BaseDir, v.Source)),
+			v.IsDir,
+		)
+		if err != nil {
+			return err
+		}
+		if r {
+			continue
 		}
 
 		var f = filename
+		if op.replacePath {
+			f = v.Source
+		}
+
 		if op.ignoreExt {
 			f = filenameWithoutExtension(f)
 		}
 
-		matched := op.searchRegex.MatchString(f)
+		// a file is matched if any of the find patterns that are paired
+		// with a replacement matches. Subsequent patterns that match every
+		// file name (such as `.*`) only transform the files matched by the
+		// other patterns
+		var matched bool
+		for i, re := range op.searchRegexes {
+			if i == 0 || (i < len(op.findSlice) && !re.MatchString("")) {
+				matched = matched || re.MatchString(f)
+			}
+		}
+
+		if op.fuzzy > 0 && op.fuzzyFind != "" {
+			matched = op.fuzzyMatch(f)
+		}
+
 		if matched {
 			op.matches = append(op.matches, v)
+			op.emitMatch(v)
 		}
 	}
 
@@ -483,7 +928,7 @@ func (op *Operation) setPaths(paths map[string][]os.DirEntry) {
 
 	// Don't bother sorting the paths in alphabetical order
 	// if a different sort has been set that's not the default
-	if op.sort != "" && op.sort != "default" {
+	if op.sort != "" && op.sort != sortDefault {
 		op.paths = op.sortPaths(paths, false)
 		return
 	}
@@ -494,17 +939,11 @@ func (op *Operation) setPaths(paths map[string][]os.DirEntry) {
 // retrieveBackupFile retrieves the path to a previously created
 // backup file for the current directory
 func (op *Operation) retrieveBackupFile() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	fullPath, err := backupPath(op.workingDir)
 	if err != nil {
 		return "", err
 	}
 
-	dir := strings.ReplaceAll(op.workingDir, pathSeperator, "_")
-	if runtime.GOOS == windows {
-		dir = strings.ReplaceAll(dir, ":", "_")
-	}
-
-	fullPath := filepath.Join(homeDir, ".f2", "backups", dir+".json")
 	if _, err := os.Stat(fullPath); err != nil {
 		return "", err
 	}
@@ -513,20 +952,46 @@ func (op *Operation) retrieveBackupFile() (string, error) {
 }
 
 // run executes the operation sequence
-func (op *Operation) run() error {
+func (op *Operation) run(ctx context.Context) error {
 	if op.revert {
-		path, err := op.retrieveBackupFile()
+		path := op.undoFile
+		if path == "" {
+			var err error
+
+			path, err = op.retrieveBackupFile()
+			if err != nil {
+				return fmt.Errorf(
+					"Failed to retrieve backup file for the current directory: %w",
+					err,
+				)
+			}
+		}
+
+		return op.undo(ctx, path)
+	}
+
+	if op.importFile != "" {
+		err := op.importPlan()
 		if err != nil {
-			return fmt.Errorf(
-				"Failed to retrieve backup file for the current directory: %w",
-				err,
-			)
+			return err
 		}
 
-		return op.undo(path)
+		return op.apply(ctx)
+	}
+
+	if op.replacePath {
+		err := op.usePathSources()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := op.findMatches(ctx)
+	if err != nil {
+		return err
 	}
 
-	err := op.findMatches()
+	err = op.escapeUnmatched(ctx)
 	if err != nil {
 		return err
 	}
@@ -545,9 +1010,67 @@ func (op *Operation) run() error {
 		}
 	}
 
+	if op.showUnmatched {
+		unmatched, err := op.unmatchedFiles()
+		if err != nil {
+			return err
+		}
+
+		printUnmatched(os.Stdout, unmatched)
+
+		return nil
+	}
+
+	if op.previewSegments {
+		op.printSegments(os.Stdout)
+		return nil
+	}
+
+	if op.subtitleMode {
+		op.matchSubtitles()
+		return op.apply(ctx)
+	}
+
+	if op.chapterMode {
+		err = op.sortChapters()
+		if err != nil {
+			return err
+		}
+
+		if len(op.replacementSlice) == 0 {
+			op.replacementSlice = []string{
+				defaultChapterTemplate(len(op.matches)),
+			}
+		}
+	}
+
+	if op.rawPairMode {
+		op.pairRawFiles()
+	}
+
+	// rename according to the CSV file if a replacement is not specified
+	if op.csvFile != "" && len(op.replacementSlice) == 0 {
+		op.csvTargets()
+	}
+
+	if op.transform != nil {
+		err = op.transformTargets(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	for i, v := range op.replacementSlice {
 		op.replacement = v
-		err = op.replace()
+		if i < len(op.substitutions) {
+			op.substitution = &op.substitutions[i]
+		}
+
+		if i < len(op.rules) {
+			op.ignoreExt = op.rules[i].ignoreExt
+			op.replaceLimit = op.rules[i].replaceLimit
+		}
+		err = op.replace(ctx)
 		if err != nil {
 			return err
 		}
@@ -563,135 +1086,510 @@ func (op *Operation) run() error {
 		}
 
 		if i != len(op.replacementSlice)-1 {
-			findPattern := ".*"
+			op.fuzzyFind = ".*"
 			if len(op.findSlice) > i+1 {
-				findPattern = op.findSlice[i+1]
+				op.fuzzyFind = op.findSlice[i+1]
 			}
 
-			if op.ignoreCase {
-				findPattern = "(?i)" + findPattern
-			}
+			op.searchRegex = op.searchRegexes[i+1]
+		}
+	}
 
-			re, err := regexp.Compile(findPattern)
-			if err != nil {
-				return err
-			}
-			op.searchRegex = re
+	if op.extCase != "" {
+		op.normalizeExtCase()
+	}
+
+	if op.normalization != nil {
+		op.normalizeUnicode()
+	}
+
+	if op.rawPairMode {
+		op.renameRawPairs()
+	}
+
+	if op.pairSidecar {
+		op.pairSidecars()
+	}
+
+	if op.targetDir != "" || op.flatten {
+		err = op.relocate()
+		if err != nil {
+			return err
 		}
 	}
 
-	return op.apply()
+	return op.apply(ctx)
 }
 
-// setOptions applies the command line arguments
-// onto the operation
-func setOptions(op *Operation, c *cli.Context) error {
-	op.findSlice = c.StringSlice("find")
-	op.replacementSlice = c.StringSlice("replace")
+// setFlags applies the command line flags that
+// are not part of Options onto the operation
+func setFlags(op *Operation, c *cli.Context) error {
+	op.wholeWord = c.Bool("whole-word")
+	op.globMode = c.Bool("glob")
+	op.anchor = c.String("anchor")
+
+	switch op.anchor {
+	case "", anchorStart, anchorEnd, anchorBoth:
+	default:
+		return errInvalidAnchor
+	}
+
+	substitutions, err := parseExprs(c.StringSlice("expr"))
+	if err != nil {
+		return err
+	}
+
+	if len(substitutions) > 0 {
+		if len(op.findSlice) > 0 || len(op.replacementSlice) > 0 {
+			return errExprWithFind
+		}
+
+		for _, v := range substitutions {
+			op.findSlice = append(op.findSlice, v.find)
+			op.replacementSlice = append(op.replacementSlice, v.replacement)
+		}
+
+		op.substitutions = substitutions
+	}
+
+	if path := c.String("rules"); path != "" {
+		if len(op.findSlice) > 0 || len(op.replacementSlice) > 0 {
+			return errRulesWithFind
+		}
+
+		op.rules, err = readRules(path)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range op.rules {
+			op.findSlice = append(op.findSlice, v.find)
+			op.replacementSlice = append(op.replacementSlice, v.replace)
+		}
+	}
+
+	if c.String("watch-rules") != "" &&
+		(!c.Bool("watch") || len(op.findSlice) > 0 ||
+			len(op.replacementSlice) > 0) {
+		return errWatchRules
+	}
 	op.exec = c.Bool("exec")
-	op.fixConflicts = c.Bool("fix-conflicts")
-	op.includeDir = c.Bool("include-dir")
-	op.includeHidden = c.Bool("hidden")
-	op.ignoreCase = c.Bool("ignore-case")
-	op.ignoreExt = c.Bool("ignore-ext")
-	op.recursive = c.Bool("recursive")
-	op.directories = c.Args().Slice()
-	op.onlyDir = c.Bool("only-dir")
-	op.stringLiteralMode = c.Bool("string-mode")
-	op.excludeFilter = c.StringSlice("exclude")
-	op.maxDepth = int(c.Uint("max-depth"))
+	op.hidden, err = newHiddenPolicy(
+		op.hidden.files,
+		c.Bool("hidden-files"),
+		c.Bool("hidden-dirs"),
+		c.StringSlice("include-dotdirs"),
+	)
+	if err != nil {
+		return err
+	}
+	op.ignoreExtCase = c.Bool("ignore-ext-case")
 	op.quiet = c.Bool("quiet")
+	op.silent = c.Bool("silent")
+	op.literalDot = c.Bool("literal-dot")
+	op.autoEscape = c.Bool("auto-escape")
+	op.delimiter = c.String("delimiter")
+	op.previewSegments = c.Bool("preview-segments")
+	op.showUnmatched = c.Bool("unmatched")
+	op.durable = c.Bool("durable")
+	op.noMetadataCache = c.Bool("no-metadata-cache")
+	op.transactionScope = c.String("transaction-scope")
+	op.transactional = c.Bool("transactional") || op.transactionScope == scopeDir
+
+	switch op.transactionScope {
+	case scopeOperation, scopeDir:
+	default:
+		return errInvalidTransactionScope
+	}
+	op.targetDir = c.String("target-dir")
+	op.targetStructure = c.String("target-structure")
+	op.flatten = c.Bool("flatten")
+	op.replacePath = c.Bool("replace-path")
+	op.pairSidecar = c.Bool("pair")
+	op.seeded = c.IsSet("seed")
+	op.transliterate = c.Bool("transliterate")
+	op.linkMode = c.String("link")
+
+	switch op.linkMode {
+	case "", linkHard, linkSym:
+	default:
+		return errInvalidLinkMode
+	}
+
+	if spec := c.String("segments"); spec != "" {
+		if len(op.replacementSlice) > 0 {
+			return errSegmentsWithReplace
+		}
+
+		template, err := segmentsTemplate(spec)
+		if err != nil {
+			return err
+		}
+
+		op.replacementSlice = []string{template}
+		op.segmentMode = true
+		op.ignoreExt = true
+	}
+
+	if path := c.String("script"); path != "" {
+		if len(op.replacementSlice) > 0 || c.String("csv") != "" {
+			return errScriptWithReplace
+		}
+
+		op.transform, err = loadTransformScript(path)
+		if err != nil {
+			return err
+		}
+	}
+
 	op.revert = c.Bool("undo")
-	op.replaceLimit = c.Int("replace-limit")
+	op.offline = c.Bool("offline")
+	op.subtitleMode = c.Bool("match-subtitles")
+	op.chapterMode = c.Bool("chapters")
+	op.rawPairMode = c.Bool("raw-pairs")
+	op.orphanRaw = c.String("orphan-raw")
+	op.ocrCommand = c.String("ocr-cmd")
+	op.manifestFile = c.String("write-manifest")
+	op.updateSymlinks = c.Bool("retarget-symlinks")
+	op.refPatterns = c.StringSlice("update-refs")
+	op.notifyOnDone = c.Bool("notify")
+	op.message = c.String("message")
+	op.webhook = c.String("webhook")
+	op.onUnresolved = c.String("on-unresolved")
+	op.strict = c.Bool("strict")
+	op.interactive = c.Bool("interactive")
+	op.fuzzy = c.Float64("fuzzy")
+	op.csvFile = c.String("csv")
+	op.onDuplicate = c.String("on-duplicate")
+	op.json = c.Bool("json")
+	op.reportFile = c.String("report")
+	op.exportFormat = c.String("export")
+	op.importFile = c.String("import")
+	op.print0 = c.Bool("print0")
+	op.edit = c.Bool("edit")
+	op.extCase = c.String("ext-case")
+
+	switch op.extCase {
+	case "", extLower, extUpper:
+	default:
+		return errInvalidExtCase
+	}
+
+	op.splits, err = parseSplits(c.String("split"))
+	if err != nil {
+		return err
+	}
+
+	op.splitSeed = c.String("split-seed")
+	op.descendants = c.String("descendants")
+
+	switch op.descendants {
+	case "", descendantsCount, descendantsList:
+	default:
+		return errInvalidDescendants
+	}
+
+	op.normalization, err = parseNormalization(c.String("normalize"))
+	if err != nil {
+		return err
+	}
+
+	for _, v := range c.StringSlice("ext-case-exclude") {
+		op.extCaseExclude = append(op.extCaseExclude, strings.Split(v, ",")...)
+	}
+	op.editor = editorCommand()
+	op.exportScriptFormat = c.String("export-script")
+
+	switch op.exportScriptFormat {
+	case "", scriptShell, scriptPowerShell:
+	default:
+		return errInvalidScriptFormat
+	}
+
+	switch op.exportFormat {
+	case "", formatMmv, formatQmv:
+	default:
+		return errInvalidPlanFormat
+	}
+
+	if op.fuzzy < 0 || op.fuzzy > 1 {
+		return errInvalidFuzzyThreshold
+	}
+
+	if op.strict {
+		for _, v := range op.replacementSlice {
+			err := checkVariables(v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	switch op.onUnresolved {
+	case unresolvedEmpty, unresolvedError, unresolvedSkip:
+	default:
+		return errInvalidUnresolvedPolicy
+	}
+
+	switch op.onDuplicate {
+	case "", duplicatePrefix, duplicateCounter, duplicateSkip:
+	default:
+		return errInvalidOnDuplicate
+	}
+
+	if op.orphanRaw != orphanSkip && op.orphanRaw != orphanFlag {
+		return errInvalidOrphanRaw
+	}
+
+	rateLimits, err := parseRateLimits(c.StringSlice("rate-limit"))
+	if err != nil {
+		return err
+	}
+	op.rateLimits = rateLimits
 
-	// Sorting
-	if c.String("sort") != "" {
-		op.sort = c.String("sort")
-	} else if c.String("sortr") != "" {
-		op.sort = c.String("sortr")
-		op.reverseSort = true
+	op.rootMappings, err = parseRootMappings(c.StringSlice("map-root"))
+	if err != nil {
+		return err
+	}
+
+	op.xcmds, err = parseXcmds(c.StringSlice("xcmd"))
+	if err != nil {
+		return err
+	}
+
+	switch op.targetStructure {
+	case structurePreserve, structureFlatten:
+	default:
+		return errInvalidTargetStructure
 	}
 
+	op.startsWith = c.String("starts-with")
+	op.endsWith = c.String("ends-with")
+
+	return nil
+}
+
+// prepare validates the options of the operation
+// and compiles its search patterns
+func (op *Operation) prepare() error {
 	if op.onlyDir {
 		op.includeDir = true
 	}
 
-	var findPattern string
+	if op.interactive {
+		op.exec = true
+	}
+
+	if op.silent {
+		op.quiet = true
+	}
+
 	if len(op.findSlice) > 0 {
-		findPattern = op.findSlice[0]
+		op.fuzzyFind = op.findSlice[0]
+	}
+
+	// Each replacement is paired with the find pattern at the same
+	// position. Missing find patterns match the entire file name
+	steps := len(op.replacementSlice)
+	if steps == 0 {
+		steps = 1
 	}
 
+	op.searchRegexes = make([]*regexp.Regexp, steps)
+	for i := range op.searchRegexes {
+		var findPattern string
+		if i < len(op.findSlice) {
+			findPattern = op.findSlice[i]
+		}
+
+		re, err := op.compileFind(findPattern)
+		if err != nil {
+			if !op.autoEscape {
+				return escapeSuggestion(findPattern, err)
+			}
+
+			re, err = op.compileFind(regexp.QuoteMeta(findPattern))
+			if err != nil {
+				return err
+			}
+		}
+
+		if i < len(op.substitutions) && op.substitutions[i].ignoreCase &&
+			!op.ignoreCase {
+			re, err = regexp.Compile("(?i)" + re.String())
+			if err != nil {
+				return err
+			}
+		}
+
+		op.searchRegexes[i] = re
+	}
+
+	// each rule is compiled with its own search options
+	for i, v := range op.rules {
+		re, err := v.regex()
+		if err != nil {
+			return err
+		}
+
+		op.searchRegexes[i] = re
+	}
+
+	if op.startsWith != "" || op.endsWith != "" {
+		if len(op.findSlice) > 0 {
+			return errAnchorWithFind
+		}
+
+		findPattern := anchoredPattern(op.startsWith, op.endsWith)
+		if op.ignoreCase {
+			findPattern = "(?i)" + findPattern
+		}
+
+		re, err := regexp.Compile(findPattern)
+		if err != nil {
+			return err
+		}
+
+		op.searchRegexes[0] = re
+	}
+
+	op.searchRegex = op.searchRegexes[0]
+
+	return nil
+}
+
+// compileFind compiles a find pattern in accordance with the search
+// options. An empty pattern matches the entire file name
+func (op *Operation) compileFind(findPattern string) (*regexp.Regexp, error) {
 	// Escape all regular expression metacharacters in string literal mode
-	if op.stringLiteralMode {
+	switch {
+	case op.globMode:
+		findPattern = globPattern(findPattern)
+	case op.stringLiteralMode && op.ignoreExtCase && !op.ignoreCase:
+		ext := filepath.Ext(findPattern)
+		findPattern = regexp.QuoteMeta(findPattern[:len(findPattern)-len(ext)])
+		if ext != "" {
+			findPattern += "(?i:" + regexp.QuoteMeta(ext) + ")"
+		}
+	case op.stringLiteralMode, op.fuzzy > 0:
+		// the fuzzy pattern is compared as a literal string
 		findPattern = regexp.QuoteMeta(findPattern)
+	case op.literalDot:
+		findPattern = escapeDots(findPattern)
+	}
+
+	literal := op.stringLiteralMode || op.globMode || op.fuzzy > 0
+
+	// Expand shorthand tokens such as `\num`
+	if !literal {
+		findPattern = expandShorthands(findPattern)
+	}
+
+	// Match a trailing extension (such as `\.jpg$`) case insensitively
+	if !literal && op.ignoreExtCase && !op.ignoreCase {
+		findPattern = extPatternRegex.ReplaceAllString(
+			findPattern,
+			`(?i:\.$1)$2`,
+		)
 	}
 
 	// Match entire string if find pattern is empty
 	if findPattern == "" {
 		findPattern = ".*"
+	} else {
+		findPattern = wrapPattern(findPattern, op.wholeWord, op.anchor)
 	}
 
 	if op.ignoreCase {
 		findPattern = "(?i)" + findPattern
 	}
 
-	re, err := regexp.Compile(findPattern)
-	if err != nil {
-		return err
-	}
-	op.searchRegex = re
-
-	return nil
+	return regexp.Compile(findPattern)
 }
 
-// newOperation returns an Operation constructed
-// from command line flags & arguments
-func newOperation(c *cli.Context) (*Operation, error) {
+// newOperation returns an Operation constructed from command line flags &
+// arguments. The flags that correspond to Options are applied in the same
+// way as New
+func newOperation(c *cli.Context, fsys FS) (*Operation, error) {
 	if len(c.StringSlice("find")) == 0 &&
 		len(c.StringSlice("replace")) == 0 &&
-		!c.Bool("undo") {
+		len(c.StringSlice("expr")) == 0 &&
+		c.String("rules") == "" &&
+		c.String("watch-rules") == "" &&
+		!c.Bool("undo") &&
+		!c.Bool("match-subtitles") &&
+		!c.Bool("chapters") &&
+		c.String("starts-with") == "" &&
+		c.String("segments") == "" &&
+		!c.Bool("preview-segments") &&
+		c.String("ends-with") == "" &&
+		c.String("csv") == "" &&
+		c.String("script") == "" &&
+		c.String("import") == "" &&
+		c.String("ext-case") == "" &&
+		c.String("normalize") == "" {
 		return nil, errInvalidArgument
 	}
 
 	op := &Operation{}
-	err := setOptions(op, c)
+	op.setOptions(flagOptions(c, fsys))
+
+	err := setFlags(op, c)
 	if err != nil {
 		return nil, err
 	}
 
+	err = op.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	return op, op.scan()
+}
+
+// scan finds the files in the target directories (or the
+// current directory) that the operation is applied to
+func (op *Operation) scan() error {
+	var err error
+
 	// Get the current working directory
 	op.workingDir, err = filepath.Abs(".")
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if op.revert {
-		return op, nil
+	if op.revert || op.importFile != "" {
+		return nil
+	}
+
+	if op.csvFile != "" {
+		return op.csvPaths()
 	}
 
 	var paths = make(map[string][]os.DirEntry)
 	for _, v := range op.directories {
-		paths[v], err = os.ReadDir(v)
+		paths[v], err = op.filesystem().ReadDir(v)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	// Use current directory
 	if len(paths) == 0 {
-		paths["."], err = os.ReadDir(".")
+		paths["."], err = op.filesystem().ReadDir(".")
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	if op.recursive {
-		paths, err = walk(paths, op.includeHidden, op.maxDepth)
+		paths, err = walk(op.filesystem(), paths, op.hidden, op.maxDepth)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	op.setPaths(paths)
-	return op, nil
+
+	return nil
 }
@@ -1,6 +1,7 @@
 package f2
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -82,6 +83,17 @@ func init() {
 		workingDir+".json",
 	)
 
+	// keep the history of the test operations separate
+	dataDir, err := ioutil.TempDir("", "f2-data")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.Setenv("XDG_DATA_HOME", dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	rand.Seed(time.Now().UnixNano())
 }
 
@@ -130,6 +142,44 @@ func setupFileSystem(t testing.TB) string {
 	return abs
 }
 
+// setupFiles creates a temporary directory containing empty files
+// with the specified names and returns its absolute path. Names may
+// include parent directories which are created as needed. The directory
+// is removed when the test is done
+func setupFiles(t *testing.T, files []string) string {
+	testDir, err := ioutil.TempDir(".", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err = os.RemoveAll(testDir); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for _, f := range files {
+		filePath := filepath.Join(testDir, f)
+
+		err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filePath, []byte{}, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	abs, err := filepath.Abs(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return abs
+}
+
 type ActionResult struct {
 	changes         []Change
 	conflicts       map[conflict][]Conflict
@@ -143,14 +193,14 @@ func action(args []string) (ActionResult, error) {
 
 	app := GetApp()
 	app.Action = func(c *cli.Context) error {
-		op, err := newOperation(c)
+		op, err := newOperation(c, osFS{})
 		if err != nil {
 			return err
 		}
 
 		op.quiet = true
 
-		result.applyError = op.run()
+		result.applyError = op.run(c.Context)
 		result.changes = op.matches
 		result.backupFile = backupFilePath
 		result.conflicts = op.conflicts
@@ -159,7 +209,7 @@ func action(args []string) (ActionResult, error) {
 		return nil
 	}
 
-	return result, app.Run(args)
+	return result, runApp(app, args)
 }
 
 func sortChanges(s []Change) {
@@ -249,6 +299,79 @@ func TestHidden(t *testing.T) {
 			},
 			args: []string{"-f", "pdf", "-r", "pdf.bak", "-H", "-R", testDir},
 		},
+		{
+			name: "Hidden files are included without descending into hidden directories",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "abc.pdf.bak",
+				},
+				{
+					Source:  ".forbidden.pdf",
+					BaseDir: testDir,
+					Target:  ".forbidden.pdf.bak",
+				},
+			},
+			args: []string{
+				"-f",
+				"pdf",
+				"-r",
+				"pdf.bak",
+				"--hidden-files",
+				"-R",
+				testDir,
+			},
+		},
+		{
+			name: "Hidden directories are descended into without including hidden files",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "abc.pdf.bak",
+				},
+				{
+					Source:  "sample.pdf",
+					BaseDir: filepath.Join(testDir, ".dir"),
+					Target:  "sample.pdf.bak",
+				},
+			},
+			args: []string{
+				"-f",
+				"pdf",
+				"-r",
+				"pdf.bak",
+				"--hidden-dirs",
+				"-R",
+				testDir,
+			},
+		},
+		{
+			name: "Hidden directories matching --include-dotdirs are descended into",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "abc.pdf.bak",
+				},
+				{
+					Source:  "sample.pdf",
+					BaseDir: filepath.Join(testDir, ".dir"),
+					Target:  "sample.pdf.bak",
+				},
+			},
+			args: []string{
+				"-f",
+				"pdf",
+				"-r",
+				"pdf.bak",
+				"--include-dotdirs",
+				".d*",
+				"-R",
+				testDir,
+			},
+		},
 	}
 
 	runFindReplace(t, cases)
@@ -509,6 +632,90 @@ func TestStringMode(t *testing.T) {
 				filepath.Join(testDir, "images"),
 			},
 		},
+		{
+			name: "Preserve the original case of the text that is not replaced",
+			want: []Change{
+				{
+					Source:  "No Pressure (2021) S1.E1.1080p.mkv",
+					BaseDir: testDir,
+					Target:  "No Limits (2021) S1.E1.1080p.mkv",
+				},
+				{
+					Source:  "No Pressure (2021) S1.E2.1080p.mkv",
+					BaseDir: testDir,
+					Target:  "No Limits (2021) S1.E2.1080p.mkv",
+				},
+				{
+					Source:  "No Pressure (2021) S1.E3.1080p.mkv",
+					BaseDir: testDir,
+					Target:  "No Limits (2021) S1.E3.1080p.mkv",
+				},
+			},
+			args: []string{"-f", "PRESSURE", "-r", "Limits", "-si", testDir},
+		},
+		{
+			name: "Match only the extension case insensitively",
+			want: []Change{
+				{
+					Source:  "b.jPg",
+					BaseDir: filepath.Join(testDir, "images"),
+					Target:  "c.jpeg",
+				},
+			},
+			args: []string{
+				"-f",
+				"b.jpg",
+				"-r",
+				"c.jpeg",
+				"-R",
+				"-s",
+				"--ignore-ext-case",
+				filepath.Join(testDir, "images"),
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestIgnoreExtCase(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	cases := []testCase{
+		{
+			name: "Match a trailing extension in a regex case insensitively",
+			want: []Change{
+				{
+					Source:  "a.jpg",
+					BaseDir: filepath.Join(testDir, "images"),
+					Target:  "a.jpeg",
+				},
+				{
+					Source:  "b.jPg",
+					BaseDir: filepath.Join(testDir, "images"),
+					Target:  "b.jpeg",
+				},
+				{
+					Source:  "123.JPG",
+					BaseDir: filepath.Join(testDir, "images", "pics"),
+					Target:  "123.jpeg",
+				},
+				{
+					Source:  "free.jpg",
+					BaseDir: filepath.Join(testDir, "images", "pics"),
+					Target:  "free.jpeg",
+				},
+			},
+			args: []string{
+				"-f",
+				`^([a-z0-9]+)\.jpg$`,
+				"-r",
+				"$1.jpeg",
+				"-R",
+				"--ignore-ext-case",
+				filepath.Join(testDir, "images"),
+			},
+		},
 	}
 
 	runFindReplace(t, cases)
@@ -684,3 +891,104 @@ func TestHandleErrors(t *testing.T) {
 		os.Remove(str)
 	}
 }
+
+func TestBackupAfterFailedStep(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	op := &Operation{
+		exec:         true,
+		quiet:        true,
+		workingDir:   testDir,
+		manifestFile: filepath.Join(testDir, "missing", "manifest.sha256"),
+		reportFile:   filepath.Join(testDir, "missing", "report.html"),
+		matches: []Change{
+			{Source: "a.txt", BaseDir: testDir, Target: "a.md"},
+			{Source: "b.txt", BaseDir: testDir, Target: "b.md"},
+		},
+	}
+
+	err := op.apply(context.Background())
+	if err == nil {
+		t.Fatal("Expected the manifest and report to fail")
+	}
+
+	if !strings.Contains(err.Error(), "manifest.sha256") ||
+		!strings.Contains(err.Error(), "report.html") {
+		t.Fatalf("Expected both errors to be reported, but got: %v", err)
+	}
+
+	defer os.Remove(op.historyFile)
+
+	path, err := op.retrieveBackupFile()
+	if err != nil {
+		t.Fatalf("Expected the renames to be backed up: %v", err)
+	}
+
+	defer os.Remove(path)
+
+	bf, err := readBackupFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bf.Operations) != 2 {
+		t.Fatalf("Expected two renames in the backup: %v", bf.Operations)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	path := filepath.Join(testDir, "abc.pdf")
+	err := ioutil.WriteFile(path, []byte("hello world\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := filepath.Join(testDir, "manifest.sha256")
+
+	args := os.Args[0:1]
+	args = append(
+		args,
+		"-f",
+		"abc",
+		"-r",
+		"xyz",
+		"--write-manifest",
+		manifest,
+		"-x",
+		testDir,
+	)
+
+	result, err := action(args)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defer os.Remove(result.backupFile)
+
+	if result.applyError != nil {
+		t.Fatalf("Unexpected apply error: %v", result.applyError)
+	}
+
+	b, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading manifest: %v", err)
+	}
+
+	// abc.epub is empty while abc.pdf contains "hello world\n"
+	want := []string{
+		"a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447  " +
+			filepath.ToSlash(filepath.Join(testDir, "xyz.pdf")),
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  " +
+			filepath.ToSlash(filepath.Join(testDir, "xyz.epub")),
+	}
+
+	got := strings.Split(strings.TrimSpace(string(b)), "\n")
+	sort.Strings(want)
+	sort.Strings(got)
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Expected: %v, but got: %v", want, got)
+	}
+}
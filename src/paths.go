@@ -0,0 +1,83 @@
+package f2
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// separators returns the characters that separate path segments on
+// the specified operating system
+func separators(goos string) string {
+	if goos == windows {
+		return `/\`
+	}
+
+	return "/"
+}
+
+// separator returns the preferred path separator of the
+// specified operating system
+func separator(goos string) string {
+	if goos == windows {
+		return `\`
+	}
+
+	return "/"
+}
+
+// normalizeSeparators converts all the path separators in the path
+// (whether they come from the replacement string or a variable) to the
+// preferred separator of the operating system and collapses consecutive
+// separators into one
+func normalizeSeparators(path, goos string) string {
+	seps, sep := separators(goos), separator(goos)
+
+	// the separators are ASCII so the path is processed byte by byte
+	// to preserve any bytes that are not valid UTF-8
+	var b strings.Builder
+	var prev bool
+	for i := 0; i < len(path); i++ {
+		if strings.IndexByte(seps, path[i]) != -1 {
+			if !prev {
+				b.WriteString(sep)
+			}
+
+			prev = true
+			continue
+		}
+
+		prev = false
+		b.WriteByte(path[i])
+	}
+
+	return b.String()
+}
+
+// hasDir reports whether the target contains a directory component
+func hasDir(target, goos string) bool {
+	return strings.ContainsAny(target, separators(goos))
+}
+
+// portablePath converts the relative path to the slash-separated form
+// that is stored in backup files so that operations recorded on one
+// operating system can be reverted on another
+func portablePath(path string) string {
+	return filepath.ToSlash(path)
+}
+
+// localPath converts a relative path that was stored in its portable form
+// (or with the separators of another operating system) to the
+// separators of the current operating system
+func localPath(path string) string {
+	return normalizeSeparators(filepath.FromSlash(path), runtime.GOOS)
+}
+
+// normalizeTargets normalizes the path separators in each target so that
+// subsequent checks and renames only need to handle the preferred
+// separator of the current operating system
+func (op *Operation) normalizeTargets() {
+	for i, ch := range op.matches {
+		op.matches[i].Target = normalizeSeparators(ch.Target, runtime.GOOS)
+	}
+}
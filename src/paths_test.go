@@ -0,0 +1,33 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestNormalizeSeparators(t *testing.T) {
+	cases := []struct {
+		path string
+		goos string
+		want string
+	}{
+		{path: "a/b.txt", goos: "linux", want: "a/b.txt"},
+		{path: "a//b///c.txt", goos: "linux", want: "a/b/c.txt"},
+		{path: `a\b.txt`, goos: "linux", want: `a\b.txt`},
+		{path: "a/b.txt", goos: windows, want: `a\b.txt`},
+		{path: `a/\b\\c.txt`, goos: windows, want: `a\b\c.txt`},
+		{path: "/a/", goos: darwin, want: "/a/"},
+	}
+
+	for _, tc := range cases {
+		got := normalizeSeparators(tc.path, tc.goos)
+		if got != tc.want {
+			t.Fatalf(
+				"Expected normalizeSeparators(%q, %s) to be %q, but got %q",
+				tc.path,
+				tc.goos,
+				tc.want,
+				got,
+			)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package f2
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// Step describes how a find and replace pair transformed a file name
+type Step struct {
+	// Find is the compiled find pattern
+	Find string `json:"find"`
+	// Replacement is the replacement string before variables are resolved
+	Replacement string `json:"replacement"`
+	// Groups are the capture groups of the first match in the file name
+	Groups []string `json:"groups,omitempty"`
+	// Replaced is the file name after the find pattern was replaced but
+	// before variables, numbering and transformations were applied
+	Replaced string `json:"replaced"`
+	// Result is the new file name produced by the step
+	Result string `json:"result"`
+}
+
+// PlannedChange is a change in the plan of an operation along with
+// the details of how its target was derived
+type PlannedChange struct {
+	Change
+	// Steps contains one entry for each find and replace pair
+	Steps []Step `json:"steps,omitempty"`
+	// Conflict is the type of conflict detected for the change (if any)
+	// as used in JSON output (e.g. file_exists)
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// recordStep records how the change was transformed by the current
+// find and replace pair when the operation is being planned
+func (op *Operation) recordStep(ch *Change, fileName, replaced string) {
+	if !op.planOnly {
+		return
+	}
+
+	step := Step{
+		Find:        op.searchRegex.String(),
+		Replacement: op.replacement,
+		Replaced:    unescapeLiterals(replaced),
+		Result:      ch.Target,
+	}
+
+	if m := op.searchRegex.FindStringSubmatch(fileName); len(m) > 1 {
+		step.Groups = m[1:]
+	}
+
+	ch.steps = append(ch.steps, step)
+}
+
+// reset clears the results of a previous run so that
+// the operation can be run again
+func (op *Operation) reset() {
+	op.matches = nil
+	op.conflicts = nil
+	op.errors = nil
+
+	if len(op.searchRegexes) > 0 {
+		op.searchRegex = op.searchRegexes[0]
+	}
+
+	if len(op.findSlice) > 0 {
+		op.fuzzyFind = op.findSlice[0]
+	}
+
+	// random values are repeated when the operation is run again
+	// with a seed
+	if op.seeded {
+		op.rng = nil
+	}
+}
+
+// Plan computes the changes of the operation without renaming any files.
+// Each change includes the steps through which its target was derived
+// and the type of conflict detected for it
+func (op *Operation) Plan() ([]PlannedChange, error) {
+	op.reset()
+
+	// nothing is renamed or recorded while planning
+	exec := op.exec
+	op.planOnly, op.exec = true, false
+
+	defer func() {
+		op.planOnly, op.exec = false, exec
+	}()
+
+	err := op.run(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make(map[string]string)
+	for k, v := range op.conflicts {
+		for _, c := range v {
+			for _, source := range c.source {
+				kinds[source] = conflictTypes[k]
+			}
+		}
+	}
+
+	plan := make([]PlannedChange, len(op.matches))
+	for i, ch := range op.matches {
+		plan[i] = PlannedChange{
+			Change:   ch,
+			Steps:    ch.steps,
+			Conflict: kinds[filepath.Join(ch.BaseDir, ch.Source)],
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,86 @@
+package f2
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/urfave/cli/v2"
+)
+
+func TestPlan(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_001.jpg",
+		"IMG_002.jpg",
+		"photo_1.jpg",
+	})
+
+	var plan []PlannedChange
+
+	app := GetApp()
+	app.Action = func(c *cli.Context) error {
+		op, err := newOperation(c, osFS{})
+		if err != nil {
+			return err
+		}
+
+		// planning twice produces the same result
+		_, err = op.Plan()
+		if err != nil {
+			return err
+		}
+
+		plan, err = op.Plan()
+
+		return err
+	}
+
+	args := append(os.Args[0:1],
+		"-f",
+		`IMG_(\d+)\.jpg`,
+		"-r",
+		"photo_$1{{ext}}",
+		"-f",
+		"_0+",
+		"-r",
+		"_",
+		testDir,
+	)
+
+	err := app.Run(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PlannedChange{
+		{
+			Change: Change{
+				Source:  "IMG_001.jpg",
+				BaseDir: testDir,
+				Target:  "photo_1.jpg",
+			},
+			Steps: []Step{
+				{
+					Find:        `IMG_(\d+)\.jpg`,
+					Replacement: "photo_$1{{ext}}",
+					Groups:      []string{"001"},
+					Replaced:    "photo_001{{ext}}",
+					Result:      "photo_001.jpg",
+				},
+				{
+					Find:        "_0+",
+					Replacement: "_",
+					Replaced:    "photo_1.jpg",
+					Result:      "photo_1.jpg",
+				},
+			},
+			Conflict: "file_exists",
+		},
+	}
+
+	opt := cmpopts.IgnoreUnexported(Change{})
+	if !cmp.Equal(want, plan[:1], opt) {
+		t.Fatalf("Plan: %s", cmp.Diff(want, plan[:1], opt))
+	}
+}
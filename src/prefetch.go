@@ -0,0 +1,112 @@
+package f2
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// fileMetadata is the metadata extracted from a file ahead of the
+// replacement loop
+type fileMetadata struct {
+	exif    *Exif
+	exifErr error
+	id3     *ID3
+	id3Err  error
+}
+
+// prefetchMetadata runs the expensive extractors (hashing, EXIF and ID3)
+// whose variables are referenced in the replacement across the matches
+// using a pool of workers. The replacement loop remains sequential since
+// variables such as indexes depend on the order of the matches, but it
+// uses the extracted metadata instead of reading each file in turn.
+// Extractors whose variables are not referenced are never run
+func (op *Operation) prefetchMetadata(ctx context.Context) {
+	var hashFns []string
+	for _, v := range hashRegex.FindAllStringSubmatch(op.replacement, -1) {
+		hashFns = append(hashFns, v[1])
+	}
+
+	useExif := exifRegex.MatchString(op.replacement)
+	useID3 := id3Regex.MatchString(op.replacement)
+
+	// a single file does not benefit from a worker pool
+	if (len(hashFns) == 0 && !useExif && !useID3) || len(op.matches) < 2 {
+		return
+	}
+
+	workers := runtime.NumCPU()
+	if workers > len(op.matches) {
+		workers = len(op.matches)
+	}
+
+	op.metadata = make(map[string]*fileMetadata)
+
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+
+	paths := make(chan string)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for path := range paths {
+				m := &fileMetadata{}
+
+				if useExif {
+					m.exif, m.exifErr = op.cachedExifData(path)
+				}
+
+				if useID3 {
+					m.id3, m.id3Err = op.cachedID3Tags(path)
+				}
+
+				// the hashes are kept in the hash cache and any errors
+				// are reported when the variable is replaced
+				for _, fn := range hashFns {
+					_, _ = op.fileHash(path, fn)
+				}
+
+				mu.Lock()
+				op.metadata[path] = m
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, ch := range op.matches {
+		if ctx.Err() != nil || ch.IsDir {
+			continue
+		}
+
+		paths <- filepath.Join(ch.BaseDir, ch.originalSource)
+	}
+
+	close(paths)
+	wg.Wait()
+}
+
+// exifData returns the EXIF data of the file, using the
+// prefetched data if it is available
+func (op *Operation) exifData(path string) (*Exif, error) {
+	if m, ok := op.metadata[path]; ok && (m.exif != nil || m.exifErr != nil) {
+		return m.exif, m.exifErr
+	}
+
+	return op.cachedExifData(path)
+}
+
+// id3Tags returns the ID3 tags of the file, using the
+// prefetched tags if they are available
+func (op *Operation) id3Tags(path string) (*ID3, error) {
+	if m, ok := op.metadata[path]; ok && (m.id3 != nil || m.id3Err != nil) {
+		return m.id3, m.id3Err
+	}
+
+	return op.cachedID3Tags(path)
+}
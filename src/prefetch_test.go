@@ -0,0 +1,58 @@
+package f2
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPrefetchMetadata(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "images")
+
+	op := &Operation{
+		replacement: "{{x.make}}-{{hash.sha1:8}}",
+		matches: []Change{
+			{BaseDir: testDir, Source: "bike.jpeg", originalSource: "bike.jpeg"},
+			{BaseDir: testDir, Source: "proraw.dng", originalSource: "proraw.dng"},
+			{BaseDir: testDir, Source: "bike.json", originalSource: "bike.json"},
+		},
+	}
+
+	op.prefetchMetadata(context.Background())
+
+	if len(op.metadata) != len(op.matches) {
+		t.Fatalf("Expected metadata for %d files, but got: %v", len(op.matches), op.metadata)
+	}
+
+	for _, ch := range op.matches {
+		path := filepath.Join(ch.BaseDir, ch.Source)
+
+		want, err := getExifData(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := op.exifData(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Expected the prefetched EXIF data of %s to be %+v, but got: %+v", path, want, got)
+		}
+
+		if op.metadata[path].id3 != nil {
+			t.Fatalf("Expected the ID3 tags of %s not to be extracted", path)
+		}
+	}
+
+	// extractors that are not referenced are not run
+	op.metadata = nil
+	op.replacement = "{{f}}-{{mtime.YYYY}}"
+	op.prefetchMetadata(context.Background())
+
+	if op.metadata != nil {
+		t.Fatalf("Expected no metadata to be extracted, but got: %v", op.metadata)
+	}
+}
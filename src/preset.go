@@ -0,0 +1,273 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	errInvalidPresetName = errors.New(
+		"Invalid preset name: use letters, numbers, periods, hyphens and underscores only",
+	)
+
+	errInvalidParam = errors.New(
+		"Invalid argument: --param must be in the form <name>=<value>",
+	)
+
+	errMissingPresetName = errors.New(
+		"Invalid argument: the name of the preset must be specified",
+	)
+
+	presetNameRegex = regexp.MustCompile(`^[\w.-]+$`)
+
+	// paramRegex matches the placeholders in a preset
+	// that are filled in with --param
+	paramRegex = regexp.MustCompile(`{{param\.([\w-]+)}}`)
+)
+
+// presetPath returns the location of the named preset which is kept in
+// the presets directory alongside the configuration file
+func presetPath(name string) (string, error) {
+	if !presetNameRegex.MatchString(name) {
+		return "", errInvalidPresetName
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "presets", name+".toml"), nil
+}
+
+// parseParams parses the --param values
+func parseParams(values []string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, v := range values {
+		i := strings.IndexByte(v, '=')
+		if i < 1 {
+			return nil, errInvalidParam
+		}
+
+		params[v[:i]] = v[i+1:]
+	}
+
+	return params, nil
+}
+
+// readPreset returns the options in the named preset with the parameter
+// placeholders (e.g. `{{param.event}}`) replaced by their values
+func readPreset(name string, params map[string]string) (map[string][]string, error) {
+	path, err := presetPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = os.Stat(path); err != nil {
+		return nil, fmt.Errorf("Unable to load preset '%s': %w", name, err)
+	}
+
+	preset, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range preset {
+		for i, v := range values {
+			var missing string
+			values[i] = paramRegex.ReplaceAllStringFunc(v, func(p string) string {
+				param := paramRegex.FindStringSubmatch(p)[1]
+
+				value, ok := params[param]
+				if !ok {
+					missing = param
+				}
+
+				return value
+			})
+
+			if missing != "" {
+				return nil, fmt.Errorf(
+					"Missing value for parameter '%s' of preset '%s'. Use --param %s=<value>",
+					missing,
+					name,
+					missing,
+				)
+			}
+		}
+
+		preset[key] = values
+	}
+
+	return preset, nil
+}
+
+// encodePreset returns the options as TOML with the keys sorted
+func encodePreset(options map[string][]string, slices map[string]bool) string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		values := options[k]
+
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = v
+			if _, err := strconv.ParseFloat(v, 64); err != nil &&
+				v != "true" && v != "false" {
+				quoted[i] = strconv.Quote(v)
+			}
+		}
+
+		if slices[k] {
+			fmt.Fprintf(&b, "%s = [%s]\n", k, strings.Join(quoted, ", "))
+		} else {
+			fmt.Fprintf(&b, "%s = %s\n", k, quoted[0])
+		}
+	}
+
+	return b.String()
+}
+
+// expandShortOptions splits combined short options (such as `-Rs`) into
+// separate flags. Otherwise, the values of the slice flags that precede
+// them are recorded twice since the arguments are parsed again after the
+// combined options are split
+func expandShortOptions(args []string, flags []cli.Flag) []string {
+	short := make(map[rune]bool)
+	takesValue := make(map[string]bool)
+	for _, f := range flags {
+		_, isBool := f.(*cli.BoolFlag)
+		for _, name := range f.Names() {
+			if len(name) == 1 {
+				short[rune(name[0])] = true
+			}
+
+			takesValue[name] = !isBool
+		}
+	}
+
+	var expanded []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(expanded, args[i:]...)
+		}
+
+		// the argument may be the value of the previous flag
+		combined := len(arg) > 2 && arg[0] == '-' && arg[1] != '-' &&
+			(i == 0 || !takesValue[strings.TrimLeft(args[i-1], "-")])
+		for _, r := range arg[1:] {
+			combined = combined && short[r]
+		}
+
+		if !combined {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		for _, r := range arg[1:] {
+			expanded = append(expanded, "-"+string(r))
+		}
+	}
+
+	return expanded
+}
+
+// presetOptions returns the options that are set by the flags so that
+// they can be saved as a preset. The flags are parsed in the same way as
+// the command line so that short options may be combined
+func presetOptions(args []string) (map[string][]string, map[string]bool, error) {
+	options := make(map[string][]string)
+	slices := make(map[string]bool)
+
+	app := GetApp()
+	app.Commands = nil
+	app.Action = func(c *cli.Context) error {
+		if c.NArg() > 0 {
+			return fmt.Errorf(
+				"Presets cannot include paths: %s",
+				strings.Join(c.Args().Slice(), ", "),
+			)
+		}
+
+		for _, f := range c.App.Flags {
+			name := f.Names()[0]
+			if name == "preset" || name == "param" {
+				continue
+			}
+
+			var set bool
+			for _, v := range f.Names() {
+				set = set || c.IsSet(v)
+			}
+
+			if !set {
+				continue
+			}
+
+			switch f.(type) {
+			case *cli.StringSliceFlag:
+				options[name] = c.StringSlice(name)
+				slices[name] = true
+			case *cli.BoolFlag:
+				options[name] = []string{strconv.FormatBool(c.Bool(name))}
+			default:
+				options[name] = []string{c.Generic(name).(fmt.Stringer).String()}
+			}
+		}
+
+		return nil
+	}
+
+	args = expandShortOptions(args, app.Flags)
+
+	err := app.Run(append([]string{app.Name}, args...))
+
+	return options, slices, err
+}
+
+// savePreset stores the flags that follow the name of the preset so that
+// they can be replayed with --preset
+func savePreset(c *cli.Context) error {
+	name := c.Args().First()
+	if name == "" {
+		return errMissingPresetName
+	}
+
+	path, err := presetPath(name)
+	if err != nil {
+		return err
+	}
+
+	options, slices, err := presetOptions(c.Args().Tail())
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, []byte(encodePreset(options, slices)), 0600)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved preset '%s' to %s\n", name, path)
+
+	return nil
+}
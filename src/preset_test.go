@@ -0,0 +1,89 @@
+package f2
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPreset(t *testing.T) {
+	setConfigHome(t)
+
+	err := GetApp().Run([]string{
+		"f2",
+		"preset",
+		"save",
+		"photo-import",
+		"-f",
+		"IMG_",
+		"-r",
+		"{{param.event}}-%03d{{ext}}",
+		"-Rs",
+		"--sort",
+		"mtime",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := presetPath("photo-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `find = ["IMG_"]
+recursive = true
+replace = ["{{param.event}}-%03d{{ext}}"]
+sort = "mtime"
+string-mode = true
+`
+	if string(b) != want {
+		t.Fatalf("Expected preset:\n%s\nbut got:\n%s", want, string(b))
+	}
+
+	c := runWithConfig(
+		t,
+		"--preset",
+		"photo-import",
+		"--param",
+		"event=Wedding",
+		"--sort",
+		"size",
+	)
+
+	if !cmp.Equal(c.StringSlice("replace"), []string{"Wedding-%03d{{ext}}"}) ||
+		!c.Bool("recursive") || c.String("sort") != "size" {
+		t.Fatalf(
+			"Unexpected options: %v %v %s",
+			c.StringSlice("replace"),
+			c.Bool("recursive"),
+			c.String("sort"),
+		)
+	}
+
+	app := GetApp()
+	app.Action = applyConfig
+
+	err = app.Run([]string{"f2", "--preset", "photo-import"})
+	if err == nil {
+		t.Fatal("Expected an error for the missing parameter")
+	}
+
+	for _, name := range []string{"../escape", "a/b", ""} {
+		if _, err := presetPath(name); err != errInvalidPresetName {
+			t.Fatalf("Expected %q to be rejected, but got: %v", name, err)
+		}
+	}
+
+	_, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,177 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRateLimit is the number of requests per second that can be
+	// made to an online provider when no limit is configured
+	defaultRateLimit = 1
+	providerTimeout  = 20 * time.Second
+)
+
+var (
+	errOffline = errors.New(
+		"Network access is disabled in offline mode and no cached data is available",
+	)
+
+	errInvalidRateLimit = errors.New(
+		"Invalid rate limit: expected a value in the form <provider>=<requests per second>",
+	)
+)
+
+// rateLimiter ensures that successive requests to an online provider are
+// spaced out by a minimum interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+// wait blocks until the next request is allowed to proceed
+func (r *rateLimiter) wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interval <= 0 {
+		return
+	}
+
+	next := r.last.Add(r.interval)
+	if d := time.Until(next); d > 0 {
+		time.Sleep(d)
+	}
+
+	r.last = time.Now()
+}
+
+// provider represents an online metadata source (such as the Nominatim
+// geocoder). Responses are cached on disk and requests are
+// rate limited
+type provider struct {
+	name    string
+	offline bool
+	cache   *providerCache
+	limiter *rateLimiter
+	client  http.Client
+}
+
+// fetch retrieves the resource at the specified url. Cached responses are
+// preferred and the network is never accessed in offline mode
+func (p *provider) fetch(url string) ([]byte, error) {
+	if b, ok := p.cache.get(url); ok {
+		return b, nil
+	}
+
+	if p.offline {
+		return nil, fmt.Errorf("%s: %w", p.name, errOffline)
+	}
+
+	p.limiter.wait()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	// public providers such as Nominatim reject requests
+	// without an identifying user agent
+	req.Header.Set("User-Agent", "f2 (https://github.com/ayoisaiah/f2)")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"%s: unexpected response status: %s",
+			p.name,
+			resp.Status,
+		)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	err = p.cache.set(url, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// getProvider returns the named online provider, creating it on first use
+func (op *Operation) getProvider(name string) (*provider, error) {
+	if p, ok := op.providers[name]; ok {
+		return p, nil
+	}
+
+	cache, err := newProviderCache(name, defaultCacheTTL, defaultCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, ok := op.rateLimits[name]
+	if !ok {
+		rate = defaultRateLimit
+	}
+
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	p := &provider{
+		name:    name,
+		offline: op.offline,
+		cache:   cache,
+		limiter: &rateLimiter{interval: interval},
+		client:  http.Client{Timeout: providerTimeout},
+	}
+
+	if op.providers == nil {
+		op.providers = make(map[string]*provider)
+	}
+
+	op.providers[name] = p
+
+	return p, nil
+}
+
+// parseRateLimits parses rate limits in the form
+// `<provider>=<requests per second>`. A rate of zero disables rate limiting
+// for that provider
+func parseRateLimits(values []string) (map[string]float64, error) {
+	limits := make(map[string]float64)
+
+	for _, v := range values {
+		slice := strings.Split(v, "=")
+		expectedLength := 2
+		if len(slice) != expectedLength || slice[0] == "" {
+			return nil, fmt.Errorf("%w: %s", errInvalidRateLimit, v)
+		}
+
+		rate, err := strconv.ParseFloat(slice[1], 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("%w: %s", errInvalidRateLimit, v)
+		}
+
+		limits[strings.ToLower(slice[0])] = rate
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,61 @@
+package f2
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	got, err := parseRateLimits([]string{"MusicBrainz=1", "tvdb=0.5", "geo=0"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]float64{"musicbrainz": 1, "tvdb": 0.5, "geo": 0}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Expected: %v, but got: %v", want, got)
+	}
+
+	for _, v := range []string{"tvdb", "=1", "tvdb=fast", "tvdb=-1"} {
+		_, err := parseRateLimits([]string{v})
+		if !errors.Is(err, errInvalidRateLimit) {
+			t.Fatalf("Test (%s) — Expected an invalid rate limit error", v)
+		}
+	}
+}
+
+func TestProviderOffline(t *testing.T) {
+	p := newTestProvider(t, "f2-test", true)
+
+	_, err := p.fetch("https://example.com/uncached")
+	if !errors.Is(err, errOffline) {
+		t.Fatalf("Expected an offline error, but got: %v", err)
+	}
+
+	err = p.cache.set("https://example.com/cached", []byte("data"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := p.fetch("https://example.com/cached")
+	if err != nil || string(b) != "data" {
+		t.Fatalf("Expected cached data in offline mode, but got: %s (%v)", b, err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	interval := 50 * time.Millisecond
+	r := &rateLimiter{interval: interval}
+
+	start := time.Now()
+	r.wait()
+	r.wait()
+	r.wait()
+
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Fatalf("Expected requests to be spaced out, but took only %v", elapsed)
+	}
+}
@@ -0,0 +1,55 @@
+package f2
+
+import (
+	"fmt"
+	"io"
+)
+
+// printQuietSummary writes the errors that occurred and a one-line summary
+// of the operation in logfmt so that failures are not silent when running
+// in quiet mode (such as from cron)
+func (op *Operation) printQuietSummary(w io.Writer, err error) {
+	for _, v := range op.summary(nil).Errors {
+		fmt.Fprintf(
+			w,
+			"f2: level=error source=%q target=%q error=%q\n",
+			v.Source,
+			v.Target,
+			v.Error,
+		)
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+		fmt.Fprintf(
+			w,
+			"f2: level=error error=%q\n",
+			ansiRegex.ReplaceAllString(err.Error(), ""),
+		)
+	}
+
+	var changes, conflicts int
+	for _, ch := range op.matches {
+		if !op.failed(ch) {
+			changes++
+		}
+	}
+
+	changes += len(op.errors)
+
+	for _, v := range op.conflicts {
+		conflicts += len(v)
+	}
+
+	fmt.Fprintf(
+		w,
+		"f2: status=%s exec=%t undo=%t changes=%d failed=%d conflicts=%d\n",
+		status,
+		op.exec,
+		op.revert,
+		changes,
+		len(op.errors),
+		conflicts,
+	)
+}
@@ -0,0 +1,50 @@
+package f2
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPrintQuietSummary(t *testing.T) {
+	op := &Operation{
+		exec:  true,
+		quiet: true,
+		matches: []Change{
+			{Source: "a.txt", Target: "b.txt", BaseDir: "docs"},
+		},
+		errors: []renameError{
+			{
+				entry: Change{Source: "c.txt", Target: "d.txt", BaseDir: "docs"},
+				err:   errors.New("permission denied"),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	op.printQuietSummary(&buf, errors.New("Some files could not be renamed"))
+
+	want := `f2: level=error source="c.txt" target="d.txt" error="permission denied"
+f2: level=error error="Some files could not be renamed"
+f2: status=error exec=true undo=false changes=2 failed=1 conflicts=0
+`
+
+	if buf.String() != want {
+		t.Fatalf("Expected: %s, but got: %s", want, buf.String())
+	}
+
+	op = &Operation{
+		quiet: true,
+		matches: []Change{
+			{Source: "a.txt", Target: "b.txt", BaseDir: "docs"},
+		},
+	}
+
+	buf.Reset()
+	op.printQuietSummary(&buf, nil)
+
+	want = "f2: status=ok exec=false undo=false changes=1 failed=0 conflicts=0\n"
+	if buf.String() != want {
+		t.Fatalf("Expected: %s, but got: %s", want, buf.String())
+	}
+}
@@ -0,0 +1,121 @@
+package f2
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	rawExtensions = []string{
+		".cr2", ".cr3", ".nef", ".nrw", ".arw", ".dng", ".raf",
+		".orf", ".rw2", ".pef", ".srw", ".raw",
+	}
+	jpegExtensions = []string{".jpg", ".jpeg"}
+
+	errInvalidOrphanRaw = errors.New(
+		"Invalid argument: --orphan-raw must be set to 'skip' or 'flag'",
+	)
+)
+
+const (
+	orphanSkip = "skip"
+	orphanFlag = "flag"
+)
+
+// pairKey identifies the files that belong to the same photo
+// (e.g `IMG_0001.CR2` and `IMG_0001.JPG`)
+func pairKey(ch Change) string {
+	return filepath.Join(
+		ch.BaseDir,
+		strings.ToLower(filenameWithoutExtension(ch.Source)),
+	)
+}
+
+// pairRawFiles removes RAW files from the matches and pairs the JPEG
+// matches with the RAW files of the same name so that only the JPEG takes
+// part in the renaming operation. RAW files whose JPEG has been deleted
+// (such as during culling) are skipped or flagged depending on the
+// --orphan-raw option
+func (op *Operation) pairRawFiles() {
+	jpegs := make(map[string]bool)
+	for _, ch := range op.paths {
+		ext := strings.ToLower(filepath.Ext(ch.Source))
+		if !ch.IsDir && contains(jpegExtensions, ext) {
+			jpegs[pairKey(ch)] = true
+		}
+	}
+
+	op.rawPairs = make(map[string][]Change)
+
+	var primary []Change
+	for _, ch := range op.matches {
+		ext := strings.ToLower(filepath.Ext(ch.Source))
+		if ch.IsDir || !contains(rawExtensions, ext) {
+			primary = append(primary, ch)
+			if !ch.IsDir && contains(jpegExtensions, ext) {
+				op.rawPairs[pairKey(ch)] = nil
+			}
+
+			continue
+		}
+
+		// RAW files with a JPEG are renamed along with the JPEG
+		if jpegs[pairKey(ch)] {
+			continue
+		}
+
+		if op.orphanRaw == orphanFlag {
+			ch.Target = ch.Source
+			op.orphans = append(op.orphans, ch)
+		}
+	}
+
+	for _, ch := range op.paths {
+		ext := strings.ToLower(filepath.Ext(ch.Source))
+		if ch.IsDir || !contains(rawExtensions, ext) {
+			continue
+		}
+
+		key := pairKey(ch)
+		if _, ok := op.rawPairs[key]; ok {
+			op.rawPairs[key] = append(op.rawPairs[key], ch)
+		}
+	}
+
+	op.matches = primary
+}
+
+// renameRawPairs adds the paired RAW files back to the matches using the
+// same target name as their JPEG file so that both receive identical
+// serial numbers. Flagged orphans are included but left unchanged
+func (op *Operation) renameRawPairs() {
+	var raws []Change
+	for _, ch := range op.matches {
+		pairs, ok := op.rawPairs[pairKey(ch)]
+		if !ok || ch.IsDir {
+			continue
+		}
+
+		dir := filepath.Dir(ch.Target)
+		base := filenameWithoutExtension(filepath.Base(ch.Target))
+		for _, raw := range pairs {
+			raw.Target = filepath.Join(dir, base+filepath.Ext(raw.Source))
+			raws = append(raws, raw)
+		}
+	}
+
+	raws = append(raws, op.orphans...)
+	op.matches = append(op.matches, raws...)
+}
+
+// isOrphan reports whether the change is a flagged orphaned RAW file
+func (op *Operation) isOrphan(ch Change) bool {
+	for _, v := range op.orphans {
+		if v.BaseDir == ch.BaseDir && v.Source == ch.Source {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package f2
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRawPairs(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_0001.JPG",
+		"IMG_0001.CR2",
+		"IMG_0002.jpg",
+		"IMG_0003.CR2",
+		"IMG_0004.jpg",
+		"IMG_0004.dng",
+	})
+
+	cases := []testCase{
+		{
+			name: "Rename pairs with identical serial numbers and skip orphans",
+			want: []Change{
+				{Source: "IMG_0001.JPG", BaseDir: testDir, Target: "photo-01.JPG"},
+				{Source: "IMG_0001.CR2", BaseDir: testDir, Target: "photo-01.CR2"},
+				{Source: "IMG_0002.jpg", BaseDir: testDir, Target: "photo-02.jpg"},
+				{Source: "IMG_0004.jpg", BaseDir: testDir, Target: "photo-03.jpg"},
+				{Source: "IMG_0004.dng", BaseDir: testDir, Target: "photo-03.dng"},
+			},
+			args: []string{
+				"-f",
+				"IMG_\\d+",
+				"-r",
+				"photo-%02d",
+				"--raw-pairs",
+				testDir,
+			},
+		},
+		{
+			name: "Flag orphaned RAW files",
+			want: []Change{
+				{Source: "IMG_0001.JPG", BaseDir: testDir, Target: "photo-01.JPG"},
+				{Source: "IMG_0001.CR2", BaseDir: testDir, Target: "photo-01.CR2"},
+				{Source: "IMG_0002.jpg", BaseDir: testDir, Target: "photo-02.jpg"},
+				{Source: "IMG_0003.CR2", BaseDir: testDir, Target: "IMG_0003.CR2"},
+				{Source: "IMG_0004.jpg", BaseDir: testDir, Target: "photo-03.jpg"},
+				{Source: "IMG_0004.dng", BaseDir: testDir, Target: "photo-03.dng"},
+			},
+			args: []string{
+				"-f",
+				"IMG_\\d+",
+				"-r",
+				"photo-%02d",
+				"--raw-pairs",
+				"--orphan-raw",
+				"flag",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestInvalidOrphanRaw(t *testing.T) {
+	dir := setupFiles(t, nil)
+
+	args := os.Args[0:1]
+	args = append(args, "-f", "a", "--raw-pairs", "--orphan-raw", "x", dir)
+	_, err := action(args)
+	if err == nil {
+		t.Fatal("Expected an error for an invalid --orphan-raw value")
+	}
+}
@@ -0,0 +1,254 @@
+package f2
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// referenceChange represents a text file whose references to renamed paths
+// were rewritten. Both versions of the content are recorded so that the
+// change can be reverted and reapplied
+type referenceChange struct {
+	Path     string `json:"path"`
+	Original string `json:"original"`
+	Updated  string `json:"updated"`
+}
+
+// refBoundaryChars are the characters that may precede or follow a path
+// reference in a text file such as a Markdown document or playlist
+const refBoundaryChars = " \t\r\n\"'()[]<>=/\\#?,;|&"
+
+// isRefBoundary reports whether the byte at index i in the string can
+// terminate a path reference
+func isRefBoundary(s string, i int) bool {
+	return i >= len(s) || strings.IndexByte(refBoundaryChars, s[i]) != -1
+}
+
+// referenceMap returns the references to renamed paths relative to the
+// specified directories mapped to their new values. Absolute references and
+// percent-encoded spaces (as used in Markdown and HTML links) are included
+func (op *Operation) referenceMap(oldDir, newDir string) map[string]string {
+	refs := make(map[string]string)
+
+	for _, ch := range op.matches {
+		if op.failed(ch) {
+			continue
+		}
+
+		source, err := filepath.Abs(filepath.Join(ch.BaseDir, ch.Source))
+		if err != nil {
+			continue
+		}
+
+		target := op.renamedPath(source)
+		if target == source {
+			continue
+		}
+
+		refs[source] = target
+
+		oldRel, err := filepath.Rel(oldDir, source)
+		if err != nil {
+			continue
+		}
+
+		newRel, err := filepath.Rel(newDir, target)
+		if err != nil {
+			continue
+		}
+
+		oldRel, newRel = filepath.ToSlash(oldRel), filepath.ToSlash(newRel)
+		if oldRel == newRel {
+			continue
+		}
+
+		refs[oldRel] = newRel
+
+		if strings.Contains(oldRel, " ") {
+			refs[strings.ReplaceAll(oldRel, " ", "%20")] = strings.ReplaceAll(
+				newRel,
+				" ",
+				"%20",
+			)
+		}
+	}
+
+	return refs
+}
+
+// rewriteReferences replaces the references in the content with their new
+// values. References must be delimited by boundary characters so that
+// `a.jpg` is not replaced in `data.jpg`
+func rewriteReferences(content string, refs map[string]string) string {
+	if len(refs) == 0 {
+		return content
+	}
+
+	keys := make([]string, 0, len(refs))
+	for k := range refs {
+		keys = append(keys, k)
+	}
+
+	// prefer the longest reference
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j])
+	})
+
+	for i := range keys {
+		keys[i] = regexp.QuoteMeta(keys[i])
+	}
+
+	re := regexp.MustCompile(
+		`(?:^|[\s"'()\[\]<>=/\\])(` + strings.Join(keys, "|") + `)`,
+	)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(content, -1) {
+		start, end := loc[2], loc[3]
+		if !isRefBoundary(content, end) {
+			continue
+		}
+
+		b.WriteString(content[last:start])
+		b.WriteString(refs[content[start:end]])
+		last = end
+	}
+
+	b.WriteString(content[last:])
+
+	return b.String()
+}
+
+// updateReferences rewrites references to renamed paths in the text files
+// within the scanned paths that match one of the --update-refs patterns
+func (op *Operation) updateReferences() error {
+	for _, v := range op.paths {
+		if v.IsDir {
+			continue
+		}
+
+		var matched bool
+		for _, pattern := range op.refPatterns {
+			ok, err := filepath.Match(pattern, v.Source)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		oldPath, err := filepath.Abs(filepath.Join(v.BaseDir, v.Source))
+		if err != nil {
+			return err
+		}
+
+		newPath := op.renamedPath(oldPath)
+
+		b, err := os.ReadFile(newPath)
+		if err != nil {
+			return err
+		}
+
+		refs := op.referenceMap(filepath.Dir(oldPath), filepath.Dir(newPath))
+		content := rewriteReferences(string(b), refs)
+		if content == string(b) {
+			continue
+		}
+
+		info, err := os.Stat(newPath)
+		if err != nil {
+			return err
+		}
+
+		err = writeFileAtomic(newPath, []byte(content), info.Mode())
+		if err != nil {
+			return err
+		}
+
+		op.references = append(op.references, referenceChange{
+			Path:     newPath,
+			Original: string(b),
+			Updated:  content,
+		})
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes the data to a temporary file in the same directory
+// which then replaces the file at path so that the file is never left
+// partially written
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".f2tmp-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// replaceReferences replaces the content of each recorded file with its
+// original content when the operation is reverted or with its updated
+// content when it is reapplied. Files whose content has changed since then
+// are left untouched and reported
+func replaceReferences(refs []referenceChange, revert bool) error {
+	for _, v := range refs {
+		from, to := v.Original, v.Updated
+		if revert {
+			from, to = v.Updated, v.Original
+		}
+
+		info, err := os.Stat(v.Path)
+		if err != nil {
+			return err
+		}
+
+		b, err := os.ReadFile(v.Path)
+		if err != nil {
+			return err
+		}
+
+		if string(b) != from {
+			return fmt.Errorf(
+				"Unable to restore the references in '%s' since it was modified after the renaming operation",
+				v.Path,
+			)
+		}
+
+		err = writeFileAtomic(v.Path, []byte(to), info.Mode())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
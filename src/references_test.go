@@ -0,0 +1,136 @@
+package f2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteReferences(t *testing.T) {
+	refs := map[string]string{
+		"a.jpg":          "b.jpg",
+		"images":         "pics",
+		"my%20song.mp3":  "song.mp3",
+		"../notes/x.txt": "../notes/y.txt",
+	}
+
+	cases := map[string]string{
+		"![img](a.jpg)":                "![img](b.jpg)",
+		"<img src=\"images/c.png\">":   "<img src=\"pics/c.png\">",
+		"data.jpg a.jpg\na.jpg":        "data.jpg b.jpg\nb.jpg",
+		"[song](my%20song.mp3#t=10)":   "[song](song.mp3#t=10)",
+		"see ../notes/x.txt, a.jpgs":   "see ../notes/y.txt, a.jpgs",
+		"imagesets/a.png and (images)": "imagesets/a.png and (pics)",
+	}
+
+	for input, want := range cases {
+		got := rewriteReferences(input, refs)
+		if got != want {
+			t.Fatalf("Test (%s) — Expected: %s, but got: %s", input, want, got)
+		}
+	}
+}
+
+func TestUpdateReferences(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	readme := filepath.Join(testDir, "README.md")
+	err := ioutil.WriteFile(
+		readme,
+		[]byte("![a](images/a.jpg)\n[script](scripts/index.js)\n"),
+		0600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	playlist := filepath.Join(testDir, "morepics", "list.m3u")
+	err = ioutil.WriteFile(playlist, []byte("nested/linux.mp4\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args[0:1]
+	args = append(
+		args,
+		"-f",
+		"^(images|index\\.js|linux\\.mp4)$",
+		"-r",
+		"new-$1",
+		"-d",
+		"-R",
+		"--update-refs",
+		"*.md",
+		"--update-refs",
+		"*.m3u",
+		"-x",
+		testDir,
+	)
+
+	result, err := action(args)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defer os.Remove(result.backupFile)
+
+	if result.applyError != nil {
+		t.Fatalf("Unexpected apply error: %v", result.applyError)
+	}
+
+	want := map[string]string{
+		readme:   "![a](new-images/a.jpg)\n[script](scripts/new-index.js)\n",
+		playlist: "nested/new-linux.mp4\n",
+	}
+
+	for path, content := range want {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if string(b) != content {
+			t.Fatalf("Expected: %q, but got: %q", content, b)
+		}
+	}
+	args = os.Args[0:1]
+	args = append(args, "-u", "-x")
+
+	result, err = action(args)
+	if err != nil {
+		t.Fatalf("Unexpected error in undo mode: %v", err)
+	}
+
+	if result.applyError != nil {
+		t.Fatalf("Unexpected undo error: %v", result.applyError)
+	}
+
+	want = map[string]string{
+		readme:   "![a](images/a.jpg)\n[script](scripts/index.js)\n",
+		playlist: "nested/linux.mp4\n",
+	}
+
+	for path, content := range want {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if string(b) != content {
+			t.Fatalf("Expected %q after undo, but got: %q", content, b)
+		}
+	}
+
+	entries, err := os.ReadDir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range entries {
+		if strings.Contains(v.Name(), ".f2tmp-") {
+			t.Fatalf("Expected no temporary files to be left: %s", v.Name())
+		}
+	}
+}
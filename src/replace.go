@@ -1,10 +1,12 @@
 package f2
 
 import (
+	"context"
 	"errors"
 	"math"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 )
@@ -73,6 +75,7 @@ type hashVar struct {
 	values     []struct {
 		regex  *regexp.Regexp
 		hashFn string
+		length int
 	}
 }
 
@@ -85,6 +88,14 @@ type randomVar struct {
 	}
 }
 
+type ocrVar struct {
+	submatches [][]string
+	values     []struct {
+		regex *regexp.Regexp
+		words int
+	}
+}
+
 type replaceVars struct {
 	exif      exifVar
 	exiftool  exiftoolVar
@@ -94,6 +105,7 @@ type replaceVars struct {
 	date      dateVar
 	random    randomVar
 	transform transformVar
+	ocr       ocrVar
 }
 
 var (
@@ -136,7 +148,7 @@ func getHashVar(str string) (hashVar, error) {
 	var h hashVar
 	if hashRegex.MatchString(str) {
 		h.submatches = hashRegex.FindAllStringSubmatch(str, -1)
-		expectedLength := 2
+		expectedLength := 3
 
 		for _, submatch := range h.submatches {
 			if len(submatch) < expectedLength {
@@ -146,6 +158,7 @@ func getHashVar(str string) (hashVar, error) {
 			var x struct {
 				regex  *regexp.Regexp
 				hashFn string
+				length int
 			}
 			regex, err := regexp.Compile(submatch[0])
 			if err != nil {
@@ -154,6 +167,12 @@ func getHashVar(str string) (hashVar, error) {
 
 			x.regex = regex
 			x.hashFn = submatch[1]
+			if submatch[2] != "" {
+				x.length, err = strconv.Atoi(submatch[2])
+				if err != nil {
+					return h, err
+				}
+			}
 			h.values = append(h.values, x)
 		}
 	}
@@ -161,6 +180,42 @@ func getHashVar(str string) (hashVar, error) {
 	return h, nil
 }
 
+func getOcrVar(str string) (ocrVar, error) {
+	var ov ocrVar
+	if ocrRegex.MatchString(str) {
+		ov.submatches = ocrRegex.FindAllStringSubmatch(str, -1)
+		expectedLength := 2
+
+		for _, submatch := range ov.submatches {
+			if len(submatch) < expectedLength {
+				return ov, errInvalidSubmatches
+			}
+
+			var x struct {
+				regex *regexp.Regexp
+				words int
+			}
+			regex, err := regexp.Compile(submatch[0])
+			if err != nil {
+				return ov, err
+			}
+
+			x.regex = regex
+			x.words = defaultOcrWords
+			if submatch[1] != "" {
+				x.words, err = strconv.Atoi(submatch[1])
+				if err != nil {
+					return ov, err
+				}
+			}
+
+			ov.values = append(ov.values, x)
+		}
+	}
+
+	return ov, nil
+}
+
 func getTransformVar(str string) (transformVar, error) {
 	var t transformVar
 	if transformRegex.MatchString(str) {
@@ -468,6 +523,11 @@ func getAllVariables(str string) (replaceVars, error) {
 		return v, err
 	}
 
+	v.ocr, err = getOcrVar(str)
+	if err != nil {
+		return v, err
+	}
+
 	return v, nil
 }
 
@@ -477,63 +537,95 @@ func regexReplace(
 	fileName, replacement string,
 	replaceLimit int,
 ) string {
-	var output string
+	if replaceLimit == 0 {
+		return r.ReplaceAllString(fileName, replacement)
+	}
 
-	switch limit := replaceLimit; {
-	case limit > 0:
-		counter := 0
-		output = r.ReplaceAllStringFunc(
-			fileName,
-			func(val string) string {
-				if counter == replaceLimit {
-					return val
-				}
+	matches := r.FindAllStringSubmatchIndex(fileName, -1)
 
-				counter++
-				return r.ReplaceAllString(val, replacement)
-			},
-		)
-	case limit < 0:
-		matches := r.FindAllString(fileName, -1)
+	// replace the first N matches or the last N matches
+	// if the limit is negative
+	start, end := 0, len(matches)
+	if replaceLimit > 0 && replaceLimit < end {
+		end = replaceLimit
+	}
 
-		l := len(matches) + limit
-		counter := 0
-		output = r.ReplaceAllStringFunc(
-			fileName,
-			func(val string) string {
-				if counter >= l {
-					return r.ReplaceAllString(val, replacement)
-				}
+	if replaceLimit < 0 && -replaceLimit < end {
+		start = end + replaceLimit
+	}
 
-				counter++
-				return val
-			},
-		)
-	default:
-		output = r.ReplaceAllString(fileName, replacement)
+	// matches are expanded in the context of the entire file name
+	// so that anchors and word boundaries are respected
+	var output []byte
+	last := 0
+	for _, m := range matches[start:end] {
+		output = append(output, fileName[last:m[0]]...)
+		output = r.ExpandString(output, replacement, fileName, m)
+		last = m[1]
 	}
 
-	return output
+	output = append(output, fileName[last:]...)
+
+	return string(output)
 }
 
 func (op *Operation) replaceString(fileName string) (str string) {
+	// the entire file name is replaced by the segments
+	if op.segmentMode {
+		return escapeLiterals(op.replacement)
+	}
+
+	if op.fuzzy > 0 && op.fuzzyFind != "" {
+		return op.fuzzyReplace(fileName)
+	}
+
+	if op.substitution != nil {
+		return op.substitution.substitute(
+			op.searchRegex,
+			fileName,
+			escapeLiterals(op.replacement),
+		)
+	}
+
 	return regexReplace(
 		op.searchRegex,
 		fileName,
-		op.replacement,
+		escapeLiterals(op.replacement),
 		op.replaceLimit,
 	)
 }
 
 // replace replaces the matched text in each path with the
 // replacement string
-func (op *Operation) replace() (err error) {
+func (op *Operation) replace(ctx context.Context) (err error) {
 	vars, err := getAllVariables(op.replacement)
 	if err != nil {
 		return err
 	}
 
+	if groupRegex.MatchString(op.replacement) {
+		op.groupMatches()
+	}
+
+	if hashRegex.MatchString(op.replacement) ||
+		exifRegex.MatchString(op.replacement) ||
+		id3Regex.MatchString(op.replacement) {
+		op.openMetadataCache()
+
+		defer func() {
+			if op.metaCache != nil {
+				_ = op.metaCache.prune()
+			}
+		}()
+	}
+
+	op.prefetchMetadata(ctx)
+
 	for i, v := range op.matches {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
 		fileName := v.Source
 		fileExt := filepath.Ext(fileName)
 		if op.ignoreExt {
@@ -541,6 +633,42 @@ func (op *Operation) replace() (err error) {
 		}
 
 		str := op.replaceString(fileName)
+		replaced := str
+
+		// handle variables that are passed through filters
+		str, err = op.replaceFilters(str, v)
+		if err != nil {
+			return err
+		}
+
+		// handle variables that are sliced by position
+		str, err = op.replaceSlices(str, v)
+		if err != nil {
+			return err
+		}
+
+		// handle conditional blocks
+		str, err = op.replaceConditionals(str, v)
+		if err != nil {
+			return err
+		}
+
+		// handle default values and unresolved variables
+		var skip bool
+		str, skip, err = op.resolveVariables(str, v)
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			v.Target = v.Source
+			op.matches[i] = v
+			continue
+		}
+
+		if op.sanitize {
+			str = protectSeparators(str, runtime.GOOS)
+		}
 
 		// handle variables
 		str, err = op.handleVariables(str, v, &vars)
@@ -548,16 +676,39 @@ func (op *Operation) replace() (err error) {
 			return err
 		}
 
+		if op.sanitize {
+			str = restoreSeparators(str, runtime.GOOS)
+		}
+
+		// handle date and letter sequences
+		if seqRegex.MatchString(str) {
+			str, err = replaceSeqVariables(str, i)
+			if err != nil {
+				return err
+			}
+		}
+
 		// If numbering scheme is present
 		if indexRegex.MatchString(str) {
 			str = op.replaceIndex(str, i, vars.number)
 		}
 
+		// handle arithmetic on captures and numbers (e.g. `{{$1+100}}`)
+		if arithRegex.MatchString(str) {
+			str, err = replaceArithmetic(str)
+			if err != nil {
+				return err
+			}
+		}
+
+		str = unescapeLiterals(str)
+
 		if op.ignoreExt {
 			str += fileExt
 		}
 
 		v.Target = strings.TrimSpace(filepath.Join(str))
+		op.recordStep(&v, fileName, replaced)
 		op.matches[i] = v
 	}
 
@@ -3,6 +3,7 @@ package f2
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"testing"
 )
@@ -367,3 +368,130 @@ func TestReplacementChaining(t *testing.T) {
 	}
 	runFindReplace(t, cases)
 }
+
+func TestEscapeLiterals(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	cases := []testCase{
+		{
+			name: "Escaped braces and dollars are included literally",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "{{f}}-$1-abc-{x}.pdf",
+				},
+				{
+					Source:  "abc.epub",
+					BaseDir: testDir,
+					Target:  "{{f}}-$1-abc-{x}.epub",
+				},
+			},
+			args: []string{
+				"-f",
+				"(abc)",
+				"-r",
+				`\{\{f\}\}-\$1-$1-\{x\}`,
+				testDir,
+			},
+		},
+		{
+			name: "Escaped variables are not expanded",
+			want: []Change{
+				{
+					Source:  "abc.pdf",
+					BaseDir: testDir,
+					Target:  "{{f}}_abc.pdf",
+				},
+			},
+			args: []string{
+				"-f",
+				"abc.pdf",
+				"-r",
+				`\{{f\}}_{{f}}{{ext}}`,
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestMultipleFindReplacePairs(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"a b.txt",
+		"c&d.txt",
+		"e f&g.txt",
+		"x(1).txt",
+		"plain.txt",
+	})
+
+	cases := []testCase{
+		{
+			name: "Files matching any of the find patterns are renamed",
+			want: []Change{
+				{
+					Source:  "a b.txt",
+					BaseDir: testDir,
+					Target:  "a_b.txt",
+				},
+				{
+					Source:  "c&d.txt",
+					BaseDir: testDir,
+					Target:  "candd.txt",
+				},
+				{
+					Source:  "e f&g.txt",
+					BaseDir: testDir,
+					Target:  "e_fandg.txt",
+				},
+			},
+			args: []string{"-f", " ", "-r", "_", "-f", "&", "-r", "and", testDir},
+		},
+		{
+			name: "String mode applies to every find pattern",
+			want: []Change{
+				{
+					Source:  "x(1).txt",
+					BaseDir: testDir,
+					Target:  "x[1].txt",
+				},
+			},
+			args: []string{"-f", "(", "-r", "[", "-f", ")", "-r", "]", "-s", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestRegexReplace(t *testing.T) {
+	cases := []struct {
+		pattern, input, replacement, want string
+		limit                             int
+	}{
+		{`\Bo`, "foo boo", "0", "f0o boo", 1},
+		{`\Bo`, "foo boo", "0", "foo bo0", -1},
+		{`(\d)`, "a1b2c3", "$1$1", "a11b22c3", 2},
+		{`b(\d)`, "a1b2c3", "<b$1>", "a1<b2>c3", 0},
+		{`^a`, "aaa", "b", "baa", 5},
+		{`a`, "aaa", "b", "bbb", -5},
+	}
+
+	for _, v := range cases {
+		got := regexReplace(
+			regexp.MustCompile(v.pattern),
+			v.input,
+			v.replacement,
+			v.limit,
+		)
+		if got != v.want {
+			t.Fatalf(
+				"Pattern %s with limit %d — Expected: %s, but got: %s",
+				v.pattern,
+				v.limit,
+				v.want,
+				got,
+			)
+		}
+	}
+}
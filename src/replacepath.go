@@ -0,0 +1,47 @@
+package f2
+
+import (
+	"path/filepath"
+)
+
+// usePathSources makes the source of each path relative to the searched
+// directory that contains it so that find and replace operate on the
+// whole relative path including the names of the parent directories
+func (op *Operation) usePathSources() error {
+	for i, v := range op.paths {
+		root := op.searchRoot(v.BaseDir)
+
+		rel, err := filepath.Rel(root, v.BaseDir)
+		if err != nil {
+			return err
+		}
+
+		source := filepath.ToSlash(filepath.Join(rel, v.Source))
+
+		op.paths[i].BaseDir = root
+		op.paths[i].Source = source
+		op.paths[i].originalSource = source
+	}
+
+	return nil
+}
+
+// removeEmptyDirs removes the parent directories of the renamed paths that
+// were left empty after their contents were moved to a new path
+func (op *Operation) removeEmptyDirs() {
+	for _, ch := range op.matches {
+		if op.failed(ch) {
+			continue
+		}
+
+		dir := filepath.Dir(filepath.Join(ch.BaseDir, ch.Source))
+		for dir != filepath.Clean(ch.BaseDir) {
+			// only empty directories can be removed
+			if op.filesystem().Remove(dir) != nil {
+				break
+			}
+
+			dir = filepath.Dir(dir)
+		}
+	}
+}
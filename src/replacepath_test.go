@@ -0,0 +1,96 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplacePath(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"Trip.txt",
+		"2020-Trip/day1/a.jpg",
+		"2020-Trip/b.jpg",
+		"other/c.jpg",
+	})
+
+	cases := []testCase{
+		{
+			name: "Replace in parent directory names",
+			want: []Change{
+				{
+					Source:  "2020-Trip/b.jpg",
+					BaseDir: testDir,
+					Target:  filepath.Join("2020-Vacation", "b.jpg"),
+				},
+				{
+					Source:  "2020-Trip/day1/a.jpg",
+					BaseDir: testDir,
+					Target:  filepath.Join("2020-Vacation", "day1", "a.jpg"),
+				},
+				{
+					Source:  "Trip.txt",
+					BaseDir: testDir,
+					Target:  "Vacation.txt",
+				},
+			},
+			args: []string{
+				"-f",
+				"Trip",
+				"-r",
+				"Vacation",
+				"-R",
+				"--replace-path",
+				testDir,
+			},
+		},
+		{
+			name: "Move files between directories",
+			want: []Change{
+				{
+					Source:  "other/c.jpg",
+					BaseDir: testDir,
+					Target:  filepath.Join("images", "c.jpg"),
+				},
+			},
+			args: []string{
+				"-f",
+				"^other/(.*)\\.jpg$",
+				"-r",
+				"images/$1.jpg",
+				"-R",
+				"--replace-path",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestRemoveEmptyDirs(t *testing.T) {
+	testDir := setupFiles(t, []string{"d/f.txt"})
+
+	// the sources of the matches have been moved out of a/b and d
+	err := os.MkdirAll(filepath.Join(testDir, "a", "b"), 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := &Operation{
+		matches: []Change{
+			{Source: "a/b/c.txt", BaseDir: testDir, Target: "c.txt"},
+			{Source: "d/e.txt", BaseDir: testDir, Target: "e.txt"},
+		},
+	}
+
+	op.removeEmptyDirs()
+
+	if _, err := os.Stat(filepath.Join(testDir, "a")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the empty directories to be removed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "d")); err != nil {
+		t.Fatalf("Expected the non-empty directory to be kept: %v", err)
+	}
+}
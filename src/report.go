@@ -0,0 +1,178 @@
+package f2
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// reportTemplate is a standalone HTML page that presents the outcome of an
+// operation in a table that can be sorted and filtered without any
+// external resources
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>F2 report — {{.Date}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+h1 { font-size: 1.5rem; }
+.summary { display: flex; gap: 1rem; margin: 1rem 0; }
+.summary div { padding: .75rem 1rem; border: 1px solid #d0d7de; border-radius: 6px; }
+.summary strong { display: block; font-size: 1.5rem; }
+.controls { display: flex; gap: .5rem; margin: 1rem 0; }
+input, select { padding: .4rem; font-size: 1rem; }
+input { flex: 1; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: .4rem .6rem; text-align: left; word-break: break-all; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; }
+th[data-order="asc"]::after { content: " ▲"; }
+th[data-order="desc"]::after { content: " ▼"; }
+.failed { color: #cf222e; }
+.renamed { color: #1a7f37; }
+</style>
+</head>
+<body>
+<h1>F2 renaming report</h1>
+<p>{{.WorkingDir}} — {{.Date}}</p>
+<div class="summary">
+<div><strong>{{.Total}}</strong>changes</div>
+<div><strong>{{.Renamed}}</strong>renamed</div>
+<div><strong>{{.Unchanged}}</strong>unchanged</div>
+<div><strong>{{.Failed}}</strong>failed</div>
+</div>
+{{if .Errors}}
+<h2>Errors</h2>
+<ul>
+{{range .Errors}}<li class="failed">{{.Source}} → {{.Target}}: {{.Error}}</li>
+{{end}}</ul>
+{{end}}
+<h2>Changes</h2>
+<div class="controls">
+<input id="filter" type="search" placeholder="Filter by path">
+<select id="status">
+<option value="">All</option>
+<option value="renamed">Renamed</option>
+<option value="unchanged">Unchanged</option>
+<option value="failed">Failed</option>
+</select>
+</div>
+<table id="changes">
+<thead>
+<tr><th>Directory</th><th>Source</th><th>Target</th><th>Status</th></tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr data-status="{{.Status}}"><td>{{.BaseDir}}</td><td>{{.Source}}</td><td>{{.Target}}</td><td class="{{.Status}}">{{.Status}}</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+(function () {
+  var table = document.getElementById("changes");
+  var body = table.tBodies[0];
+  var filter = document.getElementById("filter");
+  var status = document.getElementById("status");
+
+  function update() {
+    var text = filter.value.toLowerCase();
+    Array.prototype.forEach.call(body.rows, function (row) {
+      var visible = row.textContent.toLowerCase().indexOf(text) !== -1 &&
+        (status.value === "" || row.dataset.status === status.value);
+      row.style.display = visible ? "" : "none";
+    });
+  }
+
+  filter.addEventListener("input", update);
+  status.addEventListener("change", update);
+
+  Array.prototype.forEach.call(table.tHead.rows[0].cells, function (th, i) {
+    th.addEventListener("click", function () {
+      var order = th.dataset.order === "asc" ? "desc" : "asc";
+      Array.prototype.forEach.call(table.tHead.rows[0].cells, function (c) {
+        delete c.dataset.order;
+      });
+      th.dataset.order = order;
+
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[i].textContent, y = b.cells[i].textContent;
+        var n = x.localeCompare(y, undefined, { numeric: true });
+        return order === "asc" ? n : -n;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
+
+// reportRow represents a single change in the report
+type reportRow struct {
+	BaseDir string
+	Source  string
+	Target  string
+	Status  string
+}
+
+// report holds the data presented in the report
+type report struct {
+	WorkingDir string
+	Date       string
+	Total      int
+	Renamed    int
+	Unchanged  int
+	Failed     int
+	Rows       []reportRow
+	Errors     []summaryError
+}
+
+// writeReport writes a standalone HTML report of the
+// executed operation to the specified file
+func (op *Operation) writeReport() (err error) {
+	r := report{
+		WorkingDir: op.workingDir,
+		Date:       time.Now().Format(time.RFC1123),
+		Total:      len(op.matches),
+		Errors:     op.summary(nil).Errors,
+	}
+
+	for _, ch := range op.matches {
+		var status string
+
+		switch {
+		case op.failed(ch):
+			status = "failed"
+			r.Failed++
+		case ch.Source == ch.Target:
+			status = "unchanged"
+			r.Unchanged++
+		default:
+			status = "renamed"
+			r.Renamed++
+		}
+
+		r.Rows = append(r.Rows, reportRow{
+			BaseDir: filepath.Clean(ch.BaseDir),
+			Source:  ch.Source,
+			Target:  ch.Target,
+			Status:  status,
+		})
+	}
+
+	f, err := os.Create(op.reportFile)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		ferr := f.Close()
+		if ferr != nil {
+			err = ferr
+		}
+	}()
+
+	return reportTemplate.Execute(f, r)
+}
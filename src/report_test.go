@@ -0,0 +1,61 @@
+package f2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "report.html")
+
+	op := &Operation{
+		workingDir: "/home/user",
+		reportFile: reportFile,
+		matches: []Change{
+			{Source: "a.txt", Target: "b.txt", BaseDir: "docs"},
+			{Source: "<c>.txt", Target: "d.txt", BaseDir: "docs"},
+			{Source: "e.txt", Target: "e.txt", BaseDir: "docs"},
+		},
+		errors: []renameError{
+			{
+				entry: Change{Source: "<c>.txt", Target: "d.txt", BaseDir: "docs"},
+				err:   errors.New("permission denied"),
+			},
+		},
+	}
+
+	err := op.writeReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(b)
+
+	want := []string{
+		"<strong>3</strong>changes",
+		"<strong>1</strong>renamed",
+		"<strong>1</strong>unchanged",
+		"<strong>1</strong>failed",
+		`<tr data-status="renamed"><td>docs</td><td>a.txt</td><td>b.txt</td>`,
+		`<tr data-status="unchanged"><td>docs</td><td>e.txt</td><td>e.txt</td>`,
+		"&lt;c&gt;.txt → d.txt: permission denied",
+	}
+
+	for _, v := range want {
+		if !strings.Contains(content, v) {
+			t.Fatalf("Expected the report to contain: %s", v)
+		}
+	}
+
+	if strings.Contains(content, "<c>.txt") {
+		t.Fatal("Expected file names to be escaped")
+	}
+}
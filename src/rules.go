@@ -0,0 +1,162 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const rulesTable = "rule"
+
+var (
+	errRulesWithFind = errors.New(
+		"Invalid argument: --rules cannot be combined with -f, -r or --expr",
+	)
+
+	errNoRules = errors.New(
+		"The rules file does not contain any [[rule]] tables",
+	)
+)
+
+// rule is a find and replace step in a rules file. Each step has its own
+// search options and is applied to the result of the previous one
+type rule struct {
+	find         string
+	replace      string
+	stringMode   bool
+	ignoreCase   bool
+	ignoreExt    bool
+	replaceLimit int
+}
+
+// regex compiles the find pattern of the rule in accordance with its options
+func (r rule) regex() (*regexp.Regexp, error) {
+	op := &Operation{
+		stringLiteralMode: r.stringMode,
+		ignoreCase:        r.ignoreCase,
+	}
+
+	return op.compileFind(r.find)
+}
+
+// parseRule sets the option of the rule identified by the key
+func parseRule(r *rule, key, value string) error {
+	var err error
+
+	switch key {
+	case "find":
+		r.find = value
+	case "replace":
+		r.replace = value
+	case "string-mode":
+		r.stringMode, err = strconv.ParseBool(value)
+	case "ignore-case":
+		r.ignoreCase, err = strconv.ParseBool(value)
+	case "ignore-ext":
+		r.ignoreExt, err = strconv.ParseBool(value)
+	case "replace-limit":
+		r.replaceLimit, err = strconv.Atoi(value)
+	default:
+		return fmt.Errorf("unknown option '%s'", key)
+	}
+
+	return err
+}
+
+// readRules reads the ordered find and replace steps from a rules file.
+// Each step is a `[[rule]]` table such as:
+//
+//	[[rule]]
+//	find = '\s*\[.*?\]'
+//	replace = ''
+//
+//	[[rule]]
+//	find = '  '
+//	replace = ' '
+//	string-mode = true
+func readRules(path string) ([]rule, error) {
+	tables, err := readTables(path, rulesTable)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]rule, 0, len(tables))
+	for i, t := range tables {
+		var r rule
+
+		for k, v := range t {
+			err = parseRule(&r, k, v)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Invalid rule %d in '%s': %w",
+					i+1,
+					path,
+					err,
+				)
+			}
+		}
+
+		rules = append(rules, r)
+	}
+
+	if len(rules) == 0 {
+		return nil, errNoRules
+	}
+
+	return rules, nil
+}
+
+// readTables reads the options in each of the `[[<table>]]` tables
+// of a rules file in the order in which they appear
+func readTables(path, table string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read rules file '%s': %w", path, err)
+	}
+
+	var tables []map[string]string
+	for n := 1; ; n++ {
+		prefix := table + "." + strconv.Itoa(n) + "."
+
+		t := make(map[string]string)
+		for k, v := range config {
+			if !strings.HasPrefix(k, prefix) {
+				continue
+			}
+
+			if len(v) != 1 {
+				return nil, fmt.Errorf(
+					"Invalid value for '%s' in rule %d of '%s'",
+					k[len(prefix):],
+					n,
+					path,
+				)
+			}
+
+			t[k[len(prefix):]] = v[0]
+
+			delete(config, k)
+		}
+
+		if len(t) == 0 {
+			break
+		}
+
+		tables = append(tables, t)
+	}
+
+	for k := range config {
+		return nil, fmt.Errorf("Unknown option '%s' in rules file '%s'", k, path)
+	}
+
+	return tables, nil
+}
@@ -0,0 +1,114 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "rules.toml")
+
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReadRules(t *testing.T) {
+	path := writeRules(t, `
+# strip bracketed tags
+[[rule]]
+find = '\s*\[.*?\]'
+replace = ''
+
+[[rule]]
+find = "."
+replace = " "
+string-mode = true
+ignore-ext = true
+replace-limit = -1
+`)
+
+	rules, err := readRules(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []rule{
+		{find: `\s*\[.*?\]`},
+		{
+			find:         ".",
+			replace:      " ",
+			stringMode:   true,
+			ignoreExt:    true,
+			replaceLimit: -1,
+		},
+	}
+
+	if len(rules) != len(want) {
+		t.Fatalf("Expected %d rules, but got: %+v", len(want), rules)
+	}
+
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Fatalf("Expected rule %d to be %+v, but got: %+v", i+1, want[i], rules[i])
+		}
+	}
+
+	for _, v := range []string{
+		"",
+		"find = 'a'",
+		"[[rule]]\nfind = 'a'\nscope = 'name'",
+		"[[rule]]\nfind = 'a'\nignore-case = 'yes'",
+		"[[rule]]\nfind = ['a', 'b']",
+	} {
+		_, err = readRules(writeRules(t, v))
+		if err == nil {
+			t.Fatalf("Expected an error for the rules file:\n%s", v)
+		}
+	}
+}
+
+func TestRulesMode(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"Show.Name.S01E02  [1080p] [WEB].MKV",
+	})
+
+	rules := writeRules(t, `
+[[rule]]
+find = '\s*\[.*?\]'
+replace = ''
+
+[[rule]]
+find = "."
+replace = " "
+string-mode = true
+ignore-ext = true
+
+[[rule]]
+find = 'mkv$'
+replace = 'mkv'
+ignore-case = true
+`)
+
+	cases := []testCase{
+		{
+			name: "Apply the rules in sequence",
+			want: []Change{
+				{
+					Source:  "Show.Name.S01E02  [1080p] [WEB].MKV",
+					BaseDir: testDir,
+					Target:  "Show Name S01E02.mkv",
+				},
+			},
+			args: []string{"--rules", rules, testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,120 @@
+package f2
+
+import (
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// separatorPlaceholder stands in for the path separators in the
+// replacement string while variables are replaced so that separators
+// introduced by variable values can be told apart
+const separatorPlaceholder = "\uE003"
+
+// windowsReservedRegex matches the device names that cannot be used as
+// file names on Windows (with or without an extension)
+var windowsReservedRegex = regexp.MustCompile(
+	`(?i)^(con|prn|aux|nul|com[1-9]|lpt[1-9])(\..*)?$`,
+)
+
+// protectSeparators replaces the path separators in the string with
+// a placeholder before variables are replaced
+func protectSeparators(str, goos string) string {
+	for _, sep := range separators(goos) {
+		str = strings.ReplaceAll(str, string(sep), separatorPlaceholder)
+	}
+
+	return str
+}
+
+// restoreSeparators replaces the path separators introduced by variable
+// values (such as `AC/DC` in an artist tag) with underscores so that they
+// don't create directories and restores the protected separators
+func restoreSeparators(str, goos string) string {
+	for _, sep := range separators(goos) {
+		str = strings.ReplaceAll(str, string(sep), "_")
+	}
+
+	return strings.ReplaceAll(str, separatorPlaceholder, "/")
+}
+
+// sanitizeName returns a version of the file name that is valid on the
+// specified operating system. Control characters and forbidden characters
+// are removed, trailing periods and spaces are trimmed on Windows and
+// reserved names are suffixed with an underscore
+func sanitizeName(name, goos string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if !unicode.IsControl(r) &&
+			!strings.ContainsRune(separators(goos), r) {
+			// invalid UTF-8 bytes are preserved
+			b.WriteString(name[i : i+size])
+		}
+
+		i += size
+	}
+
+	name = b.String()
+
+	switch goos {
+	case windows:
+		name = windowsForbiddenRegex.ReplaceAllString(name, "")
+		name = strings.TrimRight(name, ". ")
+
+		if m := windowsReservedRegex.FindStringSubmatch(name); m != nil {
+			name = m[1] + "_" + m[2]
+		}
+	case darwin:
+		name = macForbiddenRegex.ReplaceAllString(name, "")
+	}
+
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+
+	return name
+}
+
+// sanitizeTarget sanitizes each segment of the target path. Relative
+// segments (such as those produced by --target-dir) are preserved in
+// the directory portion of the path
+func sanitizeTarget(target, goos string) string {
+	segments := strings.FieldsFunc(target, func(r rune) bool {
+		return strings.ContainsRune(separators(goos), r)
+	})
+
+	for i, v := range segments {
+		if i < len(segments)-1 && (v == "." || v == "..") {
+			continue
+		}
+
+		segments[i] = sanitizeName(v, goos)
+	}
+
+	if len(segments) == 0 {
+		return "_"
+	}
+
+	path := strings.Join(segments, "/")
+	if strings.HasPrefix(target, "/") {
+		path = "/" + path
+	}
+
+	return filepath.FromSlash(path)
+}
+
+// sanitizeTargets ensures that the targets are valid file names on the
+// current operating system before they are validated
+func (op *Operation) sanitizeTargets() {
+	for i, ch := range op.matches {
+		if ch.Target == ch.Source {
+			continue
+		}
+
+		op.matches[i].Target = sanitizeTarget(ch.Target, runtime.GOOS)
+	}
+}
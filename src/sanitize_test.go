@@ -0,0 +1,179 @@
+package f2
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode"
+)
+
+func TestSanitizeName(t *testing.T) {
+	cases := []struct {
+		name string
+		goos string
+		want string
+	}{
+		{name: "report.txt", goos: "linux", want: "report.txt"},
+		{name: "a\x00b\tc.txt", goos: "linux", want: "abc.txt"},
+		{name: "..", goos: "linux", want: "_"},
+		{name: "", goos: "linux", want: "_"},
+		{name: "a:b.txt", goos: darwin, want: "ab.txt"},
+		{name: "what?<>.txt", goos: windows, want: "what.txt"},
+		{name: "notes. . ", goos: windows, want: "notes"},
+		{name: "CON", goos: windows, want: "CON_"},
+		{name: "nul.txt", goos: windows, want: "nul_.txt"},
+		{name: "lpt9.tar.gz", goos: windows, want: "lpt9_.tar.gz"},
+		{name: "console.txt", goos: windows, want: "console.txt"},
+		{name: "a\\b", goos: windows, want: "ab"},
+	}
+
+	for _, tc := range cases {
+		got := sanitizeName(tc.name, tc.goos)
+		if got != tc.want {
+			t.Fatalf(
+				"Expected sanitizeName(%q, %s) to be %q, but got %q",
+				tc.name,
+				tc.goos,
+				tc.want,
+				got,
+			)
+		}
+	}
+}
+
+func TestSanitizeNameProperties(t *testing.T) {
+	for _, goos := range []string{"linux", darwin, windows} {
+		goos := goos
+		valid := func(name string) bool {
+			got := sanitizeName(name, goos)
+			if got == "" || got == "." || got == ".." {
+				return false
+			}
+
+			if sanitizeName(got, goos) != got {
+				return false
+			}
+
+			for _, r := range got {
+				if unicode.IsControl(r) ||
+					strings.ContainsRune(separators(goos), r) {
+					return false
+				}
+			}
+
+			switch goos {
+			case windows:
+				return !fullWindowsForbiddenRegex.MatchString(got) &&
+					!windowsReservedRegex.MatchString(got)
+			case darwin:
+				return !macForbiddenRegex.MatchString(got)
+			}
+
+			return true
+		}
+
+		// names made up of the characters that need sanitizing
+		// are more likely to find problems than random strings
+		tricky := func(b []byte) bool {
+			const chars = "./\\:*?\"<>| \x00\x1fCONUL1"
+
+			name := make([]byte, len(b))
+			for i, v := range b {
+				name[i] = chars[int(v)%len(chars)]
+			}
+
+			return valid(string(name))
+		}
+
+		err := quick.Check(valid, &quick.Config{MaxCount: 2000})
+		if err != nil {
+			t.Fatalf("%s: %v", goos, err)
+		}
+
+		err = quick.Check(tricky, &quick.Config{MaxCount: 2000})
+		if err != nil {
+			t.Fatalf("%s: %v", goos, err)
+		}
+	}
+}
+
+func TestSanitizeTarget(t *testing.T) {
+	cases := []struct {
+		target string
+		want   string
+	}{
+		{target: "a/b.txt", want: filepath.Join("a", "b.txt")},
+		{target: "../out/b.txt", want: filepath.Join("..", "out", "b.txt")},
+		{target: "a//b\x01.txt", want: filepath.Join("a", "b.txt")},
+		{target: "a/..", want: filepath.Join("a", "_")},
+		{target: "/", want: "_"},
+	}
+
+	for _, tc := range cases {
+		got := sanitizeTarget(tc.target, "linux")
+		if got != tc.want {
+			t.Fatalf(
+				"Expected sanitizeTarget(%q) to be %q, but got %q",
+				tc.target,
+				tc.want,
+				got,
+			)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	testDir := setupFiles(t, []string{"track01.mp3"})
+
+	csvFile := filepath.Join(testDir, "tags.csv")
+	content := "filename,artist\ntrack01.mp3,AC/DC\n"
+
+	err := ioutil.WriteFile(csvFile, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []testCase{
+		{
+			name: "Separators in variable values are not treated as directories",
+			want: []Change{
+				{
+					Source:  "track01.mp3",
+					BaseDir: testDir,
+					Target:  filepath.Join("music", "AC_DC.mp3"),
+				},
+			},
+			args: []string{
+				"--csv",
+				csvFile,
+				"--sanitize",
+				"-f",
+				"track01",
+				"-r",
+				"music/{{csv.2}}",
+			},
+		},
+		{
+			name: "Separators in variable values are kept without --sanitize",
+			want: []Change{
+				{
+					Source:  "track01.mp3",
+					BaseDir: testDir,
+					Target:  filepath.Join("music", "AC", "DC.mp3"),
+				},
+			},
+			args: []string{
+				"--csv",
+				csvFile,
+				"-f",
+				"track01",
+				"-r",
+				"music/{{csv.2}}",
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
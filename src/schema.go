@@ -0,0 +1,445 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	schemaLower = "lower"
+	schemaUpper = "upper"
+)
+
+var (
+	errMissingSchema = errors.New(
+		"Invalid argument: the path to a naming schema must be specified with --schema",
+	)
+
+	errUnsupportedSchema = errors.New(
+		"Unsupported naming schema: use a .json, .yaml or .yml file",
+	)
+
+	errSchemaViolations = errors.New("The file names do not conform to the naming schema")
+
+	// schemaSeparators are tried in order when splitting a file
+	// name whose fields are not separated as the schema expects
+	schemaSeparators = []string{"_", "-", " ", "."}
+)
+
+// schemaField is one of the separated parts of a file name
+type schemaField struct {
+	name    string
+	pattern *regexp.Regexp
+	values  []string
+}
+
+// namingSchema describes the expected structure of the file names in
+// a tree: the fields separated by the separator, the allowed extensions
+// and the letter case
+type namingSchema struct {
+	separator  string
+	letterCase string
+	extensions []string
+	fields     []schemaField
+}
+
+// schemaViolation is a file name that does not conform to the schema
+type schemaViolation struct {
+	path    string
+	message string
+}
+
+// readSchema reads a naming schema such as:
+//
+//	separator: _
+//	case: lower
+//	extensions:
+//	  - jpg
+//	fields:
+//	  - name: date
+//	    pattern: \d{8}
+//	  - name: camera
+//	    values:
+//	      - canon
+//	      - sony
+//
+// from a JSON or YAML file
+func readSchema(path string) (*namingSchema, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedSchema, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	values, err := readMetaFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// list returns the items of the sequence with the key
+	list := func(key string) []string {
+		var items []string
+		for i := 0; ; i++ {
+			v, ok := values[key+"."+strconv.Itoa(i)]
+			if !ok {
+				return items
+			}
+
+			items = append(items, v)
+		}
+	}
+
+	s := &namingSchema{
+		separator:  values["separator"],
+		letterCase: strings.ToLower(values["case"]),
+	}
+
+	if s.separator == "" {
+		s.separator = "_"
+	}
+
+	switch s.letterCase {
+	case "", schemaLower, schemaUpper:
+	default:
+		return nil, fmt.Errorf(
+			"Invalid naming schema: case must be '%s' or '%s'",
+			schemaLower,
+			schemaUpper,
+		)
+	}
+
+	for _, v := range list("extensions") {
+		s.extensions = append(s.extensions, strings.TrimPrefix(v, "."))
+	}
+
+	for i := 0; ; i++ {
+		key := "fields." + strconv.Itoa(i)
+
+		name, ok := values[key+".name"]
+		if !ok {
+			break
+		}
+
+		field := schemaField{name: name, values: list(key + ".values")}
+
+		if p := values[key+".pattern"]; p != "" {
+			field.pattern, err = regexp.Compile("^(?:" + p + ")$")
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Invalid pattern for field '%s' in the naming schema: %w",
+					name,
+					err,
+				)
+			}
+		}
+
+		s.fields = append(s.fields, field)
+	}
+
+	if len(s.fields) == 0 && len(s.extensions) == 0 && s.letterCase == "" {
+		return nil, fmt.Errorf("The naming schema in %s has no rules", path)
+	}
+
+	return s, nil
+}
+
+// changeCase changes the letter case of the string to that of the schema
+func (s *namingSchema) changeCase(str string) string {
+	switch s.letterCase {
+	case schemaLower:
+		return strings.ToLower(str)
+	case schemaUpper:
+		return strings.ToUpper(str)
+	}
+
+	return str
+}
+
+// check reports why the value does not conform to the field
+func (f schemaField) check(value string) string {
+	if f.pattern != nil && !f.pattern.MatchString(value) {
+		return fmt.Sprintf(
+			"field '%s' (%s) does not match %s",
+			f.name,
+			value,
+			f.pattern.String(),
+		)
+	}
+
+	if len(f.values) > 0 && !contains(f.values, value) {
+		return fmt.Sprintf(
+			"field '%s' (%s) is not one of %s",
+			f.name,
+			value,
+			strings.Join(f.values, ", "),
+		)
+	}
+
+	return ""
+}
+
+// violations returns the reasons why the file name
+// does not conform to the schema
+func (s *namingSchema) violations(name string) []string {
+	var reasons []string
+
+	base, ext := splitExt(name)
+
+	if len(s.extensions) > 0 && !contains(s.extensions, strings.TrimPrefix(ext, ".")) {
+		reasons = append(reasons, fmt.Sprintf(
+			"extension '%s' is not one of %s",
+			ext,
+			strings.Join(s.extensions, ", "),
+		))
+	}
+
+	if s.changeCase(name) != name {
+		reasons = append(reasons, "name is not "+s.letterCase+" case")
+	}
+
+	if len(s.fields) == 0 {
+		return reasons
+	}
+
+	parts := strings.Split(base, s.separator)
+	if len(parts) != len(s.fields) {
+		return append(reasons, fmt.Sprintf(
+			"expected %d fields separated by '%s' but found %d",
+			len(s.fields),
+			s.separator,
+			len(parts),
+		))
+	}
+
+	for i, f := range s.fields {
+		if reason := f.check(parts[i]); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	return reasons
+}
+
+// conform returns a name that conforms to the schema if one can be derived
+// from the file name by changing the letter case, the separators between
+// the fields or the case of the allowed values and extensions
+func (s *namingSchema) conform(name string) (string, bool) {
+	base, ext := splitExt(name)
+
+	if len(s.extensions) > 0 {
+		for _, v := range s.extensions {
+			if strings.EqualFold(strings.TrimPrefix(ext, "."), v) {
+				ext = "." + v
+				break
+			}
+		}
+	}
+
+	if len(s.fields) > 0 {
+		var parts []string
+		for _, sep := range append([]string{s.separator}, schemaSeparators...) {
+			parts = strings.Split(base, sep)
+			if len(parts) == len(s.fields) {
+				break
+			}
+		}
+
+		if len(parts) != len(s.fields) {
+			return "", false
+		}
+
+		for i, f := range s.fields {
+			parts[i] = s.changeCase(parts[i])
+
+			for _, v := range f.values {
+				if strings.EqualFold(parts[i], v) {
+					parts[i] = v
+					break
+				}
+			}
+		}
+
+		base = strings.Join(parts, s.separator)
+	} else {
+		base = s.changeCase(base)
+	}
+
+	if len(s.extensions) == 0 {
+		ext = s.changeCase(ext)
+	}
+
+	target := base + ext
+	if len(s.violations(target)) > 0 {
+		return "", false
+	}
+
+	return target, true
+}
+
+// schemaFiles returns the files in the specified directories
+// ordered by their path
+func schemaFiles(
+	roots []string,
+	recursive bool,
+	hidden hiddenPolicy,
+	maxDepth int,
+) ([]Change, error) {
+	paths := make(map[string][]os.DirEntry)
+	for _, v := range roots {
+		de, err := os.ReadDir(v)
+		if err != nil {
+			return nil, err
+		}
+
+		paths[v] = de
+	}
+
+	var err error
+	if recursive {
+		paths, err = walk(osFS{}, paths, hidden, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var files []Change
+	for dir, entries := range paths {
+		entries, err = removeHidden(entries, dir, hidden)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+
+			files = append(files, Change{
+				BaseDir:        dir,
+				Source:         e.Name(),
+				Target:         e.Name(),
+				originalSource: e.Name(),
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].BaseDir != files[j].BaseDir {
+			return files[i].BaseDir < files[j].BaseDir
+		}
+
+		return files[i].Source < files[j].Source
+	})
+
+	return files, nil
+}
+
+// printViolations displays the file names that do not conform to the schema
+func printViolations(w io.Writer, violations []schemaViolation) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"File", "Violation"})
+	table.SetAutoWrapText(false)
+
+	for _, v := range violations {
+		table.Append([]string{displayName(v.path), v.message})
+	}
+
+	table.Render()
+}
+
+// checkSchema reports the file names in the specified directories (or the
+// current directory) that do not conform to the naming schema. With
+// --conform, the renames that fix the violations are previewed or applied
+func checkSchema(c *cli.Context) error {
+	path := c.String("schema")
+	if path == "" {
+		return errMissingSchema
+	}
+
+	schema, err := readSchema(path)
+	if err != nil {
+		return err
+	}
+
+	roots := c.Args().Slice()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	hidden := hiddenPolicy{files: c.Bool("hidden"), dirs: c.Bool("hidden")}
+
+	files, err := schemaFiles(roots, c.Bool("recursive"), hidden, c.Int("max-depth"))
+	if err != nil {
+		return err
+	}
+
+	var violations []schemaViolation
+
+	var changes []Change
+
+	for _, ch := range files {
+		reasons := schema.violations(ch.Source)
+		if len(reasons) == 0 {
+			continue
+		}
+
+		if c.Bool("conform") {
+			if target, ok := schema.conform(ch.Source); ok {
+				ch.Target = target
+				changes = append(changes, ch)
+
+				continue
+			}
+		}
+
+		violations = append(violations, schemaViolation{
+			path:    filepath.Join(ch.BaseDir, ch.Source),
+			message: strings.Join(reasons, "; "),
+		})
+	}
+
+	if len(violations) > 0 {
+		if c.Bool("conform") {
+			fmt.Println("The following file names cannot be conformed automatically:")
+		}
+
+		printViolations(os.Stdout, violations)
+	}
+
+	if !c.Bool("conform") || len(changes) == 0 {
+		if len(violations) > 0 {
+			return fmt.Errorf("%w: %d file(s)", errSchemaViolations, len(violations))
+		}
+
+		fmt.Println("Every file name conforms to the naming schema")
+
+		return nil
+	}
+
+	workingDir, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	op := &Operation{
+		exec:       c.Bool("exec"),
+		workingDir: workingDir,
+		matches:    changes,
+	}
+
+	return op.apply(c.Context)
+}
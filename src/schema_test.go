@@ -0,0 +1,268 @@
+package f2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeSchema(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReadSchema(t *testing.T) {
+	yamlSchema := writeSchema(t, "schema.yaml", `separator: _
+case: lower
+extensions:
+  - .jpg
+  - png
+fields:
+  - name: date
+    pattern: \d{8}
+  - name: camera
+    values:
+      - canon
+      - sony
+`)
+
+	jsonSchema := writeSchema(t, "schema.json", `{
+  "case": "lower",
+  "extensions": ["jpg", "png"],
+  "fields": [
+    {"name": "date", "pattern": "\\d{8}"},
+    {"name": "camera", "values": ["canon", "sony"]}
+  ]
+}`)
+
+	for _, path := range []string{yamlSchema, jsonSchema} {
+		s, err := readSchema(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		if s.separator != "_" || s.letterCase != schemaLower {
+			t.Fatalf(
+				"%s: expected separator '_' and lower case, got %q and %q",
+				path,
+				s.separator,
+				s.letterCase,
+			)
+		}
+
+		if !cmp.Equal(s.extensions, []string{"jpg", "png"}) {
+			t.Fatalf("%s: unexpected extensions %v", path, s.extensions)
+		}
+
+		if len(s.fields) != 2 || s.fields[0].name != "date" ||
+			s.fields[0].pattern == nil ||
+			!cmp.Equal(s.fields[1].values, []string{"canon", "sony"}) {
+			t.Fatalf("%s: unexpected fields %+v", path, s.fields)
+		}
+	}
+
+	_, err := readSchema(writeSchema(t, "schema.toml", "case = 'lower'"))
+	if !errors.Is(err, errUnsupportedSchema) {
+		t.Fatalf("Expected errUnsupportedSchema, got %v", err)
+	}
+
+	_, err = readSchema(writeSchema(t, "schema.yml", "case: title"))
+	if err == nil {
+		t.Fatal("Expected an error for an invalid letter case")
+	}
+
+	_, err = readSchema(writeSchema(t, "schema.yml", "fields:\n  - name: a\n    pattern: (\n"))
+	if err == nil {
+		t.Fatal("Expected an error for an invalid field pattern")
+	}
+}
+
+func TestSchemaViolations(t *testing.T) {
+	path := writeSchema(t, "schema.yaml", `case: lower
+extensions:
+  - jpg
+fields:
+  - name: date
+    pattern: \d{8}
+  - name: camera
+    values:
+      - canon
+      - sony
+`)
+
+	s, err := readSchema(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		violations int
+		conformed  string
+	}{
+		{name: "20210101_canon.jpg"},
+		{name: "20210101-SONY.JPG", violations: 3, conformed: "20210101_sony.jpg"},
+		{name: "20210101 Canon.jpg", violations: 2, conformed: "20210101_canon.jpg"},
+		{name: "2021_nikon.jpg", violations: 2},
+		{name: "20210101_canon_01.jpg", violations: 1},
+		{name: "20210101_canon.png", violations: 1},
+	}
+
+	for _, tc := range cases {
+		got := s.violations(tc.name)
+		if len(got) != tc.violations {
+			t.Fatalf(
+				"%s: expected %d violation(s), got %d: %v",
+				tc.name,
+				tc.violations,
+				len(got),
+				got,
+			)
+		}
+
+		if tc.violations == 0 {
+			continue
+		}
+
+		target, ok := s.conform(tc.name)
+		if ok != (tc.conformed != "") || target != tc.conformed {
+			t.Fatalf(
+				"%s: expected conformed name %q, got %q (%t)",
+				tc.name,
+				tc.conformed,
+				target,
+				ok,
+			)
+		}
+	}
+}
+
+func TestSchemaFiles(t *testing.T) {
+	testDir := setupFiles(t, []string{"b.jpg", "a.jpg", ".hidden.jpg", "sub/c.jpg"})
+
+	cases := []struct {
+		name      string
+		recursive bool
+		hidden    hiddenPolicy
+		want      []string
+	}{
+		{
+			name: "List the visible files in the directory",
+			want: []string{
+				filepath.Join(testDir, "a.jpg"),
+				filepath.Join(testDir, "b.jpg"),
+			},
+		},
+		{
+			name:      "List hidden files and subdirectories",
+			recursive: true,
+			hidden:    hiddenPolicy{files: true, dirs: true},
+			want: []string{
+				filepath.Join(testDir, ".hidden.jpg"),
+				filepath.Join(testDir, "a.jpg"),
+				filepath.Join(testDir, "b.jpg"),
+				filepath.Join(testDir, "sub", "c.jpg"),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		files, err := schemaFiles([]string{testDir}, tc.recursive, tc.hidden, 0)
+		if err != nil {
+			t.Fatalf("Test (%s) — Unexpected error: %v", tc.name, err)
+		}
+
+		var got []string
+		for _, v := range files {
+			got = append(got, filepath.Join(v.BaseDir, v.Source))
+		}
+
+		if !cmp.Equal(got, tc.want) {
+			t.Fatalf("Test (%s) — Expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	schema := writeSchema(t, "schema.yaml", `case: lower
+extensions:
+  - jpg
+fields:
+  - name: date
+    pattern: \d{8}
+  - name: camera
+    values:
+      - canon
+      - sony
+`)
+
+	conformingDir := setupFiles(t, []string{"20210101_canon.jpg"})
+
+	testDir := setupFiles(t, []string{
+		"20210101_canon.jpg",
+		"20210101-SONY.JPG",
+		"2021_nikon.jpg",
+	})
+
+	cases := []struct {
+		args    []string
+		want    []string
+		exclude []string
+		err     error
+	}{
+		{
+			args: []string{"check", "--schema", schema, conformingDir},
+			want: []string{"Every file name conforms to the naming schema"},
+		},
+		{
+			args:    []string{"check", "--schema", schema, testDir},
+			want:    []string{"FILE", "VIOLATION", "20210101-SONY.JPG", "2021_nikon.jpg"},
+			exclude: []string{"20210101_canon.jpg", "Every file name conforms"},
+			err:     errSchemaViolations,
+		},
+		{
+			args: []string{"check", "--schema", schema, "--conform", testDir},
+			want: []string{
+				"The following file names cannot be conformed automatically:",
+				"2021_nikon.jpg",
+				"20210101-SONY.JPG",
+				"20210101_sony.jpg",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		out, err := captureStdout(t, append([]string{os.Args[0]}, tc.args...))
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%v: expected error %v, but got: %v", tc.args, tc.err, err)
+		}
+
+		for _, v := range tc.want {
+			if !strings.Contains(out, v) {
+				t.Fatalf("%v: expected output to contain %q, but got:\n%s", tc.args, v, out)
+			}
+		}
+
+		for _, v := range tc.exclude {
+			if strings.Contains(out, v) {
+				t.Fatalf("%v: expected output not to contain %q, but got:\n%s", tc.args, v, out)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "20210101-SONY.JPG")); err != nil {
+		t.Fatalf("Expected --conform without --exec to leave the files unchanged: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package f2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+const (
+	scriptShell      = "sh"
+	scriptPowerShell = "ps1"
+)
+
+var (
+	errInvalidScriptFormat = errors.New(
+		"Invalid argument: --export-script must be set to 'sh' or 'ps1'",
+	)
+
+	errScriptLinkMode = errors.New(
+		"Invalid argument: --export-script cannot be used with --link",
+	)
+)
+
+// scriptCommand is a rename or directory creation recorded by scriptFS
+type scriptCommand struct {
+	mkdir  bool
+	source string
+	target string
+}
+
+// scriptFS records the renames and directories created by an operation
+// without changing anything so that they can be written to a script.
+// Files and directories are looked up on the underlying filesystem
+type scriptFS struct {
+	FS
+	commands []scriptCommand
+	dirs     map[string]bool
+}
+
+func (s *scriptFS) Rename(oldpath, newpath string) error {
+	s.commands = append(s.commands, scriptCommand{
+		source: oldpath,
+		target: newpath,
+	})
+
+	return nil
+}
+
+func (s *scriptFS) MkdirAll(path string, perm fs.FileMode) error {
+	if !s.dirs[path] {
+		s.dirs[path] = true
+		s.commands = append(s.commands, scriptCommand{
+			mkdir:  true,
+			target: path,
+		})
+	}
+
+	return nil
+}
+
+// quoteShell quotes the string for a POSIX shell
+func quoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quotePowerShell quotes the string for PowerShell
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// exportScript writes the renames as `mv` commands for a POSIX shell or
+// `Move-Item` commands for PowerShell instead of executing them so that
+// the operation can be reviewed, versioned or run without f2. The renames
+// are recorded in the order in which they would be performed so that
+// chains and cycles are handled correctly
+func (op *Operation) exportScript(w io.Writer) error {
+	if op.linkMode != "" {
+		return errScriptLinkMode
+	}
+
+	recorder := &scriptFS{
+		FS:   op.filesystem(),
+		dirs: make(map[string]bool),
+	}
+
+	matches, errs, fsys := op.matches, op.errors, op.fs
+	op.matches = append([]Change(nil), matches...)
+	op.fs = recorder
+
+	if op.includeDir {
+		op.sortMatches()
+	}
+
+	op.rename(context.Background())
+	op.matches, op.errors, op.fs = matches, errs, fsys
+
+	var b strings.Builder
+	switch op.exportScriptFormat {
+	case scriptShell:
+		b.WriteString("#!/bin/sh\nset -e\n\n")
+	case scriptPowerShell:
+		b.WriteString("$ErrorActionPreference = 'Stop'\n\n")
+	}
+
+	for _, v := range recorder.commands {
+		switch {
+		case op.exportScriptFormat == scriptShell && v.mkdir:
+			fmt.Fprintf(&b, "mkdir -p -- %s\n", quoteShell(v.target))
+		case op.exportScriptFormat == scriptShell:
+			fmt.Fprintf(
+				&b,
+				"mv -- %s %s\n",
+				quoteShell(v.source),
+				quoteShell(v.target),
+			)
+		case v.mkdir:
+			fmt.Fprintf(
+				&b,
+				"New-Item -ItemType Directory -Force -Path %s | Out-Null\n",
+				quotePowerShell(v.target),
+			)
+		default:
+			fmt.Fprintf(
+				&b,
+				"Move-Item -LiteralPath %s -Destination %s\n",
+				quotePowerShell(v.source),
+				quotePowerShell(v.target),
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
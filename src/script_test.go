@@ -0,0 +1,79 @@
+package f2
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var tempSuffixRegex = regexp.MustCompile(`\.f2tmp-\d+-[0-9a-f]{8}`)
+
+func TestExportScript(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt", "it's.txt"})
+
+	newOp := func(format string) *Operation {
+		return &Operation{
+			exportScriptFormat: format,
+			matches: []Change{
+				{BaseDir: testDir, Source: "a.txt", Target: "b.txt"},
+				{BaseDir: testDir, Source: "b.txt", Target: "a.txt"},
+				{
+					BaseDir: testDir,
+					Source:  "it's.txt",
+					Target:  filepath.Join("new", "it's.txt"),
+				},
+			},
+		}
+	}
+
+	join := func(name string) string {
+		return filepath.Join(testDir, name)
+	}
+
+	cases := map[string][]string{
+		scriptShell: {
+			"#!/bin/sh",
+			"set -e",
+			"",
+			"mv -- '" + join("a.txt") + "' '" + join("a.txt.f2tmp") + "'",
+			"mv -- '" + join("b.txt") + "' '" + join("a.txt") + "'",
+			"mv -- '" + join("a.txt.f2tmp") + "' '" + join("b.txt") + "'",
+			"mkdir -p -- '" + join("new") + "'",
+			"mv -- '" + join(`it'\''s.txt`) + "' '" + join(filepath.Join("new", `it'\''s.txt`)) + "'",
+		},
+		scriptPowerShell: {
+			"$ErrorActionPreference = 'Stop'",
+			"",
+			"Move-Item -LiteralPath '" + join("a.txt") + "' -Destination '" + join("a.txt.f2tmp") + "'",
+			"Move-Item -LiteralPath '" + join("b.txt") + "' -Destination '" + join("a.txt") + "'",
+			"Move-Item -LiteralPath '" + join("a.txt.f2tmp") + "' -Destination '" + join("b.txt") + "'",
+			"New-Item -ItemType Directory -Force -Path '" + join("new") + "' | Out-Null",
+			"Move-Item -LiteralPath '" + join("it''s.txt") + "' -Destination '" + join(filepath.Join("new", "it''s.txt")) + "'",
+		},
+	}
+
+	for format, lines := range cases {
+		op := newOp(format)
+
+		var buf bytes.Buffer
+		err := op.exportScript(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// temporary names include the process ID and a random suffix
+		got := tempSuffixRegex.ReplaceAllString(buf.String(), ".f2tmp")
+
+		want := strings.Join(lines, "\n") + "\n"
+		if got != want {
+			t.Fatalf("%s: expected:\n%s\nbut got:\n%s", format, want, got)
+		}
+
+		// nothing is renamed and the matches are left intact
+		if op.matches[0].Source != "a.txt" || len(op.errors) > 0 {
+			t.Fatalf("%s: the operation was modified: %v", format, op.matches)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package f2
+
+import (
+	"math/rand"
+	"time"
+)
+
+// random returns the source of random values for the operation. It is
+// seeded with the value of --seed (if set) so that random variables
+// produce the same values each time
+func (op *Operation) random() *rand.Rand {
+	if op.rng == nil {
+		seed := time.Now().UnixNano()
+		if op.seeded {
+			seed = op.seed
+		}
+
+		op.rng = rand.New(rand.NewSource(seed))
+	}
+
+	return op.rng
+}
+
+// currentTime returns the time used for the `{{now}}` variables
+func (op *Operation) currentTime() time.Time {
+	if op.now != nil {
+		return op.now()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,66 @@
+package f2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeed(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	now := func() time.Time {
+		return time.Date(2020, 5, 17, 0, 0, 0, 0, time.UTC)
+	}
+
+	plan := func(seed int64) []PlannedChange {
+		op, err := New(Options{
+			Paths:   []string{testDir},
+			Find:    []string{".*"},
+			Replace: []string{"{{r}}-{{now.YYYY}}{{ext}}"},
+			Seed:    seed,
+			Now:     now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := op.Plan()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// planning again repeats the random values
+		again, err := op.Plan()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range changes {
+			if changes[i].Target != again[i].Target {
+				t.Fatalf(
+					"Expected %s, but got %s",
+					changes[i].Target,
+					again[i].Target,
+				)
+			}
+		}
+
+		return changes
+	}
+
+	first, second, other := plan(42), plan(42), plan(7)
+
+	for i := range first {
+		if first[i].Target != second[i].Target {
+			t.Fatalf("Expected %s, but got %s", first[i].Target, second[i].Target)
+		}
+
+		if first[i].Target == other[i].Target {
+			t.Fatalf("Expected a different seed to produce %s", other[i].Target)
+		}
+
+		if len(first[i].Target) != len("xxxxxxxxxx-2020.txt") {
+			t.Fatalf("Unexpected target: %s", first[i].Target)
+		}
+	}
+}
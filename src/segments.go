@@ -0,0 +1,165 @@
+package f2
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+var (
+	segmentRegex = regexp.MustCompile(
+		`{{seg\.(\d+)(?:-(\d*))?(?:\.(up|lw|ti|win|mac|di))?}}`,
+	)
+	segmentSepRegex  = regexp.MustCompile(`{{seg\.sep}}`)
+	segmentSpecRegex = regexp.MustCompile(
+		`^(\d+)(?:-(\d*))?(?:\.(up|lw|ti|win|mac|di))?$`,
+	)
+
+	errInvalidSegments = errors.New(
+		"Invalid argument: --segments must be a comma separated list of segment positions or ranges (e.g. '2,1,4-' or '3.up,1')",
+	)
+	errSegmentsWithReplace = errors.New(
+		"Invalid argument: --segments cannot be combined with --replace",
+	)
+)
+
+// segmentDelimiters are the delimiters that are detected
+// automatically in order of preference
+var segmentDelimiters = []string{"-", "_", " "}
+
+// detectDelimiter returns the first delimiter present in the file name
+func detectDelimiter(name string) string {
+	for _, d := range segmentDelimiters {
+		if strings.Contains(name, d) {
+			return d
+		}
+	}
+
+	return ""
+}
+
+// splitSegments splits the file name (without its extension) by the
+// delimiter. The surrounding whitespace of each segment is removed so that
+// names like `Artist - Album` are handled correctly
+func splitSegments(name, delimiter string) []string {
+	if delimiter == "" {
+		return []string{name}
+	}
+
+	segments := strings.Split(name, delimiter)
+	for i := range segments {
+		segments[i] = strings.TrimSpace(segments[i])
+	}
+
+	return segments
+}
+
+// segmentsTemplate converts a list of segment positions (e.g. `2,1,4-`)
+// into a replacement string that joins the segments with the delimiter.
+// The extension is not included as it is preserved in segment mode
+func segmentsTemplate(spec string) (string, error) {
+	var parts []string
+	for _, v := range strings.Split(spec, ",") {
+		v = strings.TrimSpace(v)
+		if !segmentSpecRegex.MatchString(v) {
+			return "", errInvalidSegments
+		}
+
+		parts = append(parts, "{{seg."+v+"}}")
+	}
+
+	return strings.Join(parts, "{{seg.sep}}"), nil
+}
+
+// replaceSegmentVariables replaces `{{seg.N}}` with the Nth segment of the
+// file name, `{{seg.N-M}}` with segments N to M (or to the last segment if
+// M is omitted) joined by the delimiter, and `{{seg.sep}}` with the
+// delimiter. Segments are numbered from 1 and may be transformed
+// (e.g. `{{seg.1.up}}`)
+func (op *Operation) replaceSegmentVariables(input, fileName string) string {
+	delimiter, segments := op.fileSegments(fileName)
+
+	input = segmentRegex.ReplaceAllStringFunc(input, func(token string) string {
+		submatch := segmentRegex.FindStringSubmatch(token)
+
+		start, _ := strconv.Atoi(submatch[1])
+		end := start
+
+		// a range such as `{{seg.2-4}}` or `{{seg.2-}}`
+		if strings.Contains(token, "-") {
+			end = len(segments)
+			if submatch[2] != "" {
+				end, _ = strconv.Atoi(submatch[2])
+			}
+		}
+
+		if end > len(segments) {
+			end = len(segments)
+		}
+
+		if start < 1 || start > end {
+			return ""
+		}
+
+		value := strings.Join(segments[start-1:end], delimiter)
+
+		return transformString(submatch[3], value)
+	})
+
+	return segmentSepRegex.ReplaceAllLiteralString(input, delimiter)
+}
+
+// fileSegments returns the delimiter and segments of the file name
+func (op *Operation) fileSegments(fileName string) (string, []string) {
+	name := filenameWithoutExtension(filepath.Base(fileName))
+
+	delimiter := op.delimiter
+	if delimiter == "" {
+		delimiter = detectDelimiter(name)
+	}
+
+	return delimiter, splitSegments(name, delimiter)
+}
+
+// printSegments displays each matched file name split into numbered
+// columns so that the segment positions can be identified before
+// writing a --segments specification
+func (op *Operation) printSegments(w io.Writer) {
+	var columns int
+	rows := make([][]string, 0, len(op.matches))
+
+	for _, ch := range op.matches {
+		delimiter, segments := op.fileSegments(ch.Source)
+		if len(segments) > columns {
+			columns = len(segments)
+		}
+
+		row := []string{ch.Source, strconv.Quote(delimiter)}
+		rows = append(rows, append(row, segments...))
+	}
+
+	header := []string{"File", "Delimiter"}
+	for i := 1; i <= columns; i++ {
+		header = append(header, strconv.Itoa(i))
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+
+	for _, row := range rows {
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+}
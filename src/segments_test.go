@@ -0,0 +1,136 @@
+package f2
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"Artist-Album-01-Title.mp3",
+		"Artist - Album - 02 - Other Title.mp3",
+		"report_2021_final.pdf",
+	})
+
+	cases := []testCase{
+		{
+			name: "Reorder and drop segments",
+			want: []Change{
+				{
+					Source:  "Artist-Album-01-Title.mp3",
+					BaseDir: testDir,
+					Target:  "01-Title-ARTIST.mp3",
+				},
+				{
+					Source:  "Artist - Album - 02 - Other Title.mp3",
+					BaseDir: testDir,
+					Target:  "02-Other Title-ARTIST.mp3",
+				},
+			},
+			args: []string{
+				"-f",
+				"^Artist",
+				"--segments",
+				"3-,1.up",
+				testDir,
+			},
+		},
+		{
+			name: "Use segments in the replacement",
+			want: []Change{
+				{
+					Source:  "report_2021_final.pdf",
+					BaseDir: testDir,
+					Target:  "2021 Report.pdf",
+				},
+			},
+			args: []string{
+				"-f",
+				"report.*",
+				"-r",
+				"{{seg.2}} {{seg.1.ti}}{{ext}}",
+				testDir,
+			},
+		},
+		{
+			name: "Use a custom delimiter",
+			want: []Change{
+				{
+					Source:  "report_2021_final.pdf",
+					BaseDir: testDir,
+					Target:  "final_report_2021.pdf",
+				},
+			},
+			args: []string{
+				"-f",
+				"report",
+				"--segments",
+				"2-",
+				"--delimiter",
+				"report_",
+				"-r",
+				"",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases[:2])
+
+	_, err := action(append([]string{"f2"}, cases[2].args...))
+	if err != errSegmentsWithReplace {
+		t.Fatalf("Expected: %v, but got: %v", errSegmentsWithReplace, err)
+	}
+}
+
+func TestSegmentsTemplate(t *testing.T) {
+	got, err := segmentsTemplate("2, 1,4-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{{seg.2}}{{seg.sep}}{{seg.1}}{{seg.sep}}{{seg.4-}}"
+	if got != want {
+		t.Fatalf("Expected: %s, but got: %s", want, got)
+	}
+
+	if _, err := segmentsTemplate("a,b"); err != errInvalidSegments {
+		t.Fatalf("Expected: %v, but got: %v", errInvalidSegments, err)
+	}
+}
+
+func TestPrintSegments(t *testing.T) {
+	op := &Operation{
+		matches: []Change{
+			{Source: "Artist-Album-01-Title.mp3"},
+			{Source: "report_2021.pdf"},
+		},
+	}
+
+	var buf bytes.Buffer
+	op.printSegments(&buf)
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 5 {
+		t.Fatalf("Unexpected output: %s", buf.String())
+	}
+
+	for _, v := range []string{"File", "Delimiter", "1", "2", "3", "4"} {
+		if !strings.Contains(lines[1], v) {
+			t.Fatalf("Expected header to contain %q: %s", v, lines[1])
+		}
+	}
+
+	for _, v := range []string{"\"-\"", "Artist", "Album", "01", "Title"} {
+		if !strings.Contains(lines[3], v) {
+			t.Fatalf("Expected row to contain %q: %s", v, lines[3])
+		}
+	}
+
+	for _, v := range []string{"\"_\"", "report", "2021"} {
+		if !strings.Contains(lines[4], v) {
+			t.Fatalf("Expected row to contain %q: %s", v, lines[4])
+		}
+	}
+}
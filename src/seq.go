@@ -0,0 +1,138 @@
+package f2
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	seqRegex = regexp.MustCompile(
+		`{{seq\.(?:date:([^{}]+?)(?::([+-]\d+)(s|min|h|d|w|mo|y))?|letters(?::([A-Za-z]+))?(?::(\d+))?)}}`,
+	)
+
+	errInvalidSeqDate = errors.New(
+		"Invalid date in {{seq.date}}: use YYYY-MM-DD, optionally followed by a time such as T15:04 or T15:04:05",
+	)
+)
+
+// seqDateLayouts maps the accepted start dates to the layout of the
+// generated dates. Colons are left out of the generated times since they
+// cannot be used in file names on Windows and macOS
+var seqDateLayouts = [][2]string{
+	{"2006-01-02T15:04:05", "2006-01-02T150405"},
+	{"2006-01-02T15:04", "2006-01-02T1504"},
+	{"2006-01-02", "2006-01-02"},
+}
+
+// addSeqStep adds the step to the date n times
+func addSeqStep(t time.Time, step int, unit string, n int) time.Time {
+	step *= n
+
+	switch unit {
+	case "s":
+		return t.Add(time.Duration(step) * time.Second)
+	case "min":
+		return t.Add(time.Duration(step) * time.Minute)
+	case "h":
+		return t.Add(time.Duration(step) * time.Hour)
+	case "w":
+		return t.AddDate(0, 0, step*7)
+	case "mo":
+		return t.AddDate(0, step, 0)
+	case "y":
+		return t.AddDate(step, 0, 0)
+	}
+
+	return t.AddDate(0, 0, step)
+}
+
+// seqDate returns the date that is n steps after the start date
+func seqDate(start, step, unit string, n int) (string, error) {
+	for _, v := range seqDateLayouts {
+		t, err := time.Parse(v[0], start)
+		if err != nil {
+			continue
+		}
+
+		s := 1
+		if step != "" {
+			s, err = strconv.Atoi(step)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		return addSeqStep(t, s, unit, n).Format(v[1]), nil
+	}
+
+	return "", errInvalidSeqDate
+}
+
+// lettersToNumber converts spreadsheet-style column letters
+// to a number (A is 1, Z is 26 and AA is 27)
+func lettersToNumber(letters string) int {
+	var n int
+	for _, r := range strings.ToUpper(letters) {
+		n = n*26 + int(r-'A'+1)
+	}
+
+	return n
+}
+
+// numberToLetters converts a number to spreadsheet-style column letters
+func numberToLetters(n int) string {
+	var b []byte
+	for n > 0 {
+		n--
+		b = append([]byte{byte('A' + n%26)}, b...)
+		n /= 26
+	}
+
+	return string(b)
+}
+
+// seqLetters returns the letters that are n steps after the start letters.
+// The case of the start letters is preserved
+func seqLetters(start, step string, n int) string {
+	if start == "" {
+		start = "A"
+	}
+
+	s := 1
+	if step != "" {
+		s, _ = strconv.Atoi(step)
+	}
+
+	letters := numberToLetters(lettersToNumber(start) + n*s)
+	if strings.ToLower(start) == start {
+		return strings.ToLower(letters)
+	}
+
+	return letters
+}
+
+// replaceSeqVariables replaces the sequences that step through dates
+// (e.g. `{{seq.date:2021-01-01:+1d}}`) or letters (e.g.
+// `{{seq.letters:AA}}`) with the value at the specified position
+func replaceSeqVariables(input string, count int) (string, error) {
+	var err error
+
+	out := seqRegex.ReplaceAllStringFunc(input, func(token string) string {
+		m := seqRegex.FindStringSubmatch(token)
+		if !strings.HasPrefix(token, "{{seq.date") {
+			return seqLetters(m[4], m[5], count)
+		}
+
+		date, derr := seqDate(m[1], m[2], m[3], count)
+		if derr != nil {
+			err = derr
+		}
+
+		return date
+	})
+
+	return out, err
+}
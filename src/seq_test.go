@@ -0,0 +1,43 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestReplaceSeqVariables(t *testing.T) {
+	cases := []struct {
+		input string
+		count int
+		want  string
+	}{
+		{input: "{{seq.date:2021-01-01}}", count: 0, want: "2021-01-01"},
+		{input: "{{seq.date:2021-01-30:+1d}}", count: 3, want: "2021-02-02"},
+		{input: "{{seq.date:2021-01-31:+1mo}}", count: 1, want: "2021-03-03"},
+		{input: "{{seq.date:2021-01-01:+2w}}", count: 2, want: "2021-01-29"},
+		{input: "{{seq.date:2021-01-01:-1y}}", count: 1, want: "2020-01-01"},
+		{input: "{{seq.date:2021-01-01T09:00:+30min}}", count: 3, want: "2021-01-01T1030"},
+		{input: "{{seq.date:2021-01-01T23:59:30:+45s}}", count: 1, want: "2021-01-02T000015"},
+		{input: "{{seq.letters}}", count: 0, want: "A"},
+		{input: "{{seq.letters}}", count: 25, want: "Z"},
+		{input: "{{seq.letters}}", count: 26, want: "AA"},
+		{input: "{{seq.letters:az}}", count: 1, want: "ba"},
+		{input: "{{seq.letters:A:2}}", count: 13, want: "AA"},
+		{input: "row-{{seq.letters:ZZ}}", count: 1, want: "row-AAA"},
+	}
+
+	for _, tc := range cases {
+		got, err := replaceSeqVariables(tc.input, tc.count)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.input, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("Expected %s (%d) to be %s, but got %s", tc.input, tc.count, tc.want, got)
+		}
+	}
+
+	_, err := replaceSeqVariables("{{seq.date:01/02/2021}}", 0)
+	if err != errInvalidSeqDate {
+		t.Fatalf("Expected an invalid date error, but got: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+const serviceName = "f2"
+
+var errUnsupportedService = errors.New(
+	"Running f2 as a service is only supported with systemd on Linux and the Task Scheduler on Windows",
+)
+
+// serviceFile returns the location of the systemd user unit on Linux or
+// the script started by the scheduled task on Windows
+func serviceFile(goos string) (string, error) {
+	switch goos {
+	case "linux":
+		dir := os.Getenv("XDG_CONFIG_HOME")
+		if dir == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+
+			dir = filepath.Join(homeDir, ".config")
+		}
+
+		return filepath.Join(dir, "systemd", "user", serviceName+".service"), nil
+	case windows:
+		dir, err := dataDir("service")
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(dir, serviceName+".cmd"), nil
+	default:
+		return "", errUnsupportedService
+	}
+}
+
+// systemdQuote quotes an argument of a systemd command line. Percent signs
+// and dollar signs are escaped so that they are not expanded by systemd
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"%", "%%",
+		"$", "$$",
+	).Replace(s)
+
+	return `"` + s + `"`
+}
+
+// batchQuote quotes an argument of a command in a Windows batch file
+func batchQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, `""`, "%", "%%").Replace(s) + `"`
+}
+
+// serviceDefinition returns the contents of the systemd unit or the script
+// that runs f2 in watch mode with the specified arguments
+func serviceDefinition(goos, exe, workingDir string, args []string) string {
+	args = append([]string{exe, "--watch"}, args...)
+
+	var b strings.Builder
+
+	if goos == windows {
+		quoted := make([]string, len(args))
+		for i, v := range args {
+			quoted[i] = batchQuote(v)
+		}
+
+		fmt.Fprintf(&b, "@echo off\r\n")
+		fmt.Fprintf(&b, "cd /d %s\r\n", batchQuote(workingDir))
+		fmt.Fprintf(&b, "%s\r\n", strings.Join(quoted, " "))
+
+		return b.String()
+	}
+
+	quoted := make([]string, len(args))
+	for i, v := range args {
+		quoted[i] = systemdQuote(v)
+	}
+
+	fmt.Fprintf(&b, "[Unit]\nDescription=F2 watch mode\n\n")
+	fmt.Fprintf(&b, "[Service]\nType=simple\n")
+	// paths are not unquoted by systemd but specifiers are expanded
+	fmt.Fprintf(
+		&b,
+		"WorkingDirectory=%s\n",
+		strings.ReplaceAll(workingDir, "%", "%%"),
+	)
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	fmt.Fprintf(&b, "ExecReload=/bin/kill -HUP $MAINPID\n")
+	fmt.Fprintf(&b, "Restart=on-failure\n\n")
+	fmt.Fprintf(&b, "[Install]\nWantedBy=default.target\n")
+
+	return b.String()
+}
+
+// serviceCommands returns the commands that install, start or stop the
+// service on the specified operating system
+func serviceCommands(goos, action, file string) [][]string {
+	if goos == windows {
+		switch action {
+		case "install":
+			return [][]string{{
+				"schtasks", "/Create", "/F", "/SC", "ONLOGON",
+				"/TN", serviceName, "/TR", `"` + file + `"`,
+			}}
+		case "start":
+			return [][]string{{"schtasks", "/Run", "/TN", serviceName}}
+		default:
+			return [][]string{{"schtasks", "/End", "/TN", serviceName}}
+		}
+	}
+
+	unit := serviceName + ".service"
+
+	switch action {
+	case "install":
+		return [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+			{"systemctl", "--user", "enable", unit},
+		}
+	case "start":
+		return [][]string{{"systemctl", "--user", "start", unit}}
+	default:
+		return [][]string{{"systemctl", "--user", "stop", unit}}
+	}
+}
+
+// commandRunner runs the command made up of the specified arguments
+type commandRunner func(args []string) error
+
+// execCommand runs the command with its output connected to the terminal
+func execCommand(args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// service manages the definition of the service at the specified location
+// with the service manager of the specified operating system
+type service struct {
+	goos string
+	file string
+	run  commandRunner
+}
+
+// newService returns the service for the current operating system
+func newService() (*service, error) {
+	file, err := serviceFile(runtime.GOOS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service{goos: runtime.GOOS, file: file, run: execCommand}, nil
+}
+
+// runCommands runs the commands for the specified service action
+func (s *service) runCommands(action string) error {
+	for _, v := range serviceCommands(s.goos, action, s.file) {
+		err := s.run(v)
+		if err != nil {
+			return fmt.Errorf("'%s' failed: %w", strings.Join(v, " "), err)
+		}
+	}
+
+	return nil
+}
+
+// install writes the definition of the service that runs the executable
+// in watch mode with the specified arguments from the working directory
+// and registers it with the service manager
+func (s *service) install(exe, workingDir string, args []string) error {
+	var watchArgs []string
+	for _, v := range args {
+		if v != "--watch" {
+			watchArgs = append(watchArgs, v)
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(s.file), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	definition := serviceDefinition(s.goos, exe, workingDir, watchArgs)
+
+	err = os.WriteFile(s.file, []byte(definition), 0600)
+	if err != nil {
+		return err
+	}
+
+	return s.runCommands("install")
+}
+
+// control starts or stops the installed service
+func (s *service) control(action string) error {
+	if _, err := os.Stat(s.file); err != nil {
+		return fmt.Errorf(
+			"The service is not installed. Use 'f2 service install' first: %w",
+			err,
+		)
+	}
+
+	return s.runCommands(action)
+}
+
+// installService writes a systemd user unit (or a scheduled task on
+// Windows) that runs f2 in watch mode with the specified flags and paths
+// from the current directory
+func installService(c *cli.Context) error {
+	s, err := newService()
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	workingDir, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	err = s.install(exe, workingDir, c.Args().Slice())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"Installed the service in %s. Start it with 'f2 service start'\n",
+		s.file,
+	)
+
+	return nil
+}
+
+// controlService starts or stops the installed service
+func controlService(action string) error {
+	s, err := newService()
+	if err != nil {
+		return err
+	}
+
+	return s.control(action)
+}
@@ -0,0 +1,174 @@
+package f2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceDefinition(t *testing.T) {
+	args := []string{"-f", "IMG", "-r", "{{mtime.YYYY}}-%03d{{ext}}", "-x", "/home/user/My Photos"}
+
+	unit := serviceDefinition("linux", "/usr/bin/f2", "/home/user/100% done", args)
+
+	want := `ExecStart="/usr/bin/f2" "--watch" "-f" "IMG" "-r" "{{mtime.YYYY}}-%%03d{{ext}}" "-x" "/home/user/My Photos"`
+	if !strings.Contains(unit, want+"\n") {
+		t.Fatalf("Expected the unit to contain:\n%s\ngot:\n%s", want, unit)
+	}
+
+	for _, v := range []string{
+		"WorkingDirectory=/home/user/100%% done\n",
+		"ExecReload=/bin/kill -HUP $MAINPID",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, v) {
+			t.Fatalf("Expected the unit to contain %q, got:\n%s", v, unit)
+		}
+	}
+
+	script := serviceDefinition(windows, `C:\f2.exe`, `C:\Users\me`, []string{"-r", `say "%d"`})
+
+	want = "@echo off\r\ncd /d \"C:\\Users\\me\"\r\n\"C:\\f2.exe\" \"--watch\" \"-r\" \"say \"\"%%d\"\"\"\r\n"
+	if script != want {
+		t.Fatalf("Expected script:\n%q\ngot:\n%q", want, script)
+	}
+}
+
+func TestServiceCommands(t *testing.T) {
+	cmds := serviceCommands("linux", "install", "")
+	if len(cmds) != 2 || strings.Join(cmds[1], " ") != "systemctl --user enable f2.service" {
+		t.Fatalf("Unexpected install commands: %v", cmds)
+	}
+
+	cmds = serviceCommands("linux", "stop", "")
+	if strings.Join(cmds[0], " ") != "systemctl --user stop f2.service" {
+		t.Fatalf("Unexpected stop commands: %v", cmds)
+	}
+
+	cmds = serviceCommands(windows, "install", `C:\f2\f2.cmd`)
+	if strings.Join(cmds[0], " ") != `schtasks /Create /F /SC ONLOGON /TN f2 /TR "C:\f2\f2.cmd"` {
+		t.Fatalf("Unexpected Windows install commands: %v", cmds)
+	}
+
+	cmds = serviceCommands(windows, "start", "")
+	if strings.Join(cmds[0], " ") != "schtasks /Run /TN f2" {
+		t.Fatalf("Unexpected Windows start commands: %v", cmds)
+	}
+
+	if _, err := serviceFile(darwin); !errors.Is(err, errUnsupportedService) {
+		t.Fatalf("Expected errUnsupportedService, got: %v", err)
+	}
+}
+
+func TestServiceInstall(t *testing.T) {
+	cases := []struct {
+		goos     string
+		file     string
+		want     string
+		commands []string
+	}{
+		{
+			goos: "linux",
+			file: filepath.Join("systemd", "user", "f2.service"),
+			want: "[Unit]\nDescription=F2 watch mode\n\n" +
+				"[Service]\nType=simple\n" +
+				"WorkingDirectory=/home/user\n" +
+				`ExecStart="/usr/bin/f2" "--watch" "--watch-rules" "rules.toml" "-x" "Downloads"` + "\n" +
+				"ExecReload=/bin/kill -HUP $MAINPID\n" +
+				"Restart=on-failure\n\n" +
+				"[Install]\nWantedBy=default.target\n",
+			commands: []string{
+				"systemctl --user daemon-reload",
+				"systemctl --user enable f2.service",
+			},
+		},
+		{
+			goos: windows,
+			file: filepath.Join("service", "f2.cmd"),
+			want: "@echo off\r\n" +
+				"cd /d \"/home/user\"\r\n" +
+				`"/usr/bin/f2" "--watch" "--watch-rules" "rules.toml" "-x" "Downloads"` + "\r\n",
+			commands: []string{"schtasks /Create /F /SC ONLOGON /TN f2 /TR"},
+		},
+	}
+
+	for _, tc := range cases {
+		var commands []string
+
+		s := &service{
+			goos: tc.goos,
+			file: filepath.Join(t.TempDir(), tc.file),
+			run: func(args []string) error {
+				commands = append(commands, strings.Join(args, " "))
+				return nil
+			},
+		}
+
+		err := s.control("start")
+		if err == nil || len(commands) != 0 {
+			t.Fatalf("%s: expected an error before the service is installed", tc.goos)
+		}
+
+		err = s.install("/usr/bin/f2", "/home/user", []string{
+			"--watch", "--watch-rules", "rules.toml", "-x", "Downloads",
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.goos, err)
+		}
+
+		b, err := os.ReadFile(s.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != tc.want {
+			t.Fatalf("%s: expected the service definition:\n%q\ngot:\n%q", tc.goos, tc.want, b)
+		}
+
+		if len(commands) != len(tc.commands) {
+			t.Fatalf("%s: expected commands %v, got: %v", tc.goos, tc.commands, commands)
+		}
+
+		for i := range commands {
+			if !strings.HasPrefix(commands[i], tc.commands[i]) {
+				t.Fatalf("%s: expected commands %v, got: %v", tc.goos, tc.commands, commands)
+			}
+		}
+
+		commands = nil
+
+		err = s.control("stop")
+		if err != nil || len(commands) != 1 {
+			t.Fatalf("%s: expected the service to be stopped, got: %v %v", tc.goos, commands, err)
+		}
+	}
+
+	s := &service{
+		goos: "linux",
+		file: filepath.Join(t.TempDir(), "f2.service"),
+		run: func(args []string) error {
+			return errors.New("exit status 1")
+		},
+	}
+
+	err := s.install("/usr/bin/f2", "/home/user", nil)
+	if err == nil || !strings.Contains(err.Error(), "'systemctl --user daemon-reload' failed") {
+		t.Fatalf("Expected the failed command to be reported, got: %v", err)
+	}
+}
+
+func TestServiceFile(t *testing.T) {
+	configHome := setConfigHome(t)
+
+	file, err := serviceFile("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(configHome, "systemd", "user", "f2.service")
+	if file != want {
+		t.Fatalf("Expected the unit to be written to %s, got: %s", want, file)
+	}
+}
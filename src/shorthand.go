@@ -0,0 +1,60 @@
+package f2
+
+import (
+	"strings"
+)
+
+// findShorthands are the tokens that may be used in a find pattern in place
+// of common regular expressions. `\dte` matches the dates that are usually
+// reformatted with `{{$1|date:...}}` such as 2021-04-05, 20210405,
+// 05-04-2021 or 05.04.2021
+var findShorthands = []struct {
+	token   string
+	pattern string
+}{
+	{`\dte`, `(?:\d{4}[-_.]?\d{2}[-_.]?\d{2}|\d{2}[-_.]\d{2}[-_.]\d{4})`},
+	{`\num`, `\d+`},
+	{`\ws`, `\s+`},
+}
+
+// expandShorthands replaces the shorthand tokens in the find pattern with
+// the regular expressions they stand for. A token preceded by an escaped
+// backslash (e.g. `\\num`) is left unchanged
+func expandShorthands(pattern string) string {
+	if !strings.Contains(pattern, `\`) {
+		return pattern
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '\\' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+
+		if i+1 < len(pattern) && pattern[i+1] == '\\' {
+			b.WriteString(`\\`)
+			i++
+
+			continue
+		}
+
+		expanded := false
+		for _, v := range findShorthands {
+			if strings.HasPrefix(pattern[i:], v.token) {
+				b.WriteString(v.pattern)
+				i += len(v.token) - 1
+				expanded = true
+
+				break
+			}
+		}
+
+		if !expanded {
+			b.WriteByte('\\')
+		}
+	}
+
+	return b.String()
+}
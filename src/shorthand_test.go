@@ -0,0 +1,46 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestExpandShorthands(t *testing.T) {
+	cases := map[string]string{
+		`IMG\ws(\num)`: `IMG\s+(\d+)`,
+		`\\num`:        `\\num`,
+		`\w\.jpg`:      `\w\.jpg`,
+		`(\dte)`:       `((?:\d{4}[-_.]?\d{2}[-_.]?\d{2}|\d{2}[-_.]\d{2}[-_.]\d{4}))`,
+	}
+
+	for input, want := range cases {
+		if got := expandShorthands(input); got != want {
+			t.Fatalf("Expected %s to expand to %s, but got: %s", input, want, got)
+		}
+	}
+}
+
+func TestFindShorthands(t *testing.T) {
+	testDir := setupFiles(t, []string{"report  05-04-2021 12.pdf"})
+
+	cases := []testCase{
+		{
+			name: "Use shorthands in the find pattern",
+			want: []Change{
+				{
+					Source:  "report  05-04-2021 12.pdf",
+					BaseDir: testDir,
+					Target:  "report_2021-04-05_12.pdf",
+				},
+			},
+			args: []string{
+				"-f",
+				"(\\w+)\\ws(\\dte)\\ws(\\num)",
+				"-r",
+				"${1}_{{$2|date:DD-MM-YYYY>YYYY-MM-DD}}_$3",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
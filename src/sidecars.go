@@ -0,0 +1,49 @@
+package f2
+
+import (
+	"path/filepath"
+)
+
+// pairSidecars adds the files that share the same name as a renamed file
+// but have a different extension (such as `IMG_0001.xmp` for
+// `IMG_0001.jpg` or `movie.srt` for `movie.mkv`) to the matches so that
+// they are renamed consistently. Sidecars that were matched on their own
+// keep their target
+func (op *Operation) pairSidecars() {
+	matched := make(map[string]bool)
+	for _, ch := range op.matches {
+		matched[filepath.Join(ch.BaseDir, ch.Source)] = true
+	}
+
+	targets := make(map[string]Change)
+	for _, ch := range op.matches {
+		if ch.IsDir || ch.Target == ch.Source {
+			continue
+		}
+
+		// the first match with a particular name takes precedence
+		key := pairKey(ch)
+		if _, ok := targets[key]; !ok {
+			targets[key] = ch
+		}
+	}
+
+	var sidecars []Change
+	for _, v := range op.paths {
+		if v.IsDir || matched[filepath.Join(v.BaseDir, v.Source)] {
+			continue
+		}
+
+		ch, ok := targets[pairKey(v)]
+		if !ok {
+			continue
+		}
+
+		dir := filepath.Dir(ch.Target)
+		base := filenameWithoutExtension(filepath.Base(ch.Target))
+		v.Target = filepath.Join(dir, base+filepath.Ext(v.Source))
+		sidecars = append(sidecars, v)
+	}
+
+	op.matches = append(op.matches, sidecars...)
+}
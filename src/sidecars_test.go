@@ -0,0 +1,52 @@
+package f2
+
+import "testing"
+
+func TestPairSidecars(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_001.jpg",
+		"IMG_001.RAW",
+		"IMG_001.xmp",
+		"IMG_002.jpg",
+		"movie.mkv",
+		"movie.srt",
+		"other.srt",
+	})
+
+	cases := []testCase{
+		{
+			name: "Rename sidecar files along with the matched files",
+			want: []Change{
+				{Source: "IMG_001.jpg", BaseDir: testDir, Target: "photo_001.jpg"},
+				{Source: "IMG_001.RAW", BaseDir: testDir, Target: "photo_001.RAW"},
+				{Source: "IMG_001.xmp", BaseDir: testDir, Target: "photo_001.xmp"},
+				{Source: "IMG_002.jpg", BaseDir: testDir, Target: "photo_002.jpg"},
+			},
+			args: []string{
+				"-f",
+				"IMG(.*)\\.jpg",
+				"-r",
+				"photo$1.jpg",
+				"--pair",
+				testDir,
+			},
+		},
+		{
+			name: "Rename subtitles along with the video",
+			want: []Change{
+				{Source: "movie.mkv", BaseDir: testDir, Target: "Film (2020).mkv"},
+				{Source: "movie.srt", BaseDir: testDir, Target: "Film (2020).srt"},
+			},
+			args: []string{
+				"-f",
+				"^movie\\.mkv$",
+				"-r",
+				"Film (2020).mkv",
+				"--pair",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
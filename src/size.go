@@ -0,0 +1,46 @@
+package f2
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// sizeRegex matches the file size in bytes (`{{size}}`) or
+// in a human readable form (`{{size.h}}`)
+var sizeRegex = regexp.MustCompile(`{{size(\.h)?}}`)
+
+// humanSize formats the number of bytes with the largest decimal unit
+// that keeps the value above one (e.g. 4.2MB)
+func humanSize(size int64) string {
+	const unit = 1000
+	if size < unit {
+		return strconv.FormatInt(size, 10) + "B"
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%cB", float64(size)/float64(div), "KMGTP"[exp])
+}
+
+// replaceSizeVariables replaces the size variables with
+// the size of the file
+func replaceSizeVariables(input, filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return sizeRegex.ReplaceAllStringFunc(input, func(v string) string {
+		if sizeRegex.FindStringSubmatch(v)[1] != "" {
+			return humanSize(info.Size())
+		}
+
+		return strconv.FormatInt(info.Size(), 10)
+	}), nil
+}
@@ -0,0 +1,55 @@
+package f2
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHumanSize(t *testing.T) {
+	cases := map[int64]string{
+		0:             "0B",
+		999:           "999B",
+		1000:          "1.0KB",
+		1500:          "1.5KB",
+		4200000:       "4.2MB",
+		3000000000:    "3.0GB",
+		5000000000000: "5.0TB",
+	}
+
+	for size, want := range cases {
+		if got := humanSize(size); got != want {
+			t.Fatalf("Expected %d to be %s, but got: %s", size, want, got)
+		}
+	}
+}
+
+func TestSizeVariables(t *testing.T) {
+	testDir := setupFiles(t, []string{"export.csv"})
+
+	err := os.WriteFile(
+		filepath.Join(testDir, "export.csv"),
+		[]byte(strings.Repeat("a", 1500)),
+		0600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []testCase{
+		{
+			name: "Add the file size to the name",
+			want: []Change{
+				{
+					Source:  "export.csv",
+					BaseDir: testDir,
+					Target:  "export_1500_1.5KB.csv",
+				},
+			},
+			args: []string{"-f", "export", "-r", "export_{{size}}_{{size.h}}", "--strict", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,75 @@
+package f2
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// sliceRegex matches a variable followed by a start and end position
+// such as `{{f:0:8}}` or `{{f:-4:}}`
+var sliceRegex = regexp.MustCompile(`{{([^{}|:]+):(-?\d*):(-?\d*)}}`)
+
+// slicePosition converts the position in a slice expression to an index
+// into a string of the specified length. Negative positions count from the
+// end of the string and positions out of range are clamped
+func slicePosition(pos string, length, fallback int) int {
+	if pos == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(pos)
+	if err != nil {
+		return fallback
+	}
+
+	if n < 0 {
+		n += length
+	}
+
+	switch {
+	case n < 0:
+		return 0
+	case n > length:
+		return length
+	}
+
+	return n
+}
+
+// sliceString returns the characters of the string from the start position
+// up to but not including the end position. Either position may be omitted
+func sliceString(str, start, end string) string {
+	runes := []rune(str)
+
+	i := slicePosition(start, len(runes), 0)
+	j := slicePosition(end, len(runes), len(runes))
+	if i >= j {
+		return ""
+	}
+
+	return string(runes[i:j])
+}
+
+// replaceSlices replaces each variable that is followed by a start and end
+// position (e.g. `{{f:0:8}}`) with the corresponding part of its value
+func (op *Operation) replaceSlices(input string, ch Change) (string, error) {
+	var err error
+
+	out := sliceRegex.ReplaceAllStringFunc(input, func(token string) string {
+		if err != nil {
+			return token
+		}
+
+		submatch := sliceRegex.FindStringSubmatch(token)
+
+		value, verr := op.variableValue(submatch[1], ch)
+		if verr != nil {
+			err = verr
+			return token
+		}
+
+		return sliceString(value, submatch[2], submatch[3])
+	})
+
+	return out, err
+}
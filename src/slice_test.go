@@ -0,0 +1,55 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestSliceString(t *testing.T) {
+	cases := []struct {
+		start string
+		end   string
+		want  string
+	}{
+		{"0", "8", "20210405"},
+		{"-7", "", "holiday"},
+		{"", "4", "2021"},
+		{"9", "-3", "holi"},
+		{"", "", "20210405_holiday"},
+		{"10", "2", ""},
+		{"-100", "100", "20210405_holiday"},
+	}
+
+	for _, tc := range cases {
+		got := sliceString("20210405_holiday", tc.start, tc.end)
+		if got != tc.want {
+			t.Fatalf("Expected [%s:%s] to be %q, but got: %q", tc.start, tc.end, tc.want, got)
+		}
+	}
+}
+
+func TestSliceVariables(t *testing.T) {
+	testDir := setupFiles(t, []string{"20210405_holiday_Été.jpg"})
+
+	cases := []testCase{
+		{
+			name: "Slice the file name by position",
+			want: []Change{
+				{
+					Source:  "20210405_holiday_Été.jpg",
+					BaseDir: testDir,
+					Target:  "2021-04-05 Été.jpg",
+				},
+			},
+			args: []string{
+				"-f",
+				".*",
+				"-r",
+				"{{f:0:4}}-{{f:4:6}}-{{f:6:8}} {{f:-3:}}{{ext}}",
+				"--strict",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -1,16 +1,25 @@
 package f2
 
 import (
-	"io/fs"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	exiftool "github.com/barasher/go-exiftool"
 	"gopkg.in/djherbis/times.v1"
 )
 
+// The sort orders that do not depend on a file time
+const (
+	sortDefault  = "default"
+	sortSize     = "size"
+	sortDuration = "duration"
+)
+
 // sortMatches is used to sort files to avoid renaming conflicts
 func (op *Operation) sortMatches() {
 	sort.SliceStable(op.matches, func(i, j int) bool {
@@ -30,17 +39,22 @@ func (op *Operation) sortMatches() {
 }
 
 // sortBySize sorts the matches according to their file size
-func (op *Operation) sortBySize() (err error) {
-	sort.SliceStable(op.matches, func(i, j int) bool {
-		ipath := filepath.Join(op.matches[i].BaseDir, op.matches[i].Source)
-		jpath := filepath.Join(op.matches[j].BaseDir, op.matches[j].Source)
+func (op *Operation) sortBySize() error {
+	sizes := make(map[string]int64, len(op.matches))
+	for _, ch := range op.matches {
+		path := filepath.Join(ch.BaseDir, ch.Source)
+
+		info, err := op.filesystem().Stat(path)
+		if err != nil {
+			return err
+		}
 
-		var ifile, jfile fs.FileInfo
-		ifile, err = os.Stat(ipath)
-		jfile, err = os.Stat(jpath)
+		sizes[path] = info.Size()
+	}
 
-		isize := ifile.Size()
-		jsize := jfile.Size()
+	sort.SliceStable(op.matches, func(i, j int) bool {
+		isize := sizes[filepath.Join(op.matches[i].BaseDir, op.matches[i].Source)]
+		jsize := sizes[filepath.Join(op.matches[j].BaseDir, op.matches[j].Source)]
 
 		if op.reverseSort {
 			return isize < jsize
@@ -49,7 +63,7 @@ func (op *Operation) sortBySize() (err error) {
 		return isize > jsize
 	})
 
-	return err
+	return nil
 }
 
 // sortByTime sorts the matches by the specified file attribute
@@ -103,6 +117,76 @@ func (op *Operation) sortByTime() (err error) {
 	return err
 }
 
+// mediaDurations returns the duration in seconds of each audio or video
+// file as reported by exiftool. Files without a duration are omitted
+func mediaDurations(paths []string) (map[string]float64, error) {
+	et, err := exiftool.NewExiftool(exiftool.NoPrintConversion())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to initialise exiftool: %w", err)
+	}
+
+	defer et.Close()
+
+	durations := make(map[string]float64)
+	for _, fileInfo := range et.ExtractMetadata(paths...) {
+		if fileInfo.Err != nil {
+			continue
+		}
+
+		v, ok := fileInfo.Fields["Duration"]
+		if !ok {
+			continue
+		}
+
+		d, err := strconv.ParseFloat(fmt.Sprintf("%v", v), 64)
+		if err == nil {
+			durations[fileInfo.File] = d
+		}
+	}
+
+	return durations, nil
+}
+
+// sortByDurations sorts the matches from the longest to the shortest
+// duration. Matches without a duration are placed last
+func (op *Operation) sortByDurations(durations map[string]float64) {
+	sort.SliceStable(op.matches, func(i, j int) bool {
+		ipath := filepath.Join(op.matches[i].BaseDir, op.matches[i].Source)
+		jpath := filepath.Join(op.matches[j].BaseDir, op.matches[j].Source)
+
+		id, iok := durations[ipath]
+		jd, jok := durations[jpath]
+
+		if !iok || !jok {
+			return iok
+		}
+
+		if op.reverseSort {
+			return id < jd
+		}
+
+		return id > jd
+	})
+}
+
+// sortByDuration sorts the matches according to the
+// duration of the audio or video files
+func (op *Operation) sortByDuration() error {
+	paths := make([]string, len(op.matches))
+	for i := range op.matches {
+		paths[i] = filepath.Join(op.matches[i].BaseDir, op.matches[i].Source)
+	}
+
+	durations, err := mediaDurations(paths)
+	if err != nil {
+		return err
+	}
+
+	op.sortByDurations(durations)
+
+	return nil
+}
+
 func (op *Operation) sortPaths(
 	paths map[string][]os.DirEntry,
 	sorted bool,
@@ -185,10 +269,12 @@ func (op *Operation) sortPaths(
 // sortBy delegates the sorting of matches to the appropriate method
 func (op *Operation) sortBy() (err error) {
 	switch op.sort {
-	case "size":
+	case sortSize:
 		return op.sortBySize()
 	case accessTime, modTime, birthTime, changeTime:
 		return op.sortByTime()
+	case sortDuration:
+		return op.sortByDuration()
 	}
 
 	return nil
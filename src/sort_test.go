@@ -1,6 +1,11 @@
 package f2
 
-import "testing"
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestSortBySize(t *testing.T) {
 	testDir := "../testdata/images"
@@ -207,3 +212,119 @@ func TestDefaultSort(t *testing.T) {
 
 	runFindReplace(t, cases)
 }
+
+func TestSortByDurations(t *testing.T) {
+	matches := []Change{
+		{BaseDir: "music", Source: "cover.jpg"},
+		{BaseDir: "music", Source: "short.mp3"},
+		{BaseDir: "music", Source: "long.flac"},
+		{BaseDir: "music", Source: "medium.m4a"},
+	}
+
+	durations := map[string]float64{
+		filepath.Join("music", "short.mp3"):  95.5,
+		filepath.Join("music", "long.flac"):  412,
+		filepath.Join("music", "medium.m4a"): 240.25,
+	}
+
+	cases := []struct {
+		reverse bool
+		want    []string
+	}{
+		{false, []string{"long.flac", "medium.m4a", "short.mp3", "cover.jpg"}},
+		{true, []string{"short.mp3", "medium.m4a", "long.flac", "cover.jpg"}},
+	}
+
+	for _, tc := range cases {
+		op := &Operation{
+			matches:     append([]Change{}, matches...),
+			reverseSort: tc.reverse,
+		}
+
+		op.sortByDurations(durations)
+
+		var got []string
+		for _, v := range op.matches {
+			got = append(got, v.Source)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("Expected order (reverse: %t): %v, but got: %v", tc.reverse, tc.want, got)
+		}
+	}
+}
+
+func TestSortByDuration(t *testing.T) {
+	_, err := exec.LookPath("exiftool")
+	if err != nil {
+		return
+	}
+
+	rootDir := filepath.Join("..", "testdata", "audio")
+
+	var paths []string
+	for _, v := range []string{
+		"sample_mp3.mp3",
+		"sample_flac.flac",
+		"sample_ogg.ogg",
+		"sample_mp3.json",
+	} {
+		paths = append(paths, filepath.Join(rootDir, v))
+	}
+
+	durations, err := mediaDurations(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each sample is about three and a half seconds long
+	for _, v := range paths[:3] {
+		if d, ok := durations[v]; !ok || d < 3 || d > 4 {
+			t.Fatalf("Expected the duration of %s to be about 3.4s, but got: %v", v, d)
+		}
+	}
+
+	if _, ok := durations[paths[3]]; ok {
+		t.Fatalf("Expected no duration for %s", paths[3])
+	}
+
+	// the MP3 sample is a few hundredths of a second longer
+	cases := []testCase{
+		{
+			name: "Sort audio files by duration",
+			want: []Change{
+				{Source: "sample_flac.flac", BaseDir: rootDir, Target: "2.flac"},
+				{Source: "sample_mp3.mp3", BaseDir: rootDir, Target: "1.mp3"},
+			},
+			args: []string{
+				"-f",
+				"sample_(flac|mp3)",
+				"-r",
+				"%d",
+				"-e",
+				"--sort",
+				"duration",
+				rootDir,
+			},
+		},
+		{
+			name: "Sort audio files by duration in reverse order",
+			want: []Change{
+				{Source: "sample_flac.flac", BaseDir: rootDir, Target: "1.flac"},
+				{Source: "sample_mp3.mp3", BaseDir: rootDir, Target: "2.mp3"},
+			},
+			args: []string{
+				"-f",
+				"sample_(flac|mp3)",
+				"-r",
+				"%d",
+				"-e",
+				"--sortr",
+				"duration",
+				rootDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,98 @@
+package f2
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	splitRegex = regexp.MustCompile(`{{split}}`)
+
+	errInvalidSplit = errors.New(
+		"Invalid argument: --split must be a comma separated list of <name>=<ratio> pairs with positive ratios (e.g. 'train=0.8,val=0.1,test=0.1')",
+	)
+
+	errSplitNotSet = errors.New(
+		"The {{split}} variable requires the --split flag (e.g. --split 'train=0.8,val=0.1,test=0.1')",
+	)
+)
+
+// datasetSplit is a named group that receives the specified
+// proportion of the matched files
+type datasetSplit struct {
+	name  string
+	ratio float64
+}
+
+// parseSplits parses the value of --split. Ratios are relative to their
+// sum so `train=8,val=1,test=1` is the same as `train=0.8,val=0.1,test=0.1`
+func parseSplits(value string) ([]datasetSplit, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var splits []datasetSplit
+
+	var total float64
+
+	for _, v := range strings.Split(value, ",") {
+		pair := strings.SplitN(v, "=", 2)
+		if len(pair) != 2 {
+			return nil, errInvalidSplit
+		}
+
+		name := strings.TrimSpace(pair[0])
+
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(pair[1]), 64)
+		if err != nil || name == "" || ratio <= 0 || math.IsInf(ratio, 0) {
+			return nil, errInvalidSplit
+		}
+
+		splits = append(splits, datasetSplit{name: name, ratio: ratio})
+		total += ratio
+	}
+
+	for i := range splits {
+		splits[i].ratio /= total
+	}
+
+	return splits, nil
+}
+
+// assignSplit returns the name of the split that the file belongs to. The
+// assignment is derived from a hash of the seed and the file name so that it
+// is reproducible across runs and independent of the other files
+func assignSplit(splits []datasetSplit, seed, fileName string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(seed + "\x00" + fileName))
+
+	pos := float64(h.Sum64()) / float64(math.MaxUint64)
+
+	var cumulative float64
+	for _, v := range splits {
+		cumulative += v.ratio
+		if pos < cumulative {
+			return v.name
+		}
+	}
+
+	return splits[len(splits)-1].name
+}
+
+// replaceSplitVariables replaces each `{{split}}` variable with the name
+// of the split that the file is assigned to
+func (op *Operation) replaceSplitVariables(
+	input, fileName string,
+) (string, error) {
+	if len(op.splits) == 0 {
+		return "", errSplitNotSet
+	}
+
+	name := assignSplit(op.splits, op.splitSeed, fileName)
+
+	return splitRegex.ReplaceAllString(input, name), nil
+}
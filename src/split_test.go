@@ -0,0 +1,90 @@
+package f2
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseSplits(t *testing.T) {
+	got, err := parseSplits("train=8, val=1,test=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []datasetSplit{{"train", 0.8}, {"val", 0.1}, {"test", 0.1}}
+	for i := range want {
+		if got[i].name != want[i].name ||
+			fmt.Sprintf("%.2f", got[i].ratio) != fmt.Sprintf("%.2f", want[i].ratio) {
+			t.Fatalf("Expected: %v, but got: %v", want, got)
+		}
+	}
+
+	for _, v := range []string{"train", "train=0", "=1", "train=x"} {
+		if _, err := parseSplits(v); err != errInvalidSplit {
+			t.Fatalf("Expected %q to be invalid, but got: %v", v, err)
+		}
+	}
+}
+
+func TestAssignSplit(t *testing.T) {
+	splits, err := parseSplits("train=0.8,val=0.1,test=0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		name := fmt.Sprintf("img_%05d.jpg", i)
+
+		split := assignSplit(splits, "", name)
+		if split != assignSplit(splits, "", name) {
+			t.Fatalf("Expected the assignment of %s to be reproducible", name)
+		}
+
+		counts[split]++
+	}
+
+	if counts["train"] < 7700 || counts["train"] > 8300 ||
+		counts["val"] < 800 || counts["val"] > 1200 ||
+		counts["test"] < 800 || counts["test"] > 1200 {
+		t.Fatalf("Expected the files to be split in proportion, but got: %v", counts)
+	}
+
+	var changed bool
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("img_%05d.jpg", i)
+		if assignSplit(splits, "", name) != assignSplit(splits, "seed", name) {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		t.Fatal("Expected the seed to change the assignment")
+	}
+}
+
+func TestSplitVariable(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.jpg", "b.jpg"})
+
+	cases := []testCase{
+		{
+			name: "Assign every file to the only split",
+			want: []Change{
+				{
+					Source:  "a.jpg",
+					BaseDir: testDir,
+					Target:  "train/a.jpg",
+				},
+				{
+					Source:  "b.jpg",
+					BaseDir: testDir,
+					Target:  "train/b.jpg",
+				},
+			},
+			args: []string{"-f", ".*", "-r", "{{split}}/{{f}}{{ext}}", "--split", "train=1", "--strict", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -0,0 +1,105 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tokenRegex matches anything that looks like a variable
+var tokenRegex = regexp.MustCompile(`{{[^{}]*}}`)
+
+var errUnknownVariable = errors.New("Unknown variable")
+
+// variableRegexes returns the patterns of all the supported variables
+func variableRegexes() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		filenameRegex,
+		extensionRegex,
+		parentDirRegex,
+		randomRegex,
+		hashRegex,
+		transformRegex,
+		ocrRegex,
+		groupRegex,
+		splitRegex,
+		sizeRegex,
+		csvRegex,
+		ageBucketRegex,
+		seqRegex,
+		xcmdRegex,
+		metaFileRegex,
+		segmentRegex,
+		segmentSepRegex,
+		id3Regex,
+		exifRegex,
+		dateRegex,
+		exiftoolRegex,
+	}
+}
+
+// isKnownVariable reports whether the token is a supported variable
+func isKnownVariable(token string) bool {
+	for _, r := range variableRegexes() {
+		if loc := r.FindStringIndex(token); loc != nil &&
+			loc[0] == 0 && loc[1] == len(token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkVariables ensures that every variable in the replacement string is
+// supported so that typos (such as {{mtme.YYYY}}) are not silently
+// included in the new file names. Conditional blocks, default values and
+// capture variables used as conditions are taken into account
+func checkVariables(replacement string) error {
+	var unknown []string
+
+	tokens := tokenRegex.FindAllString(escapeLiterals(replacement), -1)
+	for _, token := range tokens {
+		inner := strings.TrimSuffix(strings.TrimPrefix(token, "{{"), "}}")
+
+		switch {
+		case sliceRegex.MatchString(token):
+			inner = strings.TrimSpace(sliceRegex.FindStringSubmatch(token)[1])
+		case inner == "else" || inner == "end" || isArithmeticTemplate(token):
+			continue
+		case strings.HasPrefix(inner, "if "):
+			inner = strings.TrimSpace(strings.TrimPrefix(inner, "if "))
+			if strings.HasPrefix(inner, "$") {
+				continue
+			}
+		case strings.Contains(inner, "|"):
+			chain := inner[strings.Index(inner, "|"):]
+			inner = strings.TrimSpace(inner[:strings.Index(inner, "|")])
+
+			filters, _, _, err := parseFilterChain(chain)
+			if err != nil {
+				return err
+			}
+
+			// captured values can only be passed through filters
+			if strings.HasPrefix(inner, "$") && len(filters) > 0 {
+				continue
+			}
+		}
+
+		if !isKnownVariable("{{" + inner + "}}") {
+			unknown = append(unknown, token)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf(
+			"%w in replacement '%s': %s",
+			errUnknownVariable,
+			replacement,
+			strings.Join(unknown, ", "),
+		)
+	}
+
+	return nil
+}
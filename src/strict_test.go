@@ -0,0 +1,44 @@
+package f2
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckVariables(t *testing.T) {
+	valid := []string{
+		"{{f}}{{ext}}",
+		"{{p}}_%03d_{{mtime.YYYY}}-{{now.MM}}",
+		"{{exif.dt.YYYY}}_{{exif.make}}_{{x.iso}}_{{xt.ImageSize}}",
+		"{{id3.artist|Unknown}} - {{hash.sha256}} {{10r_ld}} {{tr.up}}",
+		"{{if exif.make}}{{exif.make}}{{else}}{{ocr.firstwords.3}}{{end}}",
+		"$1{{if $2}}-raw{{end}}",
+		"{{id3.title|trim|slug|Untitled}} {{$1|date:DD-MM-YYYY>YYYY-MM-DD}}",
+		"no variables",
+		`\{{mtme}} \$1`,
+	}
+
+	for _, v := range valid {
+		if err := checkVariables(v); err != nil {
+			t.Fatalf("Test (%s) — Unexpected error: %v", v, err)
+		}
+	}
+
+	invalid := []string{
+		"{{mtme.YYYY}}",
+		"{{f}}{{extt}}",
+		"{{if exif.mk}}a{{end}}",
+		"{{id3.artst|Unknown}}",
+		"{{$1|Unknown}}",
+	}
+
+	for _, v := range invalid {
+		if err := checkVariables(v); !errors.Is(err, errUnknownVariable) {
+			t.Fatalf("Test (%s) — Expected an unknown variable error", v)
+		}
+	}
+
+	if err := checkVariables("{{f|reverse:all}}"); err == nil {
+		t.Fatal("Expected an unknown filter error")
+	}
+}
@@ -0,0 +1,172 @@
+package f2
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	videoExtensions = []string{
+		".mkv", ".mp4", ".avi", ".mov", ".m4v", ".wmv",
+		".webm", ".mpg", ".mpeg", ".ts", ".flv",
+	}
+	subtitleExtensions = []string{
+		".srt", ".ass", ".ssa", ".sub", ".vtt", ".idx", ".smi",
+	}
+	// episodeRegex matches common episode markers such as S01E02 or 1x02
+	episodeRegex = regexp.MustCompile(
+		`(?i)s(\d{1,2})[ ._-]?e(\d{1,3})|(?:^|\D)(\d{1,2})x(\d{2,3})(?:\D|$)`,
+	)
+	// languageCodes are the ISO 639-1 codes and the ISO 639-2 codes of
+	// widely used languages that may follow the name of a subtitle file
+	languageCodes = strings.Fields(`
+		aa ab ae af ak am an ar as av ay az ba be bg bh bi bm bn bo br bs ca
+		ce ch co cr cs cu cv cy da de dv dz ee el en eo es et eu fa ff fi fj
+		fo fr fy ga gd gl gn gu gv ha he hi ho hr ht hu hy hz ia id ie ig ii
+		ik io is it iu ja jv ka kg ki kj kk kl km kn ko kr ks ku kv kw ky la
+		lb lg li ln lo lt lu lv mg mh mi mk ml mn mr ms mt my na nb nd ne ng
+		nl nn no nr nv ny oc oj om or os pa pi pl ps pt qu rm rn ro ru rw sa
+		sc sd se sg si sk sl sm sn so sq sr ss st su sv sw ta te tg th ti tk
+		tl tn to tr ts tt tw ty ug uk ur uz ve vi vo wa wo xh yi yo za zh zu
+		ara baq ben bul cat ces chi cze dan deu dut ell eng est eus fas fil
+		fin fra fre ger glg gre heb hin hrv hun ice ind isl ita jpn kor lav
+		lit may msa nld nor per pol por ron rum rus slk slo slv spa srp swe
+		tam tel tgl tha tur ukr urd vie zho
+	`)
+
+	// subtitleSuffixRegex matches up to two lowercase language or flag
+	// suffixes that precede the subtitle extension
+	// (e.g `.en`, `.pt-BR`, `.eng.forced`)
+	subtitleSuffixRegex = regexp.MustCompile(
+		`(?:\.(?:(?:` + strings.Join(languageCodes, "|") +
+			`)(?:[-_][A-Za-z]{2})?|forced|sdh|cc)){1,2}$`,
+	)
+	wordRegex = regexp.MustCompile(`[\pL\pN]+`)
+)
+
+// minSubtitleSimilarity is the minimum similarity between a subtitle and
+// video file name for them to be paired when no episode marker is present
+const minSubtitleSimilarity = 0.5
+
+// episodeKey returns a normalised season and episode identifier
+// for a file name or an empty string if none is found
+func episodeKey(name string) string {
+	match := episodeRegex.FindStringSubmatch(name)
+	if match == nil {
+		return ""
+	}
+
+	season, episode := match[1], match[2]
+	if season == "" {
+		season, episode = match[3], match[4]
+	}
+
+	s, _ := strconv.Atoi(season)
+	e, _ := strconv.Atoi(episode)
+
+	return strconv.Itoa(s) + "x" + strconv.Itoa(e)
+}
+
+// nameSimilarity returns a score between 0 and 1 indicating how many
+// words are shared between two file names
+func nameSimilarity(a, b string) float64 {
+	wordsA := wordRegex.FindAllString(strings.ToLower(a), -1)
+	wordsB := wordRegex.FindAllString(strings.ToLower(b), -1)
+
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool)
+	for _, w := range wordsA {
+		set[w] = true
+	}
+
+	var shared int
+	union := len(set)
+	seen := make(map[string]bool)
+	for _, w := range wordsB {
+		if seen[w] {
+			continue
+		}
+
+		seen[w] = true
+		if set[w] {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	return float64(shared) / float64(union)
+}
+
+// splitSubtitleName separates a subtitle file name into its base name and
+// the suffix (language codes and extension) that must be preserved
+func splitSubtitleName(name string) (base, suffix string) {
+	ext := filepath.Ext(name)
+	base = filenameWithoutExtension(name)
+	langSuffix := subtitleSuffixRegex.FindString(base)
+
+	// the language suffix cannot make up the entire name
+	if langSuffix == base {
+		langSuffix = ""
+	}
+
+	return strings.TrimSuffix(base, langSuffix), langSuffix + ext
+}
+
+// matchSubtitles pairs each matched subtitle file with a video file in the
+// same directory and renames the subtitle to match the video. Subtitles that
+// cannot be paired are left unchanged
+func (op *Operation) matchSubtitles() {
+	videos := make(map[string][]string)
+	for _, v := range op.paths {
+		ext := strings.ToLower(filepath.Ext(v.Source))
+		if !v.IsDir && contains(videoExtensions, ext) {
+			videos[v.BaseDir] = append(videos[v.BaseDir], v.Source)
+		}
+	}
+
+	var subtitles []Change
+	for _, ch := range op.matches {
+		ext := strings.ToLower(filepath.Ext(ch.Source))
+		if ch.IsDir || !contains(subtitleExtensions, ext) {
+			continue
+		}
+
+		base, suffix := splitSubtitleName(ch.Source)
+		ch.Target = ch.Source
+
+		key := episodeKey(base)
+
+		var best string
+		var bestScore float64
+		for _, video := range videos[ch.BaseDir] {
+			videoBase := filenameWithoutExtension(video)
+			if key != "" {
+				if episodeKey(videoBase) == key {
+					best = videoBase
+					break
+				}
+
+				continue
+			}
+
+			score := nameSimilarity(base, videoBase)
+			if score >= minSubtitleSimilarity && score > bestScore {
+				best, bestScore = videoBase, score
+			}
+		}
+
+		if best != "" {
+			ch.Target = best + suffix
+		}
+
+		subtitles = append(subtitles, ch)
+	}
+
+	op.matches = subtitles
+}
@@ -0,0 +1,73 @@
+package f2
+
+import "testing"
+
+func TestSplitSubtitleName(t *testing.T) {
+	cases := map[string][2]string{
+		"show.s01e02.en.srt":         {"show.s01e02", ".en.srt"},
+		"Movie (2020).pt-BR.srt":     {"Movie (2020)", ".pt-BR.srt"},
+		"Movie.eng.forced.ass":       {"Movie", ".eng.forced.ass"},
+		"The Big Day.srt":            {"The Big Day", ".srt"},
+		"en.srt":                     {"en", ".srt"},
+		"Show - 1x03 - Pilot.fr.vtt": {"Show - 1x03 - Pilot", ".fr.vtt"},
+		"Movie.2020.web.srt":         {"Movie.2020.web", ".srt"},
+		"Movie.2020.hdr.en.srt":      {"Movie.2020.hdr", ".en.srt"},
+		"movie.the.end.srt":          {"movie.the.end", ".srt"},
+	}
+
+	for input, want := range cases {
+		base, suffix := splitSubtitleName(input)
+		if base != want[0] || suffix != want[1] {
+			t.Fatalf(
+				"Test (%s) — Expected: %v, but got: [%s %s]",
+				input,
+				want,
+				base,
+				suffix,
+			)
+		}
+	}
+}
+
+func TestMatchSubtitles(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"Dark.S01E01.1080p.WEB.mkv",
+		"Dark.S01E02.1080p.WEB.mkv",
+		"Arrival (2016) 1080p.mp4",
+		"dark_1x01.en.srt",
+		"Dark - S01 E02.pt-BR.forced.srt",
+		"arrival 2016.eng.ass",
+		"unrelated.srt",
+	})
+
+	cases := []testCase{
+		{
+			name: "Rename subtitles to match their videos",
+			want: []Change{
+				{
+					Source:  "dark_1x01.en.srt",
+					BaseDir: testDir,
+					Target:  "Dark.S01E01.1080p.WEB.en.srt",
+				},
+				{
+					Source:  "Dark - S01 E02.pt-BR.forced.srt",
+					BaseDir: testDir,
+					Target:  "Dark.S01E02.1080p.WEB.pt-BR.forced.srt",
+				},
+				{
+					Source:  "arrival 2016.eng.ass",
+					BaseDir: testDir,
+					Target:  "Arrival (2016) 1080p.eng.ass",
+				},
+				{
+					Source:  "unrelated.srt",
+					BaseDir: testDir,
+					Target:  "unrelated.srt",
+				},
+			},
+			args: []string{"--match-subtitles", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
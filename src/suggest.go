@@ -0,0 +1,81 @@
+package f2
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// beginnerMetaChars are the regular expression metacharacters that are
+// commonly present in file names and mistaken for literal characters
+const beginnerMetaChars = "()[]{}+*?|^$"
+
+// hasMetaChars reports whether the pattern contains any
+// commonly misused regular expression metacharacters
+func hasMetaChars(pattern string) bool {
+	return strings.ContainsAny(pattern, beginnerMetaChars)
+}
+
+// escapeSuggestion returns an error for a find pattern that is not a valid
+// regular expression along with its literal interpretation
+func escapeSuggestion(pattern string, err error) error {
+	return fmt.Errorf(
+		"Invalid find pattern '%s': %w\nTo match it literally, use the %s or %s flag or escape it as '%s'",
+		pattern,
+		err,
+		printColor("yellow", "--string-mode"),
+		printColor("yellow", "--auto-escape"),
+		regexp.QuoteMeta(pattern),
+	)
+}
+
+// escapeUnmatched handles a find pattern that contains regular expression
+// metacharacters but does not match any files. If its literal
+// interpretation matches some files, the pattern is escaped when
+// --auto-escape is set. Otherwise, a suggestion is printed
+func (op *Operation) escapeUnmatched(ctx context.Context) error {
+	if len(op.matches) != 0 || len(op.findSlice) == 0 ||
+		op.stringLiteralMode || op.fuzzy > 0 {
+		return nil
+	}
+
+	pattern := op.findSlice[0]
+	if !hasMetaChars(pattern) {
+		return nil
+	}
+
+	literal, err := op.compileFind(regexp.QuoteMeta(pattern))
+	if err != nil {
+		return err
+	}
+
+	original := op.searchRegexes[0]
+	op.searchRegexes[0], op.searchRegex = literal, literal
+
+	err = op.findMatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(op.matches) == 0 || op.autoEscape {
+		return nil
+	}
+
+	if !op.quiet {
+		fmt.Fprintf(
+			os.Stderr,
+			"'%s' did not match any files as a regular expression but matches %d file(s) literally. Use the %s or %s flag to match it literally.\n",
+			pattern,
+			len(op.matches),
+			printColor("yellow", "--string-mode"),
+			printColor("yellow", "--auto-escape"),
+		)
+	}
+
+	op.matches = nil
+	op.searchRegexes[0], op.searchRegex = original, original
+
+	return nil
+}
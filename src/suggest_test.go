@@ -0,0 +1,54 @@
+package f2
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAutoEscape(t *testing.T) {
+	testDir := setupFiles(t, []string{"x(1).txt", "y+z.txt"})
+
+	cases := []testCase{
+		{
+			name: "Escape a pattern that matches nothing as a regex",
+			want: []Change{
+				{
+					Source:  "y+z.txt",
+					BaseDir: testDir,
+					Target:  "y-z.txt",
+				},
+			},
+			args: []string{"-f", "y+z", "-r", "y-z", "--auto-escape", testDir},
+		},
+		{
+			name: "Escape an invalid pattern",
+			want: []Change{
+				{
+					Source:  "x(1).txt",
+					BaseDir: testDir,
+					Target:  "x[1).txt",
+				},
+			},
+			args: []string{"-f", "x(1", "-r", "x[1", "--auto-escape", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+
+	result, err := action(
+		append(os.Args[0:1], "-f", "y+z", "-r", "y-z", testDir),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.changes) != 0 {
+		t.Fatalf("Expected no matches without --auto-escape: %v", result.changes)
+	}
+
+	_, err = action(append(os.Args[0:1], "-f", "x(1", "-r", "x", testDir))
+	if err == nil || !strings.Contains(err.Error(), `x\(1`) {
+		t.Fatalf("Expected an escaped suggestion, but got: %v", err)
+	}
+}
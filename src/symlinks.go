@@ -0,0 +1,163 @@
+package f2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// symlinkChange represents a symbolic link whose target was rewritten
+// after the path it pointed to was renamed
+type symlinkChange struct {
+	Path      string `json:"path"`
+	OldTarget string `json:"old_target"`
+	NewTarget string `json:"new_target"`
+}
+
+// symlink represents a symbolic link found in the scanned tree
+type symlink struct {
+	path   string
+	target string
+}
+
+// findSymlinks returns all the symbolic links present in the scanned paths
+func (op *Operation) findSymlinks() ([]symlink, error) {
+	var links []symlink
+	for _, v := range op.paths {
+		path, err := filepath.Abs(filepath.Join(v.BaseDir, v.Source))
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, symlink{path: path, target: target})
+	}
+
+	return links, nil
+}
+
+// renamedPath returns the location of a path after the successful renames
+// in the operation have been applied. Renames are applied in the order in
+// which they were performed so that nested directories are handled
+// correctly
+func (op *Operation) renamedPath(path string) string {
+	for _, ch := range op.matches {
+		if op.failed(ch) {
+			continue
+		}
+
+		source, err := filepath.Abs(filepath.Join(ch.BaseDir, ch.Source))
+		if err != nil {
+			continue
+		}
+
+		target, err := filepath.Abs(filepath.Join(ch.BaseDir, ch.Target))
+		if err != nil {
+			continue
+		}
+
+		if path == source {
+			path = target
+			continue
+		}
+
+		if strings.HasPrefix(path, source+string(filepath.Separator)) {
+			path = target + path[len(source):]
+		}
+	}
+
+	return path
+}
+
+// retargetSymlinks rewrites the symbolic links in the scanned tree that point
+// to a renamed path so that they continue to resolve. Relative links remain
+// relative. The rewritten links are recorded so that they can be restored
+// when the operation is reverted
+func (op *Operation) retargetSymlinks(links []symlink) error {
+	for _, link := range links {
+		resolved := link.target
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(filepath.Dir(link.path), resolved)
+		}
+
+		newResolved := op.renamedPath(resolved)
+		if newResolved == resolved {
+			continue
+		}
+
+		newPath := op.renamedPath(link.path)
+
+		newTarget := newResolved
+		if !filepath.IsAbs(link.target) {
+			rel, err := filepath.Rel(filepath.Dir(newPath), newResolved)
+			if err != nil {
+				return err
+			}
+
+			newTarget = rel
+		}
+
+		err := replaceSymlink(newPath, newTarget)
+		if err != nil {
+			return err
+		}
+
+		op.symlinks = append(op.symlinks, symlinkChange{
+			Path:      newPath,
+			OldTarget: link.target,
+			NewTarget: newTarget,
+		})
+	}
+
+	return nil
+}
+
+// replaceSymlink points the symbolic link at path to a new target. The new
+// link is created under a temporary name and renamed over the old one so
+// that the path always resolves to either the old or the new target
+func replaceSymlink(path, target string) error {
+	for n := 0; n < maxTempAttempts; n++ {
+		tmp := tempName(path)
+
+		err := os.Symlink(target, tmp)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(tmp, path)
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+
+		return err
+	}
+
+	return errTempName
+}
+
+// restoreSymlinks points the recorded symbolic links back to
+// their original targets
+func restoreSymlinks(links []symlinkChange) error {
+	for _, v := range links {
+		err := replaceSymlink(v.Path, v.OldTarget)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+// +build !windows
+
+package f2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRetargetSymlinks(t *testing.T) {
+	testDir := setupFileSystem(t)
+
+	music := filepath.Join(testDir, "music")
+	err := os.Mkdir(music, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(music, "a.mp3"), []byte{}, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relLink := filepath.Join(testDir, "scripts", "fav.mp3")
+	err = os.Symlink(filepath.Join("..", "music", "a.mp3"), relLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	absLink := filepath.Join(testDir, "scripts", "library")
+	err = os.Symlink(music, absLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args[0:1]
+	args = append(
+		args,
+		"-f",
+		"^music$",
+		"-r",
+		"audio",
+		"-d",
+		"-R",
+		"--retarget-symlinks",
+		"-x",
+		testDir,
+	)
+
+	result, err := action(args)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.applyError != nil {
+		t.Fatalf("Unexpected apply error: %v", result.applyError)
+	}
+
+	want := map[string]string{
+		relLink: filepath.Join("..", "audio", "a.mp3"),
+		absLink: filepath.Join(testDir, "audio"),
+	}
+
+	for link, target := range want {
+		got, err := os.Readlink(link)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if got != target {
+			t.Fatalf("Expected %s to point to %s, but got: %s", link, target, got)
+		}
+
+		if _, err := os.Stat(link); err != nil {
+			t.Fatalf("Expected %s to resolve, but got: %v", link, err)
+		}
+	}
+
+	args = os.Args[0:1]
+	args = append(args, "-u", "-x")
+	_, err = action(args)
+	if err != nil {
+		t.Fatalf("Unexpected error in undo mode: %v", err)
+	}
+
+	want = map[string]string{
+		relLink: filepath.Join("..", "music", "a.mp3"),
+		absLink: music,
+	}
+
+	for link, target := range want {
+		got, err := os.Readlink(link)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if got != target {
+			t.Fatalf("Expected %s to point to %s after undo, but got: %s", link, target, got)
+		}
+	}
+}
+
+func TestReplaceSymlink(t *testing.T) {
+	testDir := t.TempDir()
+
+	link := filepath.Join(testDir, "link")
+	err := os.Symlink("old", link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = replaceSymlink(link, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.Readlink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "new" {
+		t.Fatalf("Expected the link to point to new, but got: %s", got)
+	}
+
+	entries, err := os.ReadDir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected no temporary links to be left: %v", entries)
+	}
+}
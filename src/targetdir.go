@@ -0,0 +1,123 @@
+package f2
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	structurePreserve = "preserve"
+	structureFlatten  = "flatten"
+)
+
+var errInvalidTargetStructure = errors.New(
+	"Invalid argument: --target-structure must be one of 'preserve' or 'flatten'",
+)
+
+// searchRoot returns the searched directory that contains the
+// specified base directory
+func (op *Operation) searchRoot(baseDir string) string {
+	roots := op.directories
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	var root string
+	for _, v := range roots {
+		rel, err := filepath.Rel(v, baseDir)
+		if err != nil || rel == ".." ||
+			strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		// prefer the innermost root
+		if len(v) > len(root) {
+			root = v
+		}
+	}
+
+	if root == "" {
+		return baseDir
+	}
+
+	return root
+}
+
+// uniquePath returns the specified path or a numbered variant of it
+// if the path is already taken by another file. Taken paths are
+// recorded in the map
+func uniquePath(fsys FS, path, source string, m map[string][]struct {
+	source string
+	index  int
+}) string {
+	_, taken := m[path]
+	if _, err := fsys.Stat(path); err == nil && path != source {
+		taken = true
+	}
+
+	if taken {
+		dir := filepath.Dir(path)
+		path = filepath.Join(dir, getNewPath(fsys, path, dir, m))
+	}
+
+	m[path] = nil
+
+	return path
+}
+
+// relocate moves the target of each match into the target directory or
+// the searched directory when flattening. The directory structure
+// relative to the searched directories is preserved unless the flatten
+// structure is selected
+func (op *Operation) relocate() error {
+	m := make(map[string][]struct {
+		source string
+		index  int
+	})
+
+	flatten := op.flatten || op.targetStructure == structureFlatten
+
+	for i, ch := range op.matches {
+		target := ch.Target
+		if target == "" {
+			target = ch.Source
+		}
+
+		root := op.searchRoot(ch.BaseDir)
+
+		dir := op.targetDir
+		if dir == "" {
+			dir = root
+		}
+
+		if !flatten {
+			rel, err := filepath.Rel(root, ch.BaseDir)
+			if err != nil {
+				return err
+			}
+
+			dir = filepath.Join(dir, rel)
+		}
+
+		path := filepath.Join(dir, target)
+		if op.flatten {
+			path = uniquePath(
+				op.filesystem(),
+				path,
+				filepath.Join(ch.BaseDir, ch.Source),
+				m,
+			)
+		}
+
+		// targets are relative to the directory of the source file
+		target, err := filepath.Rel(ch.BaseDir, path)
+		if err != nil {
+			return err
+		}
+
+		op.matches[i].Target = target
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package f2
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTargetDir(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.pdf", "c.txt", "sub/b.pdf"})
+
+	subDir := filepath.Join(testDir, "sub")
+	outDir := filepath.Join(testDir, "out")
+
+	cases := []testCase{
+		{
+			name: "Preserve the directory structure",
+			want: []Change{
+				{
+					Source:  "a.pdf",
+					BaseDir: testDir,
+					Target:  filepath.Join("out", "a.PDF"),
+				},
+				{
+					Source:  "b.pdf",
+					BaseDir: subDir,
+					Target:  filepath.Join("..", "out", "sub", "b.PDF"),
+				},
+			},
+			args: []string{
+				"-f",
+				"pdf",
+				"-r",
+				"PDF",
+				"-R",
+				"--target-dir",
+				outDir,
+				testDir,
+			},
+		},
+		{
+			name: "Flatten the directory structure",
+			want: []Change{
+				{
+					Source:  "a.pdf",
+					BaseDir: testDir,
+					Target:  filepath.Join("out", "a.pdf"),
+				},
+				{
+					Source:  "b.pdf",
+					BaseDir: subDir,
+					Target:  filepath.Join("..", "out", "b.pdf"),
+				},
+			},
+			args: []string{
+				"-f",
+				"pdf$",
+				"-R",
+				"--target-dir",
+				outDir,
+				"--target-structure",
+				"flatten",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestFlatten(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_1.jpg",
+		"2020/IMG_1.jpg",
+		"2021/IMG_1.jpg",
+	})
+
+	cases := []testCase{
+		{
+			name: "Number files with the same name",
+			want: []Change{
+				{
+					Source:  "IMG_1.jpg",
+					BaseDir: testDir,
+					Target:  "IMG_1.jpg",
+				},
+				{
+					Source:  "IMG_1.jpg",
+					BaseDir: filepath.Join(testDir, "2020"),
+					Target:  filepath.Join("..", "IMG_1 (2).jpg"),
+				},
+				{
+					Source:  "IMG_1.jpg",
+					BaseDir: filepath.Join(testDir, "2021"),
+					Target:  filepath.Join("..", "IMG_1 (3).jpg"),
+				},
+			},
+			args: []string{"-f", "jpg$", "-R", "--flatten", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
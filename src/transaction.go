@@ -0,0 +1,273 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	scopeOperation = "operation"
+	scopeDir       = "dir"
+)
+
+var (
+	errTransactionRolledBack = fmt.Errorf(
+		"The renaming operation failed due to the above errors. All changes have been reverted",
+	)
+
+	errInvalidTransactionScope = errors.New(
+		"Invalid argument: --transaction-scope must be set to 'operation' or 'dir'",
+	)
+)
+
+// missingDir returns the outermost directory in the path that does not
+// exist yet so that it can be removed if the operation is rolled back.
+// An empty string is returned if the directory exists
+func missingDir(fsys FS, dir string) string {
+	var missing string
+	for {
+		_, err := fsys.Stat(dir)
+		if err == nil || !os.IsNotExist(err) {
+			return missing
+		}
+
+		missing = dir
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return missing
+		}
+
+		dir = parent
+	}
+}
+
+// rollback reverts the successful renames and removes the directories
+// created during the operation so that the filesystem is left exactly as
+// before. If a rename cannot be reverted, op.matches is left with the
+// renames that are still applied
+func (op *Operation) rollback() error {
+	remaining, err := op.revertRenames(op.matches)
+
+	// the directories that still contain renamed files are not empty
+	op.removeCreatedDirs(op.matches)
+
+	if err != nil {
+		op.matches = remaining
+		return err
+	}
+
+	op.rolledBack = true
+
+	return nil
+}
+
+// revertRenames reverts the successful renames among the changes. The
+// reverse renames are performed by a renamer so that the files in a chain
+// or cycle are moved through a temporary name instead of overwriting each
+// other. The renames that could not be reverted are returned along with
+// the first error
+func (op *Operation) revertRenames(changes []Change) ([]Change, error) {
+	var applied []Change
+
+	for i := len(changes) - 1; i >= 0; i-- {
+		if !op.failed(changes[i]) {
+			applied = append(applied, changes[i])
+		}
+	}
+
+	reverse := &Operation{
+		fs:         op.fs,
+		linkMode:   op.unlinkMode,
+		unlinkMode: op.linkMode,
+		matches:    make([]Change, len(applied)),
+	}
+
+	for i, ch := range applied {
+		ch.Source, ch.Target = ch.Target, ch.Source
+		reverse.matches[i] = ch
+	}
+
+	r := newRenamer(reverse)
+	for i := range reverse.matches {
+		r.visit(i)
+	}
+
+	if len(r.errs) == 0 {
+		return nil, nil
+	}
+
+	var remaining []Change
+
+	for i, ch := range applied {
+		if r.failed[i] {
+			remaining = append(remaining, ch)
+		}
+	}
+
+	v := r.errs[0]
+
+	return remaining, fmt.Errorf(
+		"Unable to roll back '%s' to '%s': %w",
+		filepath.Join(v.entry.BaseDir, v.entry.Source),
+		filepath.Join(v.entry.BaseDir, v.entry.Target),
+		v.err,
+	)
+}
+
+// removeCreatedDirs removes the directories created for the targets
+// of the changes. Only empty directories are removed so that files
+// created by other programs in the meantime are never deleted
+func (op *Operation) removeCreatedDirs(changes []Change) {
+	for _, ch := range changes {
+		dir := filepath.Dir(filepath.Join(ch.BaseDir, ch.Target))
+		for op.createdDir(dir) {
+			if op.filesystem().Remove(dir) != nil {
+				break
+			}
+
+			dir = filepath.Dir(dir)
+		}
+	}
+}
+
+// changeDir returns the directory of the source path of the change.
+// Changes are grouped by this directory when transactions are scoped
+// to each directory
+func changeDir(ch Change) string {
+	return filepath.Dir(filepath.Join(ch.BaseDir, ch.Source))
+}
+
+// createdDir reports whether the directory was created during the operation
+func (op *Operation) createdDir(dir string) bool {
+	for _, v := range op.createdDirs {
+		if dir == v || strings.HasPrefix(dir, v+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rollbackDirs reverts the successful renames in each directory where a
+// rename failed while the renames in other directories are kept. The
+// directories created for the reverted renames are removed if they are
+// left empty
+func (op *Operation) rollbackDirs() error {
+	failedDirs := make(map[string]bool)
+	for _, v := range op.errors {
+		failedDirs[changeDir(v.entry)] = true
+	}
+
+	var kept, reverted []Change
+	for _, ch := range op.matches {
+		if !op.failed(ch) && failedDirs[changeDir(ch)] {
+			reverted = append(reverted, ch)
+			continue
+		}
+
+		kept = append(kept, ch)
+	}
+
+	remaining, err := op.revertRenames(reverted)
+
+	op.removeCreatedDirs(reverted)
+
+	if err != nil {
+		op.matches = append(kept, remaining...)
+		return err
+	}
+
+	op.matches = kept
+	op.rolledBackChanges = reverted
+
+	return nil
+}
+
+// rollbackFailed records the renames that are still applied after the
+// rollback failed so that they can be reverted with f2 -u
+func (op *Operation) rollbackFailed(err error) error {
+	if len(op.matches) > 0 {
+		berr := op.backup()
+		if berr != nil {
+			return fmt.Errorf(
+				"%w. The remaining changes could not be recorded: %v",
+				err,
+				berr,
+			)
+		}
+	}
+
+	return fmt.Errorf(
+		"%w. Run %s to revert the remaining changes",
+		err,
+		printColor("yellow", "f2 -u"),
+	)
+}
+
+// abortTransaction rolls back the operation after one or more renames
+// failed in transactional mode and reports the errors
+func (op *Operation) abortTransaction() error {
+	err := op.rollback()
+	if err != nil {
+		if !op.quiet {
+			op.reportErrors()
+		}
+
+		return op.rollbackFailed(err)
+	}
+
+	for _, v := range op.errors {
+		for j := len(op.matches) - 1; j >= 0; j-- {
+			if v.entry.Target == op.matches[j].Target {
+				op.matches = append(op.matches[:j], op.matches[j+1:]...)
+			}
+		}
+	}
+
+	if !op.quiet {
+		op.reportErrors()
+	}
+
+	return errTransactionRolledBack
+}
+
+// interrupted records the renames that were applied before the operation
+// was cancelled so that they can be reverted. The renames are rolled back
+// in transactional mode
+func (op *Operation) interrupted(cause error) error {
+	if op.transactional {
+		err := op.rollback()
+		if err != nil {
+			return op.rollbackFailed(err)
+		}
+
+		return fmt.Errorf(
+			"The operation was interrupted and all changes have been reverted: %w",
+			cause,
+		)
+	}
+
+	if len(op.errors) > 0 && !op.quiet {
+		op.reportErrors()
+	}
+
+	if len(op.matches) > 0 && !op.revert {
+		err := op.backup()
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf(
+			"The operation was interrupted after renaming %d file(s). To revert the changes, run: %s: %w",
+			len(op.matches),
+			printColor("yellow", "f2 -u"),
+			cause,
+		)
+	}
+
+	return fmt.Errorf("The operation was interrupted: %w", cause)
+}
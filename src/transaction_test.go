@@ -0,0 +1,273 @@
+package f2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransactionalRollback(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	op := &Operation{
+		exec:          true,
+		quiet:         true,
+		transactional: true,
+		matches: []Change{
+			{Source: "a.txt", BaseDir: testDir, Target: "new/nested/a.txt"},
+			{Source: "b.txt", BaseDir: testDir, Target: "b2.txt"},
+			{Source: "missing.txt", BaseDir: testDir, Target: "c.txt"},
+		},
+	}
+
+	err := op.apply(context.Background())
+	if err != errTransactionRolledBack {
+		t.Fatalf("Expected error %v, but got: %v", errTransactionRolledBack, err)
+	}
+
+	for _, v := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); err != nil {
+			t.Fatalf("Expected %s to be restored: %v", v, err)
+		}
+	}
+
+	for _, v := range []string{"new", "b2.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); !os.IsNotExist(err) {
+			t.Fatalf("Expected %s to be removed: %v", v, err)
+		}
+	}
+
+	if len(op.matches) != 2 {
+		t.Fatalf("Expected the failed change to be removed: %v", op.matches)
+	}
+}
+
+func TestTransactionalRollbackCycle(t *testing.T) {
+	testDir := setupFiles(t, nil)
+
+	contents := map[string]string{"a": "A", "b": "B", "c": "C", "x": "X"}
+	for name, content := range contents {
+		err := os.WriteFile(filepath.Join(testDir, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	op := &Operation{
+		exec:          true,
+		quiet:         true,
+		transactional: true,
+		matches: []Change{
+			{Source: "a", BaseDir: testDir, Target: "b"},
+			{Source: "b", BaseDir: testDir, Target: "a"},
+			{Source: "c", BaseDir: testDir, Target: "x/y"},
+		},
+	}
+
+	err := op.apply(context.Background())
+	if err != errTransactionRolledBack {
+		t.Fatalf("Expected error %v, but got: %v", errTransactionRolledBack, err)
+	}
+
+	for name, content := range contents {
+		b, err := os.ReadFile(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatalf("Expected %s to be restored: %v", name, err)
+		}
+
+		if string(b) != content {
+			t.Fatalf("Expected %s to contain %q, but got: %q", name, content, b)
+		}
+	}
+
+	entries, err := os.ReadDir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(contents) {
+		t.Fatalf("Expected no temporary files to be left: %v", entries)
+	}
+}
+
+// failingRenameFS fails to rename files to the specified path
+type failingRenameFS struct {
+	osFS
+	path string
+}
+
+func (f failingRenameFS) Rename(oldpath, newpath string) error {
+	if newpath == f.path {
+		return os.ErrPermission
+	}
+
+	return f.osFS.Rename(oldpath, newpath)
+}
+
+func TestFailedRollback(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	op := &Operation{
+		exec:          true,
+		quiet:         true,
+		transactional: true,
+		workingDir:    testDir,
+		fs:            failingRenameFS{path: filepath.Join(testDir, "b.txt")},
+		matches: []Change{
+			{Source: "a.txt", BaseDir: testDir, Target: "new/a.txt"},
+			{Source: "b.txt", BaseDir: testDir, Target: "b2.txt"},
+			{Source: "missing.txt", BaseDir: testDir, Target: "c.txt"},
+		},
+	}
+
+	err := op.apply(context.Background())
+	if err == nil || errors.Is(err, errTransactionRolledBack) {
+		t.Fatalf("Expected the rollback to fail, but got: %v", err)
+	}
+
+	path, err := backupPath(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.Remove(path)
+	})
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the remaining changes to be backed up: %v", err)
+	}
+
+	var bf backupFile
+
+	err = json.Unmarshal(b, &bf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bf.Operations) != 1 || bf.Operations[0].Source != "b.txt" {
+		t.Fatalf("Expected only the rename of b.txt to be backed up: %v", bf.Operations)
+	}
+
+	for _, v := range []string{"a.txt", "b2.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); err != nil {
+			t.Fatalf("Expected %s to exist: %v", v, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "new")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the created directory to be removed: %v", err)
+	}
+}
+
+func TestDirTransactionRollback(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt", "b.txt"})
+
+	other := filepath.Join(testDir, "other")
+
+	err := os.Mkdir(other, 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(other, "c.txt"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := &Operation{
+		exec:             true,
+		quiet:            true,
+		transactional:    true,
+		transactionScope: scopeDir,
+		workingDir:       testDir,
+		matches: []Change{
+			{Source: "a.txt", BaseDir: testDir, Target: "new/nested/a.txt"},
+			{Source: "c.txt", BaseDir: other, Target: "c2.txt"},
+			{Source: "b.txt", BaseDir: testDir, Target: "b2.txt"},
+			{Source: "missing.txt", BaseDir: testDir, Target: "d.txt"},
+		},
+	}
+
+	err = op.apply(context.Background())
+	if err == nil || err == errTransactionRolledBack {
+		t.Fatalf("Expected the failure to be reported, but got: %v", err)
+	}
+
+	for _, v := range []string{"a.txt", "b.txt", "other/c2.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); err != nil {
+			t.Fatalf("Expected %s to exist: %v", v, err)
+		}
+	}
+
+	for _, v := range []string{"new", "b2.txt", "other/c.txt"} {
+		if _, err := os.Stat(filepath.Join(testDir, v)); !os.IsNotExist(err) {
+			t.Fatalf("Expected %s not to exist: %v", v, err)
+		}
+	}
+
+	if len(op.matches) != 1 || op.matches[0].Source != "c.txt" {
+		t.Fatalf("Expected only the rename in the other directory to be kept: %v", op.matches)
+	}
+
+	if len(op.rolledBackChanges) != 2 {
+		t.Fatalf("Expected two renames to be rolled back: %v", op.rolledBackChanges)
+	}
+}
+
+func TestInterruptedRename(t *testing.T) {
+	for _, transactional := range []bool{false, true} {
+		testDir := setupFiles(t, []string{"a.txt", "b.txt", "c.txt"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		op := &Operation{
+			exec:          true,
+			quiet:         true,
+			transactional: transactional,
+			workingDir:    testDir,
+			matches: []Change{
+				{Source: "a.txt", BaseDir: testDir, Target: "a.md"},
+				{Source: "b.txt", BaseDir: testDir, Target: "b.md"},
+				{Source: "c.txt", BaseDir: testDir, Target: "c.md"},
+			},
+			events: Events{
+				OnRename: func(ch Change) {
+					cancel()
+				},
+			},
+		}
+
+		err := op.apply(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected error %v, but got: %v", context.Canceled, err)
+		}
+
+		want := map[string]bool{"a.md": true, "b.txt": true, "c.txt": true}
+		if transactional {
+			want = map[string]bool{"a.txt": true, "b.txt": true, "c.txt": true}
+		}
+
+		for v := range want {
+			if _, err := os.Stat(filepath.Join(testDir, v)); err != nil {
+				t.Fatalf("Expected %s to exist: %v", v, err)
+			}
+		}
+
+		path, err := backupPath(testDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = os.Stat(path)
+		if transactional != os.IsNotExist(err) {
+			t.Fatalf("Unexpected backup file state: %v", err)
+		}
+
+		os.Remove(path)
+	}
+}
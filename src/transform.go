@@ -0,0 +1,236 @@
+package f2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptTimeout bounds each call to the transform function
+// so that a script that never returns does not hang f2
+const scriptTimeout = 10 * time.Second
+
+var (
+	errScriptWithReplace = errors.New(
+		"Invalid argument: --script cannot be combined with -r, --expr, --rules, --segments or --csv",
+	)
+
+	errNoTransform = errors.New(
+		"The script does not define a 'transform' function",
+	)
+)
+
+// scriptLibs are the Lua libraries available to scripts. The io, package,
+// debug and coroutine libraries are left out since a script only needs to
+// compute names
+var scriptLibs = []struct {
+	name string
+	fn   lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+	{lua.OsLibName, lua.OpenOs},
+}
+
+// scriptOSFuncs are the functions of the os library that are kept
+var scriptOSFuncs = []string{"clock", "date", "difftime", "time"}
+
+// transformScript is a Lua script that computes the new name
+// of each file with its `transform(name, meta)` function
+type transformScript struct {
+	path  string
+	state *lua.LState
+	fn    *lua.LFunction
+}
+
+// newScriptState returns a Lua state with the libraries available to
+// scripts. The output of print is written to the standard error so that
+// it does not mix with the planned changes
+func newScriptState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range scriptLibs {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	osLib := L.NewTable()
+	for _, name := range scriptOSFuncs {
+		osLib.RawSetString(name, L.GetField(L.GetGlobal(lua.OsLibName), name))
+	}
+
+	L.SetGlobal(lua.OsLibName, osLib)
+
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		args := make([]string, L.GetTop())
+		for i := range args {
+			args[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+
+		fmt.Fprintln(os.Stderr, strings.Join(args, "\t"))
+
+		return 0
+	}))
+
+	return L
+}
+
+// scriptError drops the stack traceback from the errors raised by a script
+// since the message already includes the file name and line number
+func scriptError(err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) && apiErr.Object != nil {
+		return errors.New(apiErr.Object.String())
+	}
+
+	return err
+}
+
+// loadTransformScript runs the script at the specified path so that
+// its transform function can be called for each file
+func loadTransformScript(path string) (*transformScript, error) {
+	L := newScriptState()
+
+	err := L.DoFile(path)
+	if err != nil {
+		L.Close()
+		return nil, scriptError(err)
+	}
+
+	fn, ok := L.GetGlobal("transform").(*lua.LFunction)
+	if !ok {
+		L.Close()
+		return nil, fmt.Errorf("%s: %w", path, errNoTransform)
+	}
+
+	return &transformScript{path: path, state: L, fn: fn}, nil
+}
+
+// transformMeta returns the table passed to the transform
+// function along with the name of the file
+func (op *Operation) transformMeta(ch Change, index int) (*lua.LTable, error) {
+	name := filepath.Base(ch.Source)
+
+	parentDir := filepath.Base(ch.BaseDir)
+	if parentDir == "." {
+		parentDir = filepath.Base(op.workingDir)
+	}
+
+	sourcePath := filepath.Join(ch.BaseDir, ch.Source)
+
+	info, err := op.filesystem().Stat(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	L := op.transform.state
+
+	meta := L.NewTable()
+	meta.RawSetString("name", lua.LString(name))
+	meta.RawSetString("stem", lua.LString(filenameWithoutExtension(name)))
+	meta.RawSetString("ext", lua.LString(filepath.Ext(name)))
+	meta.RawSetString("dir", lua.LString(parentDir))
+	meta.RawSetString("path", lua.LString(sourcePath))
+	meta.RawSetString("index", lua.LNumber(index))
+	meta.RawSetString("is_dir", lua.LBool(ch.IsDir))
+	meta.RawSetString("size", lua.LNumber(info.Size()))
+	meta.RawSetString("mtime", lua.LNumber(info.ModTime().Unix()))
+
+	return meta, nil
+}
+
+// callTransform returns the value returned by the transform function
+// for the specified file
+func (op *Operation) callTransform(
+	ctx context.Context,
+	name string,
+	meta *lua.LTable,
+) (lua.LValue, error) {
+	L := op.transform.state
+
+	ctx, cancel := context.WithTimeout(ctx, scriptTimeout)
+	defer cancel()
+
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	err := L.CallByParam(lua.P{
+		Fn:      op.transform.fn,
+		NRet:    1,
+		Protect: true,
+	}, lua.LString(name), meta)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+
+	v := L.Get(-1)
+	L.Pop(1)
+
+	return v, nil
+}
+
+// transformTargets sets the target of each match to the name returned by
+// the transform function of the script. Files for which the function
+// returns nil or false are left unchanged
+func (op *Operation) transformTargets(ctx context.Context) error {
+	for i, ch := range op.matches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		meta, err := op.transformMeta(ch, i+1)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Base(ch.Source)
+
+		v, err := op.callTransform(ctx, name, meta)
+		if err != nil {
+			return fmt.Errorf("transform(%q): %w", name, err)
+		}
+
+		ch.Target = ch.Source
+
+		switch v.Type() {
+		case lua.LTNil:
+		case lua.LTBool:
+			if v == lua.LTrue {
+				return fmt.Errorf(
+					"%s: transform(%q) returned true instead of a string",
+					op.transform.path,
+					name,
+				)
+			}
+		case lua.LTString, lua.LTNumber:
+			ch.Target = v.String()
+			if dir := filepath.Dir(ch.Source); dir != "." {
+				ch.Target = filepath.Join(dir, v.String())
+			}
+		default:
+			return fmt.Errorf(
+				"%s: transform(%q) returned a %s instead of a string",
+				op.transform.path,
+				name,
+				v.Type(),
+			)
+		}
+
+		op.matches[i] = ch
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package f2
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeScript(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "rename.lua")
+
+	err := ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestTransformScript(t *testing.T) {
+	testDir := setupFiles(t, []string{
+		"IMG_0012.JPG",
+		"holiday photo 3.png",
+		"notes.txt",
+	})
+
+	script := writeScript(t, `
+local function slug(s)
+  s = s:lower():gsub("[^%w]+", "-")
+  return (s:gsub("^%-+", ""):gsub("%-+$", ""))
+end
+
+function transform(name, meta)
+  if meta.ext == ".txt" then
+    return nil
+  end
+
+  local n = name:match("%d+")
+  return string.format(
+    "%s-%04d-%d-%s%s",
+    slug(meta.stem:gsub("%d+", "")),
+    tonumber(n),
+    meta.index,
+    meta.dir,
+    meta.ext:lower()
+  )
+end
+`)
+
+	dir := filepath.Base(testDir)
+
+	cases := []testCase{
+		{
+			name: "Compute the new names with the script",
+			want: []Change{
+				{
+					Source:  "IMG_0012.JPG",
+					BaseDir: testDir,
+					Target:  "img-0012-2-" + dir + ".jpg",
+				},
+				{
+					Source:  "holiday photo 3.png",
+					BaseDir: testDir,
+					Target:  "holiday-photo-0003-1-" + dir + ".png",
+				},
+				{
+					Source:  "notes.txt",
+					BaseDir: testDir,
+					Target:  "notes.txt",
+				},
+			},
+			args: []string{"--script", script, testDir},
+		},
+		{
+			name: "Only transform the files matched by the find pattern",
+			want: []Change{
+				{
+					Source:  "holiday photo 3.png",
+					BaseDir: testDir,
+					Target:  "holiday-photo-0003-1-" + dir + ".png",
+				},
+			},
+			args: []string{"--script", script, "-f", "holiday", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestTransformScriptErrors(t *testing.T) {
+	testDir := setupFiles(t, []string{"a.txt"})
+
+	cases := []struct {
+		script string
+		args   []string
+		want   error
+		msg    string
+	}{
+		{
+			script: "function transform(name) return name end",
+			args:   []string{"-r", "b"},
+			want:   errScriptWithReplace,
+		},
+		{
+			script: "local x = 1",
+			want:   errNoTransform,
+		},
+		{
+			script: "function transform(name) return os.remove(name) end",
+			msg:    "attempt to call a non-function object",
+		},
+		{
+			script: "function transform(name) return {} end",
+			msg:    `transform("a.txt") returned a table instead of a string`,
+		},
+		{
+			script: "function transform(name, meta) return meta.missing.x end",
+			msg:    "rename.lua:1: attempt to index a non-table object(nil) with key 'x'",
+		},
+	}
+
+	for _, tc := range cases {
+		script := writeScript(t, tc.script)
+
+		args := append([]string{os.Args[0], "--script", script}, tc.args...)
+		args = append(args, testDir)
+
+		result, err := action(args)
+		if err == nil {
+			err = result.applyError
+		}
+
+		if err == nil {
+			t.Fatalf("%q: expected an error", tc.script)
+		}
+
+		if tc.want != nil && !errors.Is(err, tc.want) {
+			t.Fatalf("%q: expected error %v, but got: %v", tc.script, tc.want, err)
+		}
+
+		if !strings.Contains(err.Error(), tc.msg) {
+			t.Fatalf("%q: expected error %q, but got: %v", tc.script, tc.msg, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package f2
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// translitTable maps lower case characters that do not decompose into
+// ASCII letters to their ASCII approximations
+var translitTable = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d",
+	'þ': "th", 'ł': "l", 'ı': "i", 'ŀ': "l", 'ħ': "h", 'ŋ': "ng",
+	'ſ': "s", 'ĸ': "k",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e",
+	'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k",
+	'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye",
+	'ґ': "g", 'ў': "u", 'ђ': "dj", 'ј': "j", 'љ': "lj", 'њ': "nj",
+	'ћ': "c", 'џ': "dz",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z",
+	'η': "i", 'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m",
+	'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+	// punctuation
+	'‘': "'", '’': "'", '‚': "'", '“': "\"", '”': "\"", '„': "\"",
+	'«': "<<", '»': ">>", '‹': "<", '›': ">", '–': "-", '—': "-",
+	'‐': "-", '−': "-", '…': "...", '•': "-", '×': "x", '©': "(c)",
+	'®': "(r)", '™': "tm", '€': "EUR", '£': "GBP", '¥': "JPY",
+}
+
+// translitRune returns the ASCII approximation of a character or false
+// if it has none
+func translitRune(r rune) (string, bool) {
+	switch {
+	case r <= unicode.MaxASCII:
+		return string(r), true
+	case unicode.Is(unicode.Mn, r):
+		return "", true
+	case unicode.IsSpace(r):
+		return " ", true
+	}
+
+	lower := unicode.ToLower(r)
+	s, ok := translitTable[lower]
+	if ok && r != lower && s != "" {
+		s = strings.ToUpper(s[:1]) + s[1:]
+	}
+
+	return s, ok
+}
+
+// transliterate converts the string to ASCII. Accented letters lose their
+// diacritics (é becomes e), other letters are replaced with their common
+// romanisation (ß becomes ss and ж becomes zh) and characters without an
+// ASCII approximation are replaced with an underscore
+func transliterate(str string) string {
+	var b strings.Builder
+
+	for _, r := range str {
+		// characters such as й are looked up before they are decomposed
+		// since their romanisation differs from that of the base letter
+		if s, ok := translitRune(r); ok {
+			b.WriteString(s)
+			continue
+		}
+
+		var ascii string
+		for _, v := range norm.NFKD.String(string(r)) {
+			if s, ok := translitRune(v); ok {
+				ascii += s
+			}
+		}
+
+		if ascii == "" {
+			ascii = "_"
+		}
+
+		b.WriteString(ascii)
+	}
+
+	return b.String()
+}
+
+// transliterateTargets converts the new file names to ASCII
+func (op *Operation) transliterateTargets() {
+	for i, ch := range op.matches {
+		op.matches[i].Target = transliterate(ch.Target)
+	}
+}
@@ -0,0 +1,79 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"Café Noël", "Cafe Noel"},
+		{"Straße", "Strasse"},
+		{"Über größe", "Uber grosse"},
+		{"Жуков", "Zhukov"},
+		{"щука", "shchuka"},
+		{"Αθήνα", "Athina"},
+		{"Łódź", "Lodz"},
+		{"“quoted” — ok…", "\"quoted\" - ok..."},
+		{"ﬁle²", "file2"},
+		{"日本", "__"},
+	}
+
+	for _, tc := range cases {
+		got := transliterate(tc.input)
+		if got != tc.want {
+			t.Fatalf("Expected %q to be transliterated to %q, but got: %q", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestTransliterateTargets(t *testing.T) {
+	testDir := setupFiles(t, []string{"Straße.txt", "plain.txt"})
+
+	cases := []testCase{
+		{
+			name: "Transliterate the new file names",
+			want: []Change{
+				{
+					Source:  "Straße.txt",
+					BaseDir: testDir,
+					Target:  "Strasse-Жуков.txt",
+				},
+			},
+			args: []string{"-f", "Straße", "-r", "Strasse-Жуков", testDir},
+		},
+		{
+			name: "Transliterate the new file names with --transliterate",
+			want: []Change{
+				{
+					Source:  "Straße.txt",
+					BaseDir: testDir,
+					Target:  "Strasse-Zhukov.txt",
+				},
+			},
+			args: []string{
+				"-f",
+				"Straße",
+				"-r",
+				"Straße-Жуков",
+				"--transliterate",
+				testDir,
+			},
+		},
+		{
+			name: "Transliterate a variable",
+			want: []Change{
+				{
+					Source:  "Straße.txt",
+					BaseDir: testDir,
+					Target:  "Strasse_Straße.txt",
+				},
+			},
+			args: []string{"-f", "Straße", "-r", "{{f|translit}}_{{f}}", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
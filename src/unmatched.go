@@ -0,0 +1,101 @@
+package f2
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	reasonDirectory = "filtered: directory (use --include-dir)"
+	reasonFile      = "filtered: not a directory (--only-dir)"
+	reasonHidden    = "hidden"
+	reasonExcluded  = "filtered: excluded"
+	reasonNoMatch   = "no match"
+)
+
+// unmatchedFile represents a scanned file that was not matched
+// along with the reason why
+type unmatchedFile struct {
+	path   string
+	reason string
+}
+
+// unmatchedFiles returns the scanned files that are not part of the
+// matches. The reasons are checked in the same order as findMatches
+// and filterMatches
+func (op *Operation) unmatchedFiles() ([]unmatchedFile, error) {
+	matched := make(map[string]bool, len(op.matches))
+	for _, v := range op.matches {
+		matched[filepath.Join(v.BaseDir, v.Source)] = true
+	}
+
+	var exclude *regexp.Regexp
+	if len(op.excludeFilter) != 0 {
+		var err error
+		exclude, err = regexp.Compile(strings.Join(op.excludeFilter, "|"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var unmatched []unmatchedFile
+	for _, v := range op.paths {
+		path := filepath.Join(v.BaseDir, v.Source)
+		if matched[path] {
+			continue
+		}
+
+		reason := reasonNoMatch
+
+		hidden, err := op.hidden.skip(
+			filepath.Base(v.Source),
+			filepath.Dir(path),
+			v.IsDir,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case v.IsDir && !op.includeDir:
+			reason = reasonDirectory
+		case op.onlyDir && !v.IsDir:
+			reason = reasonFile
+		case hidden:
+			reason = reasonHidden
+		case exclude != nil && exclude.MatchString(v.Source):
+			reason = reasonExcluded
+		}
+
+		unmatched = append(unmatched, unmatchedFile{
+			path:   path,
+			reason: reason,
+		})
+	}
+
+	return unmatched, nil
+}
+
+// printUnmatched displays the scanned files that were not matched
+// so that the coverage of the find pattern can be confirmed
+func printUnmatched(w io.Writer, unmatched []unmatchedFile) {
+	if len(unmatched) == 0 {
+		fmt.Fprintln(w, "Every scanned file was matched")
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Unmatched", "Reason"})
+	table.SetAutoWrapText(false)
+
+	for _, v := range unmatched {
+		table.Append([]string{displayName(v.path), v.reason})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,50 @@
+package f2
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmatchedFiles(t *testing.T) {
+	op := &Operation{
+		paths: []Change{
+			{BaseDir: "dir", Source: "a.txt"},
+			{BaseDir: "dir", Source: "b.txt"},
+			{BaseDir: "dir", Source: "notes.md"},
+			{BaseDir: "dir", Source: ".hidden.txt"},
+			{BaseDir: "dir", Source: "sub", IsDir: true},
+		},
+		matches: []Change{
+			{BaseDir: "dir", Source: "a.txt"},
+		},
+		excludeFilter: []string{"^b"},
+	}
+
+	got, err := op.unmatchedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []unmatchedFile{
+		{path: filepath.Join("dir", "b.txt"), reason: reasonExcluded},
+		{path: filepath.Join("dir", "notes.md"), reason: reasonNoMatch},
+		{path: filepath.Join("dir", ".hidden.txt"), reason: reasonHidden},
+		{path: filepath.Join("dir", "sub"), reason: reasonDirectory},
+	}
+
+	if !cmp.Equal(want, got, cmp.AllowUnexported(unmatchedFile{})) {
+		t.Fatalf("Expected: %v, but got: %v", want, got)
+	}
+
+	var buf bytes.Buffer
+	printUnmatched(&buf, got)
+
+	if !strings.Contains(buf.String(), "notes.md") ||
+		!strings.Contains(buf.String(), reasonNoMatch) {
+		t.Fatalf("Expected the unmatched files to be listed, but got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,105 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	unresolvedEmpty = "empty"
+	unresolvedError = "error"
+	unresolvedSkip  = "skip"
+)
+
+var (
+	// variableTokenRegex matches a variable with an optional default value
+	// such as `{{id3.artist|Unknown Artist}}`
+	variableTokenRegex = regexp.MustCompile(`{{([^{}|]+)(?:\|([^{}]*))?}}`)
+
+	errUnresolvedVariable = errors.New("Variable could not be resolved")
+
+	errInvalidUnresolvedPolicy = errors.New(
+		"Invalid argument: --on-unresolved must be set to 'empty', 'error' or 'skip'",
+	)
+)
+
+// alwaysResolved reports whether the variable always has a meaningful value
+// even if empty (such as the extension of a file without one)
+func alwaysResolved(variable string) bool {
+	return filenameRegex.MatchString(variable) ||
+		extensionRegex.MatchString(variable) ||
+		parentDirRegex.MatchString(variable)
+}
+
+// resolveVariables replaces each variable in the input that has a default
+// value or is subject to the --on-unresolved policy. Variables that resolve
+// to an empty string are replaced with their default value if present.
+// Otherwise, the policy decides whether the variable is left empty, an error
+// is returned or the file is skipped (indicated by the boolean return value)
+func (op *Operation) resolveVariables(
+	input string,
+	ch Change,
+) (string, bool, error) {
+	var resolveErr error
+	var skip bool
+
+	out := variableTokenRegex.ReplaceAllStringFunc(input, func(token string) string {
+		if resolveErr != nil || skip {
+			return token
+		}
+
+		submatch := variableTokenRegex.FindStringSubmatch(token)
+		name, def := strings.TrimSpace(submatch[1]), submatch[2]
+		hasDefault := strings.Contains(token, "|")
+
+		if !hasDefault && op.onUnresolved == unresolvedEmpty {
+			return token
+		}
+
+		variable := "{{" + name + "}}"
+
+		vars, err := getAllVariables(variable)
+		if err != nil {
+			resolveErr = err
+			return token
+		}
+
+		value, err := op.handleVariables(variable, ch, &vars)
+		if err != nil {
+			resolveErr = err
+			return token
+		}
+
+		// not a known variable
+		if value == variable {
+			return token
+		}
+
+		if value != "" || (!hasDefault && alwaysResolved(variable)) {
+			return value
+		}
+
+		if hasDefault {
+			return def
+		}
+
+		switch op.onUnresolved {
+		case unresolvedError:
+			resolveErr = fmt.Errorf(
+				"%w: %s in %s",
+				errUnresolvedVariable,
+				variable,
+				filepath.Join(ch.BaseDir, ch.Source),
+			)
+		case unresolvedSkip:
+			skip = true
+		}
+
+		return ""
+	})
+
+	return out, skip, resolveErr
+}
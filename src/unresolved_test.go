@@ -0,0 +1,101 @@
+package f2
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVariableDefaults(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "images")
+
+	cases := []testCase{
+		{
+			name: "Use default values for unresolved variables",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "samsung_Unknown Lens.jpeg",
+				},
+				{
+					Source:  "bike.json",
+					BaseDir: testDir,
+					Target:  "No Make_Unknown Lens.json",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike",
+				"-r",
+				"{{exif.make|No Make}}_{{exif.lens|Unknown Lens}}",
+				"-e",
+				testDir,
+			},
+		},
+		{
+			name: "Skip files with unresolved variables",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "samsung.jpeg",
+				},
+				{
+					Source:  "bike.json",
+					BaseDir: testDir,
+					Target:  "bike.json",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike",
+				"-r",
+				"{{exif.make}}",
+				"-e",
+				"--on-unresolved",
+				"skip",
+				testDir,
+			},
+		},
+		{
+			name: "Extensionless files are not treated as unresolved",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "samsung-bike.jpeg",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike.jpeg",
+				"-r",
+				"{{exif.make}}-{{f}}{{ext}}",
+				"--on-unresolved",
+				"error",
+				testDir,
+			},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
+
+func TestUnresolvedErrorPolicy(t *testing.T) {
+	op := &Operation{onUnresolved: unresolvedError}
+	ch := Change{
+		BaseDir: filepath.Join("..", "testdata", "images"),
+		Source:  "bike.json",
+	}
+	ch.originalSource = ch.Source
+
+	_, _, err := op.resolveVariables("{{exif.make}}", ch)
+	if err == nil {
+		t.Fatal("Expected an error for an unresolved variable")
+	}
+
+	out, skip, err := op.resolveVariables("{{unknown}}_{{f}}", ch)
+	if err != nil || skip || out != "{{unknown}}_bike" {
+		t.Fatalf("Unexpected result: %s (skip: %t, err: %v)", out, skip, err)
+	}
+}
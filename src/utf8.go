@@ -0,0 +1,80 @@
+package f2
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// displayName escapes the bytes in the name that are not valid UTF-8 (such
+// as names created with a legacy encoding on old Linux systems) as `\xNN`
+// so that they can be displayed safely
+func displayName(name string) string {
+	if utf8.ValidString(name) {
+		return name
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if r == utf8.RuneError && size == 1 {
+			fmt.Fprintf(&b, `\x%02X`, name[i])
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+
+		i += size
+	}
+
+	return b.String()
+}
+
+// mapValid applies the function to each run of valid UTF-8 in the string
+// while the invalid bytes are left untouched. Functions such as
+// strings.ToUpper would otherwise replace them with U+FFFD
+func mapValid(str string, f func(string) string) string {
+	if utf8.ValidString(str) {
+		return f(str)
+	}
+
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(f(str[start:i]))
+			b.WriteByte(str[i])
+			start = i + 1
+		}
+
+		i += size
+	}
+
+	b.WriteString(f(str[start:]))
+
+	return b.String()
+}
+
+// repairUTF8 converts the bytes that are not valid UTF-8 to the characters
+// they represent in Windows-1252 (a superset of Latin-1) which is the most
+// common legacy encoding of such file names
+func repairUTF8(str string) string {
+	if utf8.ValidString(str) {
+		return str
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		if r == utf8.RuneError && size == 1 {
+			r = charmap.Windows1252.DecodeByte(str[i])
+		}
+
+		b.WriteRune(r)
+		i += size
+	}
+
+	return b.String()
+}
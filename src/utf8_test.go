@@ -0,0 +1,78 @@
+package f2
+
+import (
+	"testing"
+)
+
+func TestDisplayName(t *testing.T) {
+	cases := map[string]string{
+		"café.txt":       "café.txt",
+		"caf\xe9.txt":    `caf\xE9.txt`,
+		"\xff\xfe":       `\xFF\xFE`,
+		"r\xe9sum\xe9 ü": `r\xE9sum\xE9 ü`,
+	}
+
+	for name, want := range cases {
+		if got := displayName(name); got != want {
+			t.Fatalf("Expected %q to be displayed as %q, but got %q", name, want, got)
+		}
+	}
+}
+
+func TestTransformInvalidUTF8(t *testing.T) {
+	cases := []struct {
+		token string
+		input string
+		want  string
+	}{
+		{token: "up", input: "caf\xe9.txt", want: "CAF\xe9.TXT"},
+		{token: "lw", input: "\xffABC", want: "\xffabc"},
+		{token: "di", input: "é\xe9e", want: "e\xe9e"},
+		{token: "utf8", input: "caf\xe9 \x93quoted\x94", want: "café “quoted”"},
+		{token: "utf8", input: "already valid", want: "already valid"},
+	}
+
+	for _, tc := range cases {
+		got := transformString(tc.token, tc.input)
+		if got != tc.want {
+			t.Fatalf(
+				"Expected %s of %q to be %q, but got %q",
+				tc.token,
+				tc.input,
+				tc.want,
+				got,
+			)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	testDir := setupFiles(t, []string{"caf\xe9.txt"})
+
+	cases := []testCase{
+		{
+			name: "Keep invalid bytes when replacing",
+			want: []Change{
+				{
+					Source:  "caf\xe9.txt",
+					BaseDir: testDir,
+					Target:  "bar\xe9.md",
+				},
+			},
+			args: []string{"-f", "caf", "-r", "bar", "-f", "txt", "-r", "md", testDir},
+		},
+		{
+			name: "Repair invalid UTF-8",
+			want: []Change{
+				{
+					Source:  "caf\xe9.txt",
+					BaseDir: testDir,
+					Target:  "café.txt",
+				},
+			},
+			args: []string{"-f", ".*", "-r", "{{tr.utf8}}", testDir},
+		},
+	}
+
+	runFindReplace(t, cases)
+}
@@ -37,9 +37,10 @@ func printError(silent bool, err error) {
 func removeHidden(
 	de []os.DirEntry,
 	baseDir string,
+	hidden hiddenPolicy,
 ) (ret []os.DirEntry, err error) {
 	for _, e := range de {
-		r, err := isHidden(e.Name(), baseDir)
+		r, err := hidden.skip(e.Name(), baseDir, e.IsDir())
 		if err != nil {
 			return nil, err
 		}
@@ -52,6 +53,32 @@ func removeHidden(
 	return ret, nil
 }
 
+// combineErrors returns the non-nil errors as one error
+// whose message contains each of their messages
+func combineErrors(errs ...error) error {
+	var msgs []string
+
+	var first error
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		if first == nil {
+			first = err
+		}
+
+		msgs = append(msgs, err.Error())
+	}
+
+	if len(msgs) <= 1 {
+		return first
+	}
+
+	return fmt.Errorf("%w; %s", first, strings.Join(msgs[1:], "; "))
+}
+
 // contains checks if a string is present in
 // a string slice
 func contains(s []string, e string) bool {
@@ -83,8 +110,9 @@ func filenameWithoutExtension(fileName string) string {
 // and include their contents in the pool of paths in
 // which to find matches
 func walk(
+	fsys FS,
 	paths map[string][]os.DirEntry,
-	includeHidden bool,
+	hidden hiddenPolicy,
 	maxDepth int,
 ) (map[string][]os.DirEntry, error) {
 	var iterated []string
@@ -97,18 +125,15 @@ loop:
 			continue
 		}
 
-		if !includeHidden {
-			var err error
-			v, err = removeHidden(v, k)
-			if err != nil {
-				return nil, err
-			}
+		v, err := removeHidden(v, k, hidden)
+		if err != nil {
+			return nil, err
 		}
 
 		for _, de := range v {
 			if de.IsDir() {
 				fp := filepath.Join(k, de.Name())
-				dirEntry, err := os.ReadDir(fp)
+				dirEntry, err := fsys.ReadDir(fp)
 				if err != nil {
 					return nil, err
 				}
@@ -177,3 +202,57 @@ func prettyPrint(i interface{}) string {
 	s, _ := json.MarshalIndent(i, "", "\t")
 	return string(s)
 }
+
+// levenshtein returns the minimum number of single character edits
+// (insertions, deletions or substitutions) needed to change a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(
+				minInt(prev[j]+1, curr[j-1]+1),
+				prev[j-1]+cost,
+			)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// similarity returns a score between 0 and 1 based on the edit
+// distance between two strings
+func similarity(a, b string) float64 {
+	maxLen := len([]rune(a))
+	if l := len([]rune(b)); l > maxLen {
+		maxLen = l
+	}
+
+	if maxLen == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(a, b))/float64(maxLen)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
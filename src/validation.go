@@ -28,6 +28,9 @@ var (
 const (
 	windowsMaxLength = 260
 	unixMaxBytes     = 255
+	windowsMaxPath   = 260
+	darwinMaxPath    = 1024
+	unixMaxPath      = 4096
 )
 
 type conflict int
@@ -39,6 +42,7 @@ const (
 	maxLengthExceeded
 	invalidCharacters
 	trailingPeriod
+	duplicateBaseName
 )
 
 // Conflict represents a renaming operation conflict
@@ -55,7 +59,7 @@ type Conflict struct {
 // It appends an increasing number to the target path until it finds one
 // that does not conflict with the filesystem or with another renamed
 // file
-func getNewPath(target, baseDir string, m map[string][]struct {
+func getNewPath(fsys FS, target, baseDir string, m map[string][]struct {
 	source string
 	index  int
 }) string {
@@ -77,7 +81,7 @@ func getNewPath(target, baseDir string, m map[string][]struct {
 		fullPath := filepath.Join(baseDir, newPath)
 
 		// Ensure the new path does not exist on the filesystem
-		if _, err := os.Stat(fullPath); err != nil &&
+		if _, err := fsys.Stat(fullPath); err != nil &&
 			errors.Is(err, os.ErrNotExist) {
 			for k := range m {
 				if k == fullPath {
@@ -145,6 +149,22 @@ func (op *Operation) reportConflicts() {
 		}
 	}
 
+	if slice, exists := op.conflicts[duplicateBaseName]; exists {
+		for _, v := range slice {
+			for _, s := range v.source {
+				slice := []string{
+					s,
+					v.target,
+					printColor(
+						"red",
+						"❌ [Same file name from different directories]",
+					),
+				}
+				data = append(data, slice)
+			}
+		}
+	}
+
 	if slice, exists := op.conflicts[invalidCharacters]; exists {
 		for _, v := range slice {
 			for _, s := range v.source {
@@ -194,6 +214,17 @@ func (op *Operation) detectConflicts() {
 		index  int
 	})
 
+	// paths that are vacated during the operation can be renamed to
+	// as the files are renamed in an order that allows it
+	vacated := make(map[string]bool)
+	if op.linkMode == "" && op.unlinkMode == "" {
+		for _, ch := range op.matches {
+			if ch.Source != ch.Target {
+				vacated[filepath.Join(ch.BaseDir, ch.Source)] = true
+			}
+		}
+	}
+
 	for i := 0; i < len(op.matches); i++ {
 		ch := op.matches[i]
 		var source, target = ch.Source, ch.Target
@@ -242,10 +273,12 @@ func (op *Operation) detectConflicts() {
 			continue
 		}
 
-		detected = op.checkPathExistsConflict(source, target, ch, i)
-		if detected && op.fixConflicts {
-			i--
-			continue
+		if !vacated[target] {
+			detected = op.checkPathExistsConflict(source, target, ch, i)
+			if detected && op.fixConflicts {
+				i--
+				continue
+			}
 		}
 
 		// For detecting duplicates after renaming paths
@@ -270,7 +303,7 @@ func (op *Operation) checkPathExistsConflict(
 ) bool {
 	var conflictDetected bool
 	// Report if target file exists on the filesystem
-	if _, err := os.Stat(target); err == nil ||
+	if _, err := op.filesystem().Stat(target); err == nil ||
 		errors.Is(err, os.ErrExist) {
 		// Don't report a conflict for an unchanged filename
 		// Also handles case-insensitive filesystems
@@ -291,7 +324,7 @@ func (op *Operation) checkPathExistsConflict(
 		if op.fixConflicts {
 			dir := filepath.Dir(ch.Target)
 			base := filepath.Base(ch.Target)
-			str := getNewPath(base, ch.BaseDir, nil)
+			str := getNewPath(op.filesystem(), base, ch.BaseDir, nil)
 			str = filepath.Join(dir, str)
 			op.matches[i].Target = str
 		}
@@ -310,12 +343,24 @@ func (op *Operation) checkOverwritingPathConflict(m map[string][]struct {
 	for k, v := range m {
 		if len(v) > 1 {
 			var sources []string
+			dupes := make([]targetSource, 0, len(v))
 			for _, s := range v {
 				sources = append(sources, s.source)
+				dupes = append(dupes, targetSource(s))
+			}
+
+			kind := overwritingNewPath
+			if isDuplicateBaseName(dupes) {
+				if op.onDuplicate != "" {
+					op.resolveDuplicateBaseNames(dupes, m)
+					continue
+				}
+
+				kind = duplicateBaseName
 			}
 
-			op.conflicts[overwritingNewPath] = append(
-				op.conflicts[overwritingNewPath],
+			op.conflicts[kind] = append(
+				op.conflicts[kind],
 				Conflict{
 					source: sources,
 					target: k,
@@ -331,7 +376,12 @@ func (op *Operation) checkOverwritingPathConflict(m map[string][]struct {
 
 					dir := filepath.Dir(op.matches[item.index].Target)
 					base := filepath.Base(op.matches[item.index].Target)
-					str := getNewPath(base, op.matches[item.index].BaseDir, m)
+					str := getNewPath(
+						op.filesystem(),
+						base,
+						op.matches[item.index].BaseDir,
+						m,
+					)
 					str = filepath.Join(dir, str)
 					pt := filepath.Join(op.matches[item.index].BaseDir, str)
 					if _, ok := m[pt]; !ok {
@@ -375,24 +425,164 @@ func checkForbiddenCharacters(path string) error {
 	return nil
 }
 
-// checkPathLength is responsible for ensuring that the filename length
-// does not exceed the maximum value on each supported operating system
-func checkPathLength(path string) error {
-	// Get the standalone filename
-	filename := filepath.Base(path)
+// pathLength returns the length of the path in characters on Windows
+// and in bytes on other operating systems
+func pathLength(path string) int {
+	if runtime.GOOS == windows {
+		return len([]rune(path))
+	}
+
+	return len(path)
+}
+
+// maxPathLength returns the maximum length of a full path
+// on the current operating system
+func maxPathLength() int {
+	switch runtime.GOOS {
+	case windows:
+		return windowsMaxPath
+	case darwin:
+		return darwinMaxPath
+	default:
+		return unixMaxPath
+	}
+}
+
+// maxSegmentLength returns the maximum length of a
+// file or directory name on the current operating system
+func maxSegmentLength() int {
+	if runtime.GOOS == windows {
+		return windowsMaxLength
+	}
+
+	return unixMaxBytes
+}
+
+// lengthUnit returns the unit in which path lengths are measured
+func lengthUnit() string {
+	if runtime.GOOS == windows {
+		return "characters"
+	}
+
+	return "bytes"
+}
+
+// pathSegments splits the target into its directory and file names
+func pathSegments(target string) []string {
+	return strings.FieldsFunc(target, func(r rune) bool {
+		return r == '/' || r == filepath.Separator
+	})
+}
+
+// checkPathLength is responsible for ensuring that the length of each
+// file and directory name in the target, and the length of the full path
+// do not exceed the maximum values on each supported operating system
+func checkPathLength(target, absTarget string) error {
+	for _, v := range pathSegments(target) {
+		if pathLength(v) > maxSegmentLength() {
+			return fmt.Errorf("%d %s", maxSegmentLength(), lengthUnit())
+		}
+	}
+
+	abs, err := filepath.Abs(absTarget)
+	if err != nil {
+		abs = absTarget
+	}
 
-	// max length of 260 characters in windows
-	if runtime.GOOS == windows &&
-		len([]rune(filename)) > windowsMaxLength {
-		return fmt.Errorf("%d characters", windowsMaxLength)
-	} else if runtime.GOOS != windows && len([]byte(filename)) > unixMaxBytes {
-		// max length of 255 bytes on Linux and other unix-based OSes
-		return fmt.Errorf("%d bytes", unixMaxBytes)
+	if pathLength(abs) > maxPathLength() {
+		return fmt.Errorf("%d %s in full path", maxPathLength(), lengthUnit())
 	}
 
 	return nil
 }
 
+// trimSegment removes the last character from a file or directory name.
+// The extension of the file name is preserved. It returns false if the
+// name cannot be shortened any further
+func trimSegment(segment string, isFile bool) (string, bool) {
+	ext := ""
+	if isFile {
+		ext = filepath.Ext(segment)
+	}
+
+	name := []rune(segment[:len(segment)-len(ext)])
+	if len(name) <= 1 {
+		return segment, false
+	}
+
+	return string(name[:len(name)-1]) + ext, true
+}
+
+// shortenTarget shortens the names in the target so that each one fits
+// within the maximum name length and the full path fits within the maximum
+// path length. The longest name is always shortened first so that the
+// result is deterministic. It returns the shortened target, a description
+// of each truncated name, and false if the target could not be shortened
+// enough
+func shortenTarget(target, baseDir string) (string, []string, bool) {
+	segments := pathSegments(target)
+	original := append([]string{}, segments...)
+	last := len(segments) - 1
+
+	for i := range segments {
+		for pathLength(segments[i]) > maxSegmentLength() {
+			s, ok := trimSegment(segments[i], i == last)
+			if !ok {
+				return target, nil, false
+			}
+
+			segments[i] = s
+		}
+	}
+
+	fullPath := func() string {
+		abs, err := filepath.Abs(
+			filepath.Join(baseDir, filepath.Join(segments...)),
+		)
+		if err != nil {
+			return filepath.Join(baseDir, filepath.Join(segments...))
+		}
+
+		return abs
+	}
+
+	for pathLength(fullPath()) > maxPathLength() {
+		longest := -1
+		for i, v := range segments {
+			if v == "." || v == ".." {
+				continue
+			}
+
+			if _, ok := trimSegment(v, i == last); !ok {
+				continue
+			}
+
+			if longest == -1 ||
+				pathLength(v) > pathLength(segments[longest]) {
+				longest = i
+			}
+		}
+
+		if longest == -1 {
+			return target, nil, false
+		}
+
+		segments[longest], _ = trimSegment(segments[longest], longest == last)
+	}
+
+	var truncated []string
+	for i := range segments {
+		if segments[i] != original[i] {
+			truncated = append(
+				truncated,
+				fmt.Sprintf("'%s' → '%s'", original[i], segments[i]),
+			)
+		}
+	}
+
+	return filepath.Join(segments...), truncated, true
+}
+
 // checkTrailingPeriods reports if replacement operation results
 // in files or sub directories that end in trailing dots
 func (op *Operation) checkTrailingPeriodConflict(
@@ -436,7 +626,7 @@ func (op *Operation) checkPathLengthConflict(
 	i int,
 ) bool {
 	var conflictDetected bool
-	err := checkPathLength(target)
+	err := checkPathLength(target, absTarget)
 	if err != nil {
 		op.conflicts[maxLengthExceeded] = append(
 			op.conflicts[maxLengthExceeded],
@@ -449,32 +639,21 @@ func (op *Operation) checkPathLengthConflict(
 		conflictDetected = true
 
 		if op.fixConflicts {
-			if runtime.GOOS == windows {
-				// trim filename so that it's less than 260 characters
-				filename := []rune(filepath.Base(target))
-				ext := []rune(filepath.Ext(string(filename)))
-				f := []rune(filenameWithoutExtension(string(filename)))
-				index := windowsMaxLength - len(ext)
-				f = f[:index]
-				op.matches[i].Target = filepath.Join(string(f), string(ext))
-			} else {
-				// trim filename so that it's no more than 255 bytes
-				filename := filepath.Base(target)
-				ext := filepath.Ext(filename)
-				f := filenameWithoutExtension(filename)
-				index := unixMaxBytes - len([]byte(ext))
-				for {
-					if len([]byte(f)) > index {
-						frune := []rune(f)
-						f = string(frune[:len(frune)-1])
-						continue
-					}
+			ch := op.matches[i]
+			str, truncated, ok := shortenTarget(target, ch.BaseDir)
+			if !ok {
+				// The file is left unchanged
+				op.matches[i].Target = ch.Source
+				return conflictDetected
+			}
 
-					break
-				}
+			op.matches[i].Target = str
 
-				op.matches[i].Target = filepath.Join(f, ext)
+			if op.truncations == nil {
+				op.truncations = make(map[string][]string)
 			}
+
+			op.truncations[source] = append(op.truncations[source], truncated...)
 		}
 	}
 
@@ -523,5 +702,11 @@ func (op *Operation) checkForbiddenCharactersConflict(
 // validate tries to prevent common renaming problems by analyzing the list
 // of files and target destinations
 func (op *Operation) validate() {
+	// Removing links does not create any new paths
+	if op.unlinkMode != "" {
+		op.conflicts = make(map[conflict][]Conflict)
+		return
+	}
+
 	op.detectConflicts()
 }
@@ -343,7 +343,7 @@ func TestGetNewPath(t *testing.T) {
 	}
 
 	for _, v := range cases {
-		out := getNewPath(v.input, ".", v.m)
+		out := getNewPath(osFS{}, v.input, ".", v.m)
 		if out != v.output {
 			t.Fatalf(
 				"Incorrect output from getNewPath. Want: %s, got %s",
@@ -353,3 +353,79 @@ func TestGetNewPath(t *testing.T) {
 		}
 	}
 }
+
+func TestDuplicateBaseName(t *testing.T) {
+	testDir := setupFiles(t, []string{"2020/IMG_0001.jpg", "2021/IMG_0001.jpg"})
+	args := []string{"-f", ".*", "-r", "../all/{{f}}{{ext}}", "-R", testDir}
+
+	runConflictCheck(t, []conflictTable{
+		{
+			name: "Same file name from different directories",
+			want: map[conflict][]Conflict{
+				duplicateBaseName: {
+					{
+						source: []string{
+							filepath.Join(testDir, "2020", "IMG_0001.jpg"),
+							filepath.Join(testDir, "2021", "IMG_0001.jpg"),
+						},
+						target: filepath.Join(testDir, "all", "IMG_0001.jpg"),
+					},
+				},
+			},
+			args: args,
+		},
+	})
+
+	cases := []testCase{
+		{
+			name: "Prefix the source directory",
+			want: []Change{
+				{
+					Source:  "IMG_0001.jpg",
+					BaseDir: filepath.Join(testDir, "2020"),
+					Target:  filepath.Join("..", "all", "2020_IMG_0001.jpg"),
+				},
+				{
+					Source:  "IMG_0001.jpg",
+					BaseDir: filepath.Join(testDir, "2021"),
+					Target:  filepath.Join("..", "all", "2021_IMG_0001.jpg"),
+				},
+			},
+			args: append([]string{"--on-duplicate", "prefix"}, args...),
+		},
+		{
+			name: "Suffix a counter",
+			want: []Change{
+				{
+					Source:  "IMG_0001.jpg",
+					BaseDir: filepath.Join(testDir, "2020"),
+					Target:  filepath.Join("..", "all", "IMG_0001.jpg"),
+				},
+				{
+					Source:  "IMG_0001.jpg",
+					BaseDir: filepath.Join(testDir, "2021"),
+					Target:  filepath.Join("..", "all", "IMG_0001 (2).jpg"),
+				},
+			},
+			args: append([]string{"--on-duplicate", "counter"}, args...),
+		},
+		{
+			name: "Skip the duplicates",
+			want: []Change{
+				{
+					Source:  "IMG_0001.jpg",
+					BaseDir: filepath.Join(testDir, "2020"),
+					Target:  "IMG_0001.jpg",
+				},
+				{
+					Source:  "IMG_0001.jpg",
+					BaseDir: filepath.Join(testDir, "2021"),
+					Target:  "IMG_0001.jpg",
+				},
+			},
+			args: append([]string{"--on-duplicate", "skip"}, args...),
+		},
+	}
+
+	runFindReplace(t, cases)
+}
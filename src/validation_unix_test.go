@@ -4,6 +4,7 @@ package f2
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -64,3 +65,46 @@ func TestUnixFixConflict(t *testing.T) {
 
 	runFixConflict(t, table)
 }
+
+func TestShortenTarget(t *testing.T) {
+	baseDir, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := strings.Repeat("d", 300)
+	got, truncated, ok := shortenTarget(filepath.Join(dir, "file.txt"), baseDir)
+	if !ok || len(truncated) != 1 {
+		t.Fatalf("Expected a single truncated name, but got: %v", truncated)
+	}
+
+	want := filepath.Join(strings.Repeat("d", unixMaxBytes), "file.txt")
+	if got != want {
+		t.Fatalf("Expected: %s, but got: %s", want, got)
+	}
+
+	// the combined length of the directories exceeds the full path limit
+	var segments []string
+	for i := 0; i < maxPathLength()/200+1; i++ {
+		segments = append(segments, strings.Repeat(string(rune('a'+i%26)), 200))
+	}
+
+	segments = append(segments, strings.Repeat("f", 250)+".txt")
+
+	got, truncated, ok = shortenTarget(filepath.Join(segments...), baseDir)
+	if !ok || len(truncated) == 0 {
+		t.Fatalf("Expected the target to be shortened")
+	}
+
+	if l := len(filepath.Join(baseDir, got)); l > maxPathLength() {
+		t.Fatalf("Expected at most %d bytes, but got: %d", maxPathLength(), l)
+	}
+
+	if filepath.Ext(got) != ".txt" {
+		t.Fatalf("Expected the extension to be preserved: %s", got)
+	}
+
+	if err := checkPathLength(got, filepath.Join(baseDir, got)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
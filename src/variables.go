@@ -1,12 +1,14 @@
 package f2
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -16,6 +18,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -38,14 +41,37 @@ var (
 	randomRegex = regexp.MustCompile(
 		`{{(\d+)?r(?:(_l|_d|_ld)|(?:<(.*)>))?}}`,
 	)
-	hashRegex      = regexp.MustCompile(`{{hash.(sha1|sha256|sha512|md5)}}`)
-	transformRegex = regexp.MustCompile(`{{tr.(up|lw|ti|win|mac|di)}}`)
+	hashRegex = regexp.MustCompile(
+		`{{hash.(sha1|sha256|sha512|md5)(?::(\d+))?}}`,
+	)
+	transformRegex = regexp.MustCompile(`{{tr.(up|lw|ti|win|mac|di|ascii|utf8)}}`)
+	groupRegex     = regexp.MustCompile(`{{group(\.label)?}}`)
+	ocrRegex       = regexp.MustCompile(`{{ocr\.firstwords(?:\.(\d+))?}}`)
+	csvRegex       = regexp.MustCompile(`{{csv\.(\d+)}}`)
 	id3Regex       *regexp.Regexp
 	exifRegex      *regexp.Regexp
 	dateRegex      *regexp.Regexp
 	exiftoolRegex  *regexp.Regexp
 )
 
+// exifHeader precedes the TIFF structure of a raw EXIF block
+const exifHeader = "Exif\x00\x00"
+
+const (
+	// exifScanLimit is the number of bytes at the start of a file
+	// that are searched for an embedded EXIF block
+	exifScanLimit = 16 << 20
+
+	// exifScanChunk is the number of bytes read at a time
+	// while searching for an embedded EXIF block
+	exifScanChunk = 64 << 10
+
+	// exifMaxSize is the largest embedded EXIF block that is decoded
+	exifMaxSize = 4 << 20
+)
+
+var errNoExifData = errors.New("No EXIF data found")
+
 const (
 	sha1Hash   = "sha1"
 	sha256Hash = "sha256"
@@ -133,7 +159,10 @@ func init() {
 		tokens = append(tokens, key)
 	}
 
-	tokenString := strings.Join(tokens, "|")
+	// tokens may be combined (e.g. `YYYYMMDD`) and separated by
+	// hyphens or underscores (e.g. `YYYY-MM-DD`)
+	token := "(?:" + strings.Join(tokens, "|") + ")"
+	tokenString := token + "(?:[-_]?" + token + ")*"
 	dateRegex = regexp.MustCompile(
 		"{{(" + modTime + "|" + changeTime + "|" + birthTime + "|" + accessTime + "|" + currentTime + ")\\.(" + tokenString + ")}}",
 	)
@@ -141,29 +170,31 @@ func init() {
 	exiftoolRegex = regexp.MustCompile(`{{xt\.([0-9a-zA-Z]+)}}`)
 
 	exifRegex = regexp.MustCompile(
-		"{{(?:exif|x)\\.(iso|et|fl|w|h|wh|make|model|lens|fnum|fl35|lat|lon|soft)?(?:(dt)\\.(" + tokenString + "))?}}",
+		"{{(?:exif|x)\\.(iso|et|fl|w|h|wh|make|model|lens|fnum|fl35|lat|lon|place|soft)?(?:(dt)\\.(" + tokenString + "))?}}",
 	)
 
 	id3Regex = regexp.MustCompile(
 		`{{id3\.(format|type|title|album|album_artist|artist|genre|year|composer|track|disc|total_tracks|total_discs)}}`,
 	)
-
-	rand.Seed(time.Now().UnixNano())
 }
 
 // randString returns a random string of the specified length
 // using the specified characterSet
-func randString(n int, characterSet string) string {
+func randString(n int, characterSet string, rng *rand.Rand) string {
 	b := make([]byte, n)
 	for i := range b {
-		b[i] = characterSet[rand.Intn(len(characterSet))]
+		b[i] = characterSet[rng.Intn(len(characterSet))]
 	}
 	return string(b)
 }
 
 // replaceRandomVariables reolaces `{{r}}` in the string with a generated
 // random string
-func replaceRandomVariables(input string, rv randomVar) string {
+func replaceRandomVariables(
+	input string,
+	rv randomVar,
+	rng *rand.Rand,
+) string {
 	for i := range rv.submatches {
 		r := rv.values[i]
 		characters := r.characters
@@ -181,7 +212,7 @@ func replaceRandomVariables(input string, rv randomVar) string {
 
 		input = r.regex.ReplaceAllString(
 			input,
-			randString(r.length, characters),
+			randString(r.length, characters, rng),
 		)
 	}
 
@@ -225,7 +256,56 @@ func integerToRoman(number int) string {
 	return roman.String()
 }
 
+// hashCache stores the computed hashes of files so that each file is
+// hashed at most once per hash function. Entries are invalidated when
+// the size or modification time of the file changes
+var hashCache = struct {
+	sync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// getHash streams the contents of the file through the specified
+// hash function. Results are cached
 func getHash(file, hashFn string) (string, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf(
+		"%s|%s|%d|%d",
+		abs,
+		hashFn,
+		info.Size(),
+		info.ModTime().UnixNano(),
+	)
+
+	hashCache.Lock()
+	value, ok := hashCache.m[key]
+	hashCache.Unlock()
+
+	if ok {
+		return value, nil
+	}
+
+	value, err = computeHash(file, hashFn)
+	if err != nil {
+		return "", err
+	}
+
+	hashCache.Lock()
+	hashCache.m[key] = value
+	hashCache.Unlock()
+
+	return value, nil
+}
+
+func computeHash(file, hashFn string) (string, error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return "", err
@@ -257,24 +337,44 @@ func getHash(file, hashFn string) (string, error) {
 
 // replaceFileHash replaces a hash variable with the corresponding
 // hash value
-func replaceFileHash(input, filePath string, hv hashVar) (string, error) {
+func (op *Operation) replaceFileHash(
+	input, filePath string,
+	hv hashVar,
+) (string, error) {
 	for i := range hv.submatches {
 		h := hv.values[i]
 
-		hashValue, err := getHash(filePath, h.hashFn)
+		hashValue, err := op.fileHash(filePath, h.hashFn)
 		if err != nil {
 			return "", err
 		}
 
+		if h.length > 0 && h.length < len(hashValue) {
+			hashValue = hashValue[:h.length]
+		}
+
 		input = h.regex.ReplaceAllString(input, hashValue)
 	}
 
 	return input, nil
 }
 
+// formatDate formats the time according to the date tokens in the format
+// (such as `YYYYMMDD`). Each token is formatted separately so that the
+// separators between them are not mistaken for parts of a Go layout
+func formatDate(t time.Time, format string) string {
+	return dateTokenRegex.ReplaceAllStringFunc(format, func(token string) string {
+		return t.Format(dateTokens[token])
+	})
+}
+
 // replaceDateVariables replaces a date variable with the corresponding
-// date value
-func replaceDateVariables(input, filePath string, dv dateVar) (string, error) {
+// date value. The current time is used for `{{now}}`
+func replaceDateVariables(
+	input, filePath string,
+	dv dateVar,
+	now time.Time,
+) (string, error) {
 	t, err := times.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -289,25 +389,24 @@ func replaceDateVariables(input, filePath string, dv dateVar) (string, error) {
 		switch current.attr {
 		case modTime:
 			modTime := t.ModTime()
-			timeStr = modTime.Format(dateTokens[token])
+			timeStr = formatDate(modTime, token)
 		case birthTime:
 			birthTime := t.ModTime()
 			if t.HasBirthTime() {
 				birthTime = t.BirthTime()
 			}
-			timeStr = birthTime.Format(dateTokens[token])
+			timeStr = formatDate(birthTime, token)
 		case accessTime:
 			accessTime := t.AccessTime()
-			timeStr = accessTime.Format(dateTokens[token])
+			timeStr = formatDate(accessTime, token)
 		case changeTime:
 			changeTime := t.ModTime()
 			if t.HasChangeTime() {
 				changeTime = t.ChangeTime()
 			}
-			timeStr = changeTime.Format(dateTokens[token])
+			timeStr = formatDate(changeTime, token)
 		case currentTime:
-			currentTime := time.Now()
-			timeStr = currentTime.Format(dateTokens[token])
+			timeStr = formatDate(now, token)
 		}
 
 		input = regex.ReplaceAllString(input, timeStr)
@@ -325,6 +424,8 @@ func getID3Tags(filePath string) (*ID3, error) {
 		return nil, err
 	}
 
+	defer f.Close()
+
 	m, err := tag.ReadFrom(f)
 	if err != nil {
 		return &ID3{}, nil
@@ -426,6 +527,10 @@ func getExifData(filePath string) (*Exif, error) {
 
 	exifData := &Exif{}
 	x, err := exif.Decode(f)
+	if err != nil {
+		x, err = decodeEmbeddedExif(filePath)
+	}
+
 	if err == nil {
 		var b []byte
 		b, err = x.MarshalJSON()
@@ -443,8 +548,79 @@ func getExifData(filePath string) (*Exif, error) {
 	return exifData, nil
 }
 
+// findEmbeddedExif returns the offset of the first raw EXIF block in the
+// reader. The reader is scanned in chunks so that large files such as
+// videos are not loaded into memory
+func findEmbeddedExif(r io.Reader) (int64, error) {
+	headers := [][]byte{
+		[]byte(exifHeader + "II*\x00"),
+		[]byte(exifHeader + "MM\x00*"),
+	}
+
+	// keep the end of the previous chunk in case
+	// a header spans two chunks
+	overlap := len(headers[0]) - 1
+
+	chunk := make([]byte, exifScanChunk)
+	buf := make([]byte, 0, exifScanChunk+overlap)
+
+	var offset int64
+
+	for {
+		n, err := io.ReadFull(r, chunk)
+		buf = append(buf, chunk[:n]...)
+
+		first := -1
+		for _, h := range headers {
+			if i := bytes.Index(buf, h); i != -1 && (first == -1 || i < first) {
+				first = i
+			}
+		}
+
+		if first != -1 {
+			return offset + int64(first), nil
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return -1, errNoExifData
+		}
+
+		if err != nil {
+			return -1, err
+		}
+
+		keep := overlap
+		if len(buf) < keep {
+			keep = len(buf)
+		}
+
+		offset += int64(len(buf) - keep)
+		buf = append(buf[:0], buf[len(buf)-keep:]...)
+	}
+}
+
+// decodeEmbeddedExif locates and decodes a raw EXIF block embedded in
+// formats that are not directly supported by the EXIF decoder such as
+// HEIC/HEIF images where the EXIF data is stored in an ISOBMFF item.
+// Only the start of the file is searched
+func decodeEmbeddedExif(filePath string) (*exif.Exif, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	offset, err := findEmbeddedExif(io.LimitReader(f, exifScanLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	return exif.Decode(io.NewSectionReader(f, offset, exifMaxSize))
+}
+
 // replaceExifVariables replaces the exif variables in an input string
-func replaceExifVariables(
+func (op *Operation) replaceExifVariables(
 	exifData *Exif,
 	input string,
 	ev exifVar,
@@ -468,7 +644,7 @@ func replaceExifVariables(
 					return "", err
 				}
 
-				value = dt.Format(dateTokens[current.timeStr])
+				value = formatDate(dt, current.timeStr)
 			}
 		case "soft":
 			value = exifData.Software
@@ -525,6 +701,15 @@ func replaceExifVariables(
 			value = exifData.Latitude
 		case "lon":
 			value = exifData.Longitude
+		case "place":
+			var err error
+			value, err = op.reverseGeocode(
+				exifData.Latitude,
+				exifData.Longitude,
+			)
+			if err != nil {
+				return "", err
+			}
 		case "wh":
 			if len(exifData.ImageLength) > 0 && len(exifData.ImageWidth) > 0 {
 				h, w := exifData.ImageLength[0], exifData.ImageWidth[0]
@@ -653,6 +838,45 @@ func (op *Operation) replaceIndex(
 	return input
 }
 
+// transformString applies the transformation identified by the token
+// (such as `up` for uppercase) to the string
+func transformString(token, str string) string {
+	switch token {
+	case "up":
+		return mapValid(str, strings.ToUpper)
+	case "lw":
+		return mapValid(str, strings.ToLower)
+	case "ti":
+		return mapValid(str, func(s string) string {
+			return strings.Title(strings.ToLower(s))
+		})
+	case "utf8":
+		return repairUTF8(str)
+	case "ascii":
+		return transliterate(str)
+	case "win":
+		return regexReplace(fullWindowsForbiddenRegex, str, "", 0)
+	case "mac":
+		return regexReplace(macForbiddenRegex, str, "", 0)
+	case "di":
+		t := transform.Chain(
+			norm.NFD,
+			runes.Remove(runes.In(unicode.Mn)),
+			norm.NFC,
+		)
+		return mapValid(str, func(s string) string {
+			result, _, err := transform.String(t, s)
+			if err != nil {
+				return s
+			}
+
+			return result
+		})
+	}
+
+	return str
+}
+
 // replaceTransformVariables handles string transformations like uppercase,
 // lowercase, stripping characters, e.t.c
 func replaceTransformVariables(
@@ -664,45 +888,7 @@ func replaceTransformVariables(
 		current := tv.values[i]
 		r := current.regex
 		for _, v := range matches {
-			switch current.token {
-			case "up":
-				input = regexReplace(r, input, strings.ToUpper(v), 1)
-			case "lw":
-				input = regexReplace(r, input, strings.ToLower(v), 1)
-			case "ti":
-				input = regexReplace(
-					r,
-					input,
-					strings.Title(strings.ToLower(v)),
-					1,
-				)
-			case "win":
-				input = regexReplace(
-					r,
-					input,
-					regexReplace(fullWindowsForbiddenRegex, v, "", 0),
-					1,
-				)
-			case "mac":
-				input = regexReplace(
-					r,
-					input,
-					regexReplace(macForbiddenRegex, v, "", 0),
-					1,
-				)
-			case "di":
-				t := transform.Chain(
-					norm.NFD,
-					runes.Remove(runes.In(unicode.Mn)),
-					norm.NFC,
-				)
-				result, _, err := transform.String(t, v)
-				if err != nil {
-					return v
-				}
-
-				input = regexReplace(r, input, result, 1)
-			}
+			input = regexReplace(r, input, transformString(current.token, v), 1)
 		}
 	}
 
@@ -722,6 +908,11 @@ func (op *Operation) handleVariables(
 	parentDir := filepath.Base(ch.BaseDir)
 	sourcePath := filepath.Join(ch.BaseDir, ch.originalSource)
 
+	if op.replacePath {
+		// the source includes the path from the searched directory
+		parentDir = filepath.Base(filepath.Dir(sourcePath))
+	}
+
 	if parentDir == "." {
 		// Set to base folder of current working directory
 		parentDir = filepath.Base(op.workingDir)
@@ -746,9 +937,37 @@ func (op *Operation) handleVariables(
 		input = parentDirRegex.ReplaceAllString(input, parentDir)
 	}
 
+	// replace `{{seg.N}}` with the corresponding segment of the file name
+	if segmentRegex.MatchString(input) || segmentSepRegex.MatchString(input) {
+		input = op.replaceSegmentVariables(input, fileName)
+	}
+
+	// replace `{{csv.N}}` with the corresponding column in the CSV file
+	if csvRegex.MatchString(input) {
+		input = replaceCSVVariables(input, ch.csvRow)
+	}
+
 	// handle date variables (e.g {{mtime.DD}})
 	if dateRegex.MatchString(input) {
-		out, err := replaceDateVariables(input, sourcePath, vars.date)
+		out, err := replaceDateVariables(
+			input,
+			sourcePath,
+			vars.date,
+			op.currentTime(),
+		)
+		if err != nil {
+			return "", err
+		}
+		input = out
+	}
+
+	// handle retention buckets (e.g {{agebucket}})
+	if ageBucketRegex.MatchString(input) {
+		out, err := replaceAgeBucketVariables(
+			input,
+			sourcePath,
+			op.currentTime(),
+		)
 		if err != nil {
 			return "", err
 		}
@@ -764,12 +983,12 @@ func (op *Operation) handleVariables(
 	}
 
 	if exifRegex.MatchString(input) {
-		exifData, err := getExifData(sourcePath)
+		exifData, err := op.exifData(sourcePath)
 		if err != nil {
 			return "", err
 		}
 
-		out, err := replaceExifVariables(exifData, input, vars.exif)
+		out, err := op.replaceExifVariables(exifData, input, vars.exif)
 		if err != nil {
 			return "", err
 		}
@@ -777,7 +996,7 @@ func (op *Operation) handleVariables(
 	}
 
 	if id3Regex.MatchString(input) {
-		tags, err := getID3Tags(sourcePath)
+		tags, err := op.id3Tags(sourcePath)
 		if err != nil {
 			return "", err
 		}
@@ -786,15 +1005,69 @@ func (op *Operation) handleVariables(
 	}
 
 	if hashRegex.MatchString(input) {
-		out, err := replaceFileHash(input, sourcePath, vars.hash)
+		out, err := op.replaceFileHash(input, sourcePath, vars.hash)
 		if err != nil {
 			return "", err
 		}
 		input = out
 	}
 
+	if ocrRegex.MatchString(input) {
+		out, err := op.replaceOcrVariables(input, sourcePath, vars.ocr)
+		if err != nil {
+			return "", err
+		}
+		input = out
+	}
+
+	if metaFileRegex.MatchString(input) {
+		out, err := op.replaceMetaFileVariables(input, sourcePath)
+		if err != nil {
+			return "", err
+		}
+		input = out
+	}
+
+	if xcmdRegex.MatchString(input) {
+		out, err := op.replaceXcmdVariables(input, sourcePath)
+		if err != nil {
+			return "", err
+		}
+		input = out
+	}
+
+	if sizeRegex.MatchString(input) {
+		out, err := replaceSizeVariables(input, sourcePath)
+		if err != nil {
+			return "", err
+		}
+		input = out
+	}
+
+	if splitRegex.MatchString(input) {
+		out, err := op.replaceSplitVariables(
+			input,
+			filepath.Base(ch.originalSource),
+		)
+		if err != nil {
+			return "", err
+		}
+		input = out
+	}
+
+	if groupRegex.MatchString(input) {
+		group := op.groups[sourcePath]
+		input = groupRegex.ReplaceAllStringFunc(input, func(v string) string {
+			if strings.HasSuffix(v, ".label}}") {
+				return group.label
+			}
+
+			return strconv.Itoa(group.index)
+		})
+	}
+
 	if randomRegex.MatchString(input) {
-		input = replaceRandomVariables(input, vars.random)
+		input = replaceRandomVariables(input, vars.random, op.random())
 	}
 
 	if transformRegex.MatchString(input) {
@@ -1,7 +1,9 @@
 package f2
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -146,7 +148,12 @@ func TestReplaceDateVariables(t *testing.T) {
 					t.Fatalf("Test (%s) — Unexpected error: %v", v, err)
 				}
 
-				out, err := replaceDateVariables("{{"+v+"."+key+"}}", path, dv)
+				out, err := replaceDateVariables(
+					"{{"+v+"."+key+"}}",
+					path,
+					dv,
+					time.Now(),
+				)
 				if err != nil {
 					t.Fatalf("Expected no errors, but got one: %v\n", err)
 				}
@@ -164,6 +171,34 @@ func TestReplaceDateVariables(t *testing.T) {
 	}
 }
 
+func TestCompoundDateTokens(t *testing.T) {
+	now := time.Date(2021, time.April, 5, 9, 7, 3, 0, time.UTC)
+
+	cases := map[string]string{
+		"{{now.YYYYMMDD}}":             "20210405",
+		"archived_{{now.YYYY-MM-DD}}":  "archived_2021-04-05",
+		"{{now.YYYY_D}}":               "2021_5",
+		"{{now.hhmmss}}{{now.a}}":      "090703am",
+		"{{now.DDD}}-{{now.MMMMYYYY}}": "Mon-April2021",
+	}
+
+	for input, want := range cases {
+		dv, err := getDateVar(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := replaceDateVariables(input, ".", dv, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Fatalf("Expected %s to be %s, but got: %s", input, want, got)
+		}
+	}
+}
+
 func TestReplaceExifVariables(t *testing.T) {
 	rootDir := filepath.Join("..", "testdata", "images")
 
@@ -409,11 +444,64 @@ func TestFileHash(t *testing.T) {
 				testDir,
 			},
 		},
+		{
+			name: "Truncate hash values",
+			want: []Change{
+				{
+					Source:  "bike.jpeg",
+					BaseDir: testDir,
+					Target:  "bike_6801e3de_5b97fd595c70.jpeg",
+				},
+			},
+			args: []string{
+				"-f",
+				"bike.jpeg",
+				"-r",
+				"{{f}}_{{hash.md5:8}}_{{hash.sha1:12}}{{ext}}",
+				testDir,
+			},
+		},
 	}
 
 	runFindReplace(t, cases)
 }
 
+func TestHashCache(t *testing.T) {
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "file.txt")
+
+	err := os.WriteFile(path, []byte("one"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := getHash(path, md5Hash)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	second, err := getHash(path, md5Hash)
+	if err != nil || first != second {
+		t.Fatalf("Expected cached hash %s, but got: %s (%v)", first, second, err)
+	}
+
+	err = os.WriteFile(path, []byte("changed"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	err = os.Chtimes(path, future, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	third, err := getHash(path, md5Hash)
+	if err != nil || third == first {
+		t.Fatalf("Expected the hash to be recomputed after modification")
+	}
+}
+
 func TestReplaceRandomVariable(t *testing.T) {
 	slice := []string{
 		`{{10r_l}}`,
@@ -441,7 +529,7 @@ func TestReplaceRandomVariable(t *testing.T) {
 			t.Fatalf("Test (%s) — Unexpected error: %v", v, err)
 		}
 
-		str := replaceRandomVariables(v, rv)
+		str := replaceRandomVariables(v, rv, rand.New(rand.NewSource(1)))
 		if len(str) != length {
 			t.Fatalf(
 				"Test (%s) — Expected length of random string to be %d, got: %d",
@@ -590,3 +678,56 @@ func TestReplaceExifToolVariables(t *testing.T) {
 
 	runFindReplace(t, cases)
 }
+
+func TestEmbeddedExif(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("..", "testdata", "images", "bike.jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := bytes.Index(b, []byte(exifHeader))
+	if i == -1 {
+		t.Fatal("Expected EXIF data in test image")
+	}
+
+	// simulate an ISOBMFF (HEIC) container with an embedded EXIF block
+	heic := append([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"), b[i:]...)
+
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "image.heic")
+	err = os.WriteFile(path, heic, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exifData, err := getExifData(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if exifData.Make != "samsung" || exifData.Model != "SM-G975F" {
+		t.Fatalf("Unexpected EXIF data: %+v", exifData)
+	}
+}
+
+func TestFindEmbeddedExif(t *testing.T) {
+	header := exifHeader + "MM\x00*"
+
+	// the header spans two chunks
+	offset := exifScanChunk - 3
+	data := append(bytes.Repeat([]byte{0}, offset), []byte(header+"rest")...)
+
+	got, err := findEmbeddedExif(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != int64(offset) {
+		t.Fatalf("Expected offset %d, but got: %d", offset, got)
+	}
+
+	_, err = findEmbeddedExif(bytes.NewReader(bytes.Repeat([]byte{0}, 3*exifScanChunk)))
+	if !errors.Is(err, errNoExifData) {
+		t.Fatalf("Expected error %v, but got: %v", errNoExifData, err)
+	}
+}
@@ -0,0 +1,442 @@
+package f2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	errWatchMode = errors.New(
+		"Invalid argument: --watch cannot be combined with --undo, --import or --csv",
+	)
+
+	errInvalidQuietHours = errors.New(
+		"Invalid argument: --quiet-hours must be in the form HH:MM-HH:MM (e.g. 22:00-07:00)",
+	)
+)
+
+// quietHours is a daily period during which files are not renamed in
+// watch mode. The period wraps around midnight if it ends before it starts
+type quietHours struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// parseQuietHours parses a period in the form HH:MM-HH:MM
+func parseQuietHours(s string) (*quietHours, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	slice := strings.Split(s, "-")
+	expectedLength := 2
+	if len(slice) != expectedLength {
+		return nil, errInvalidQuietHours
+	}
+
+	var offsets [2]time.Duration
+	for i, v := range slice {
+		t, err := time.Parse("15:04", strings.TrimSpace(v))
+		if err != nil {
+			return nil, errInvalidQuietHours
+		}
+
+		offsets[i] = time.Duration(t.Hour())*time.Hour +
+			time.Duration(t.Minute())*time.Minute
+	}
+
+	return &quietHours{start: offsets[0], end: offsets[1]}, nil
+}
+
+// contains reports whether the specified time falls within the quiet hours
+func (q *quietHours) contains(t time.Time) bool {
+	if q == nil || q.start == q.end {
+		return false
+	}
+
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute
+
+	if q.start < q.end {
+		return offset >= q.start && offset < q.end
+	}
+
+	return offset >= q.start || offset < q.end
+}
+
+// watchGuards limit when and how quickly files are renamed in watch mode
+// so that a runaway producer or a misconfigured pattern cannot rename a
+// large number of files unexpectedly
+type watchGuards struct {
+	// batchWindow is how long files that become ready are collected
+	// before they are renamed together
+	batchWindow time.Duration
+	// maxPerMinute is the maximum number of files that may be renamed
+	// within a minute. Zero means no limit
+	maxPerMinute int
+	quietHours   *quietHours
+	// renames holds the time of each rename in the last minute
+	renames []time.Time
+}
+
+// newWatchGuards returns the guards configured through the command line
+func newWatchGuards(c *cli.Context) (*watchGuards, error) {
+	quiet, err := parseQuietHours(c.String("quiet-hours"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &watchGuards{
+		batchWindow:  c.Duration("batch-window"),
+		maxPerMinute: c.Int("max-renames-per-minute"),
+		quietHours:   quiet,
+	}, nil
+}
+
+// available returns how many more files may be renamed at the specified
+// time without exceeding the rename limit or -1 if there is no limit
+func (g *watchGuards) available(now time.Time) int {
+	i := 0
+	for i < len(g.renames) && now.Sub(g.renames[i]) >= time.Minute {
+		i++
+	}
+
+	g.renames = g.renames[i:]
+
+	if g.maxPerMinute <= 0 {
+		return -1
+	}
+
+	if n := g.maxPerMinute - len(g.renames); n > 0 {
+		return n
+	}
+
+	return 0
+}
+
+// record registers n renames at the specified time
+func (g *watchGuards) record(n int, now time.Time) {
+	if g.maxPerMinute <= 0 {
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		g.renames = append(g.renames, now)
+	}
+}
+
+// ready reports whether a batch started at the specified time
+// can be renamed now
+func (g *watchGuards) ready(batchStart, now time.Time) bool {
+	return now.Sub(batchStart) >= g.batchWindow && !g.quietHours.contains(now)
+}
+
+// watchState records the size and modification time of a file so that
+// files which are still being written can be detected
+type watchState struct {
+	size    int64
+	modTime time.Time
+}
+
+// pendingFile is a newly created file that has not yet been
+// left unchanged for the debounce period
+type pendingFile struct {
+	state watchState
+	since time.Time
+}
+
+// watchSnapshot returns the current state of each of the scanned paths
+// keyed by their absolute path
+func watchSnapshot(paths []Change) map[string]watchState {
+	snapshot := make(map[string]watchState)
+
+	for _, v := range paths {
+		path, err := filepath.Abs(filepath.Join(v.BaseDir, v.Source))
+		if err != nil {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		snapshot[path] = watchState{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	return snapshot
+}
+
+// readyFiles returns the paths in the current snapshot that have not been
+// seen before and have remained unchanged for the debounce period. Other
+// new paths are tracked as pending until they settle
+func readyFiles(
+	seen map[string]bool,
+	pending map[string]pendingFile,
+	current map[string]watchState,
+	now time.Time,
+	debounce time.Duration,
+) map[string]bool {
+	ready := make(map[string]bool)
+
+	for path := range pending {
+		if _, ok := current[path]; !ok {
+			delete(pending, path)
+		}
+	}
+
+	for path, state := range current {
+		if seen[path] {
+			continue
+		}
+
+		p, ok := pending[path]
+		if !ok || p.state != state {
+			pending[path] = pendingFile{state: state, since: now}
+			if debounce > 0 {
+				continue
+			}
+		}
+
+		if now.Sub(pending[path].since) >= debounce {
+			ready[path] = true
+			delete(pending, path)
+		}
+	}
+
+	return ready
+}
+
+// watchPaths returns the scanned paths that are present in the set
+func watchPaths(paths []Change, set map[string]bool) []Change {
+	var filtered []Change
+
+	for _, v := range paths {
+		path, err := filepath.Abs(filepath.Join(v.BaseDir, v.Source))
+		if err != nil {
+			continue
+		}
+
+		if set[path] {
+			filtered = append(filtered, v)
+		}
+	}
+
+	return filtered
+}
+
+// watch runs watch mode until the context is cancelled. The watch rules
+// file is reloaded on SIGHUP (e.g. systemctl --user reload f2)
+func watch(ctx context.Context, c *cli.Context) error {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	defer signal.Stop(reload)
+
+	return watchFiles(ctx, c, reload)
+}
+
+// watchFiles polls the target directories and applies the renaming
+// operation to files that are created after it starts. Files are only
+// renamed once they have stopped changing for the debounce period and are
+// renamed in batches subject to the watch guards. Files that would exceed
+// the rename limit are held until they can be renamed without exceeding
+// it. In dry-run mode, the planned changes for each batch of files are
+// printed instead. The watch rules are reloaded when a signal is received
+// on the reload channel
+func watchFiles(
+	ctx context.Context,
+	c *cli.Context,
+	reload <-chan os.Signal,
+) error {
+	op, err := newOperation(c, osFS{})
+	if err != nil {
+		return err
+	}
+
+	if op.revert || op.importFile != "" || op.csvFile != "" {
+		return errWatchMode
+	}
+
+	guards, err := newWatchGuards(c)
+	if err != nil {
+		return err
+	}
+
+	var rules []watchRule
+	if path := c.String("watch-rules"); path != "" {
+		rules, err = readWatchRules(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d := c.Duration("max-runtime"); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+
+		defer cancel()
+	}
+
+	metrics := &watchMetrics{}
+	if addr := c.String("metrics-addr"); addr != "" {
+		// the server is stopped once watch mode ends
+		serverCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		addr, err = serveMetrics(serverCtx, addr, metrics)
+		if err != nil {
+			return err
+		}
+
+		if !op.quiet {
+			fmt.Fprintf(os.Stderr, "Serving metrics at http://%s/metrics\n", addr)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for path := range watchSnapshot(op.paths) {
+		seen[path] = true
+	}
+
+	pending := make(map[string]pendingFile)
+
+	// batch holds the files that are ready to be renamed
+	batch := make(map[string]bool)
+
+	var batchStart time.Time
+
+	ticker := time.NewTicker(c.Duration("watch-interval"))
+	defer ticker.Stop()
+
+	if !op.quiet {
+		fmt.Fprintln(os.Stderr, "Watching for new files. Press Ctrl+C to stop")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-reload:
+			rules = reloadWatchRules(c, rules, op.quiet)
+			continue
+		case <-ticker.C:
+		}
+
+		// a target directory may be briefly unavailable (e.g. while a
+		// network share reconnects) so the error is reported and the
+		// directories are checked again on the next tick
+		op, err = newOperation(c, osFS{})
+		if err != nil {
+			printError(c.Bool("silent"), err)
+			continue
+		}
+
+		current := watchSnapshot(op.paths)
+
+		for path := range seen {
+			if _, ok := current[path]; !ok {
+				delete(seen, path)
+			}
+		}
+
+		now := time.Now()
+
+		ready := readyFiles(
+			seen,
+			pending,
+			current,
+			now,
+			c.Duration("debounce"),
+		)
+
+		for path := range ready {
+			seen[path] = true
+
+			if len(batch) == 0 {
+				batchStart = now
+			}
+
+			batch[path] = true
+		}
+
+		for path := range batch {
+			if _, ok := current[path]; !ok {
+				delete(batch, path)
+			}
+		}
+
+		if len(batch) == 0 || !guards.ready(batchStart, now) {
+			continue
+		}
+
+		paths := watchPaths(op.paths, batch)
+
+		// the files that would exceed the rename limit are held
+		// in the batch until enough time has passed
+		if n := guards.available(now); op.exec && n >= 0 && len(paths) > n {
+			paths = paths[:n]
+		}
+
+		if len(paths) == 0 {
+			continue
+		}
+
+		op.paths = paths
+
+		ops := []*Operation{op}
+		if len(rules) > 0 {
+			// the batch is kept so that it is renamed on the next tick
+			ops, err = watchRuleOperations(c, rules, op.paths)
+			if err != nil {
+				printError(c.Bool("silent"), err)
+				continue
+			}
+		}
+
+		for _, v := range paths {
+			path, err := filepath.Abs(filepath.Join(v.BaseDir, v.Source))
+			if err == nil {
+				delete(batch, path)
+			}
+		}
+
+		for _, op := range ops {
+			start := time.Now()
+
+			err = op.run(ctx)
+
+			metrics.observe(op, err, time.Since(start))
+
+			if op.quiet && !op.silent {
+				op.printQuietSummary(os.Stderr, err)
+			} else if err != nil {
+				printError(op.quiet, err)
+			}
+
+			op.notify(err)
+			op.postWebhook(err)
+
+			if op.exec {
+				guards.record(len(op.matches), now)
+			}
+
+			// the renamed files are not new
+			for _, ch := range op.matches {
+				path, err := filepath.Abs(filepath.Join(ch.BaseDir, ch.Target))
+				if err == nil {
+					seen[path] = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package f2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// startWatch runs watch mode in the background with a short interval and
+// the specified arguments. The watch rules are reloaded when a signal is
+// sent on the reload channel. The returned channel receives its result
+// once it stops which should be bounded with --max-runtime
+func startWatch(reload <-chan os.Signal, args ...string) <-chan error {
+	app := GetApp()
+	app.Action = func(c *cli.Context) error {
+		return watchFiles(c.Context, c, reload)
+	}
+
+	args = append([]string{
+		os.Args[0],
+		"--watch",
+		"--watch-interval",
+		"10ms",
+		"--debounce",
+		"20ms",
+	}, args...)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- app.Run(args)
+	}()
+
+	return done
+}
+
+// waitForFile waits until the file at the specified path exists
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Timed out waiting for %s", path)
+}
+
+func TestWatch(t *testing.T) {
+	testDir := setupFiles(t, []string{"old.txt"})
+
+	done := startWatch(nil, "-f", "txt", "-r", "md", "-x", "--silent", "--max-runtime", "1s", testDir)
+
+	// give watch mode time to take its first snapshot
+	time.Sleep(100 * time.Millisecond)
+
+	// the next ticks fail while the directory is missing
+	err := os.Rename(testDir, testDir+".tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	err = os.Rename(testDir+".tmp", testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(testDir, "new.txt"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForFile(t, filepath.Join(testDir, "new.md"))
+
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "old.txt")); err != nil {
+		t.Fatalf("Expected existing files to be left unchanged: %v", err)
+	}
+}
+
+func TestReadyFiles(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	seen := map[string]bool{"/inbox/old.txt": true}
+	pending := make(map[string]pendingFile)
+
+	current := map[string]watchState{
+		"/inbox/old.txt": {size: 10, modTime: start},
+		"/inbox/new.pdf": {size: 100, modTime: start},
+	}
+
+	ready := readyFiles(seen, pending, current, start, 2*time.Second)
+	if len(ready) != 0 {
+		t.Fatalf("Expected no ready files before the debounce period, got: %v", ready)
+	}
+
+	// the file is still being written
+	current["/inbox/new.pdf"] = watchState{size: 200, modTime: start.Add(time.Second)}
+
+	ready = readyFiles(seen, pending, current, start.Add(2*time.Second), 2*time.Second)
+	if len(ready) != 0 {
+		t.Fatalf("Expected no ready files while the file is changing, got: %v", ready)
+	}
+
+	ready = readyFiles(seen, pending, current, start.Add(4*time.Second), 2*time.Second)
+	if len(ready) != 1 || !ready["/inbox/new.pdf"] {
+		t.Fatalf("Expected /inbox/new.pdf to be ready, got: %v", ready)
+	}
+
+	if len(pending) != 0 {
+		t.Fatalf("Expected no pending files, got: %v", pending)
+	}
+
+	delete(current, "/inbox/new.pdf")
+	current["/inbox/gone.pdf"] = watchState{size: 1, modTime: start}
+
+	readyFiles(seen, pending, current, start.Add(5*time.Second), 2*time.Second)
+	delete(current, "/inbox/gone.pdf")
+	readyFiles(seen, pending, current, start.Add(6*time.Second), 2*time.Second)
+
+	if len(pending) != 0 {
+		t.Fatalf("Expected removed files to be dropped, got: %v", pending)
+	}
+
+	current["/inbox/now.txt"] = watchState{size: 1, modTime: start}
+
+	ready = readyFiles(seen, pending, current, start.Add(7*time.Second), 0)
+	if !ready["/inbox/now.txt"] {
+		t.Fatalf("Expected new files to be ready immediately without a debounce period, got: %v", ready)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		period string
+		hour   int
+		want   bool
+	}{
+		{period: "22:00-07:00", hour: 23, want: true},
+		{period: "22:00-07:00", hour: 3, want: true},
+		{period: "22:00-07:00", hour: 7, want: false},
+		{period: "22:00-07:00", hour: 12, want: false},
+		{period: "09:00-17:30", hour: 17, want: true},
+		{period: "09:00-17:30", hour: 8, want: false},
+	}
+
+	for _, tc := range cases {
+		q, err := parseQuietHours(tc.period)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.period, err)
+		}
+
+		got := q.contains(day.Add(time.Duration(tc.hour) * time.Hour))
+		if got != tc.want {
+			t.Fatalf("%s at %d:00: expected %t, got %t", tc.period, tc.hour, tc.want, got)
+		}
+	}
+
+	for _, v := range []string{"22:00", "25:00-07:00", "10pm-7am"} {
+		_, err := parseQuietHours(v)
+		if !errors.Is(err, errInvalidQuietHours) {
+			t.Fatalf("%s: expected errInvalidQuietHours, got: %v", v, err)
+		}
+	}
+}
+
+func TestWatchGuards(t *testing.T) {
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	g := &watchGuards{batchWindow: 5 * time.Second, maxPerMinute: 10}
+
+	if g.ready(start, start.Add(time.Second)) {
+		t.Fatal("Expected the batch to be held until the batch window ends")
+	}
+
+	if !g.ready(start, start.Add(5*time.Second)) {
+		t.Fatal("Expected the batch to be ready after the batch window")
+	}
+
+	if n := g.available(start); n != 10 {
+		t.Fatalf("Expected 10 renames to be available, got %d", n)
+	}
+
+	g.record(8, start)
+
+	if n := g.available(start.Add(30 * time.Second)); n != 2 {
+		t.Fatalf("Expected 2 renames to be available, got %d", n)
+	}
+
+	g.record(3, start.Add(30*time.Second))
+
+	if n := g.available(start.Add(40 * time.Second)); n != 0 {
+		t.Fatalf("Expected no renames to be available, got %d", n)
+	}
+
+	if n := g.available(start.Add(time.Minute)); n != 7 {
+		t.Fatalf("Expected renames older than a minute to be discarded, got %d", n)
+	}
+
+	if n := (&watchGuards{}).available(start); n != -1 {
+		t.Fatalf("Expected no limit, got %d", n)
+	}
+
+	q, err := parseQuietHours("11:00-13:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.quietHours = q
+
+	if g.ready(start, start.Add(10*time.Second)) {
+		t.Fatal("Expected the batch to be held during quiet hours")
+	}
+
+	if !g.ready(start, start.Add(time.Hour)) {
+		t.Fatal("Expected the batch to be ready after quiet hours")
+	}
+}
+
+func TestWatchRenameLimit(t *testing.T) {
+	testDir := setupFiles(t, nil)
+
+	done := startWatch(
+		nil,
+		"-f", "txt", "-r", "md", "-x", "--silent",
+		"--max-renames-per-minute", "1",
+		"--max-runtime", "500ms",
+		testDir,
+	)
+
+	time.Sleep(100 * time.Millisecond)
+
+	for _, f := range []string{"a.txt", "b.txt"} {
+		err := os.WriteFile(filepath.Join(testDir, f), nil, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the limit holds back the second file instead of stopping watch mode
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(testDir, "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected one file to be renamed within the limit, got: %v", matches)
+	}
+}
+
+func TestWatchRules(t *testing.T) {
+	testDir := setupFiles(t, []string{"old.pdf"})
+
+	rules := filepath.Join(t.TempDir(), "rules.toml")
+
+	writeRules := func(content string) {
+		err := os.WriteFile(rules, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeRules(`
+[[watch]]
+match = '*.pdf'
+replace = 'doc-{{f}}{{ext}}'
+
+[[watch]]
+regex = '^IMG_(\d+)'
+replace = 'photo-$1'
+`)
+
+	reload := make(chan os.Signal, 1)
+
+	done := startWatch(
+		reload,
+		"--watch-rules", rules,
+		"-x", "--silent",
+		"--max-runtime", "1s",
+		testDir,
+	)
+
+	time.Sleep(100 * time.Millisecond)
+
+	for _, f := range []string{"a.pdf", "IMG_0012.jpg", "notes.txt"} {
+		err := os.WriteFile(filepath.Join(testDir, f), nil, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForFile(t, filepath.Join(testDir, "doc-a.pdf"))
+	waitForFile(t, filepath.Join(testDir, "photo-0012.jpg"))
+
+	writeRules(`
+[[watch]]
+match = '*.pdf'
+replace = 'scan-{{f}}{{ext}}'
+`)
+
+	reload <- syscall.SIGHUP
+
+	time.Sleep(50 * time.Millisecond)
+
+	err := os.WriteFile(filepath.Join(testDir, "b.pdf"), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForFile(t, filepath.Join(testDir, "scan-b.pdf"))
+
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, f := range []string{"old.pdf", "notes.txt", "doc-a.pdf"} {
+		if _, err := os.Stat(filepath.Join(testDir, f)); err != nil {
+			t.Fatalf("Expected %s to be left unchanged: %v", f, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package f2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+)
+
+const watchRulesTable = "watch"
+
+var (
+	errWatchRules = errors.New(
+		"Invalid argument: --watch-rules can only be used with --watch and cannot be combined with -f, -r, --expr or --rules",
+	)
+
+	errNoWatchRules = errors.New(
+		"The watch rules file does not contain any [[watch]] tables",
+	)
+)
+
+// watchRule maps the new files whose names match a glob or regular
+// expression to a rename template in watch mode
+type watchRule struct {
+	match      string
+	regex      string
+	replace    string
+	ignoreCase bool
+	matcher    *regexp.Regexp
+}
+
+// parseWatchRule sets the option of the watch rule identified by the key
+func parseWatchRule(r *watchRule, key, value string) error {
+	var err error
+
+	switch key {
+	case "match":
+		r.match = value
+	case "regex":
+		r.regex = value
+	case "replace":
+		r.replace = value
+	case "ignore-case":
+		r.ignoreCase, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("unknown option '%s'", key)
+	}
+
+	return err
+}
+
+// compile prepares the matcher of the rule. Exactly one of a glob
+// or a regular expression must be specified
+func (r *watchRule) compile() error {
+	if (r.match == "") == (r.regex == "") {
+		return errors.New("exactly one of 'match' or 'regex' must be set")
+	}
+
+	op := &Operation{
+		globMode:   r.match != "",
+		ignoreCase: r.ignoreCase,
+	}
+
+	pattern := r.regex
+	if op.globMode {
+		pattern = r.match
+	}
+
+	var err error
+
+	r.matcher, err = op.compileFind(pattern)
+
+	return err
+}
+
+// readWatchRules reads the rules used to rename new files in watch mode.
+// Each rule is a `[[watch]]` table such as:
+//
+//	[[watch]]
+//	match = '*.pdf'
+//	replace = 'scans/{{mtime.YYYY}}/{{f}}{{ext}}'
+//
+//	[[watch]]
+//	regex = '^IMG_(\d+)'
+//	replace = 'photo-$1'
+//
+// The rules are evaluated in order and each file is renamed by the
+// first rule that matches its name
+func readWatchRules(path string) ([]watchRule, error) {
+	tables, err := readTables(path, watchRulesTable)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]watchRule, 0, len(tables))
+	for i, t := range tables {
+		var r watchRule
+
+		for k, v := range t {
+			err = parseWatchRule(&r, k, v)
+			if err != nil {
+				break
+			}
+		}
+
+		if err == nil {
+			err = r.compile()
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Invalid watch rule %d in '%s': %w",
+				i+1,
+				path,
+				err,
+			)
+		}
+
+		rules = append(rules, r)
+	}
+
+	if len(rules) == 0 {
+		return nil, errNoWatchRules
+	}
+
+	return rules, nil
+}
+
+// matchWatchRules groups the paths by the first rule that matches their
+// name. Paths that do not match any rule are left out
+func matchWatchRules(rules []watchRule, paths []Change) [][]Change {
+	groups := make([][]Change, len(rules))
+
+	for _, v := range paths {
+		for i, r := range rules {
+			if r.matcher.MatchString(v.Source) {
+				groups[i] = append(groups[i], v)
+				break
+			}
+		}
+	}
+
+	return groups
+}
+
+// watchRuleOperations returns an operation for each rule that matches
+// at least one of the paths. Each operation renames the matched paths
+// according to the template of the rule
+func watchRuleOperations(
+	c *cli.Context,
+	rules []watchRule,
+	paths []Change,
+) ([]*Operation, error) {
+	var ops []*Operation
+
+	for i, group := range matchWatchRules(rules, paths) {
+		if len(group) == 0 {
+			continue
+		}
+
+		op, err := newOperation(c, osFS{})
+		if err != nil {
+			return nil, err
+		}
+
+		op.useWatchRule(rules[i])
+		op.paths = group
+
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// useWatchRule replaces the find and replace steps of the
+// operation with the matcher and template of the rule
+func (op *Operation) useWatchRule(r watchRule) {
+	op.findSlice = nil
+	op.fuzzyFind = ""
+	op.replacementSlice = []string{r.replace}
+	op.searchRegexes = []*regexp.Regexp{r.matcher}
+	op.searchRegex = r.matcher
+}
+
+// reloadWatchRules reads the watch rules file again. The current rules
+// are kept if the file cannot be read
+func reloadWatchRules(c *cli.Context, rules []watchRule, quiet bool) []watchRule {
+	path := c.String("watch-rules")
+	if path == "" {
+		return rules
+	}
+
+	reloaded, err := readWatchRules(path)
+	if err != nil {
+		printError(quiet, fmt.Errorf("Unable to reload the watch rules: %w", err))
+		return rules
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Reloaded %d watch rule(s) from %s\n", len(reloaded), path)
+	}
+
+	return reloaded
+}
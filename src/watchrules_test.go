@@ -0,0 +1,87 @@
+package f2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadWatchRules(t *testing.T) {
+	path := writeRules(t, `
+[[watch]]
+match = '*.pdf'
+replace = 'scans/{{f}}{{ext}}'
+ignore-case = true
+
+[[watch]]
+regex = '^IMG_(\d+)'
+replace = 'photo-$1'
+
+[[watch]]
+match = '*'
+replace = 'other/{{f}}{{ext}}'
+`)
+
+	rules, err := readWatchRules(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, but got: %+v", rules)
+	}
+
+	paths := []Change{
+		{BaseDir: "inbox", Source: "invoice.PDF"},
+		{BaseDir: "inbox", Source: "IMG_0042.jpg"},
+		{BaseDir: "inbox", Source: "notes.txt"},
+		{BaseDir: "inbox", Source: "IMG_0043.pdf"},
+	}
+
+	want := [][]string{
+		{"invoice.PDF", "IMG_0043.pdf"},
+		{"IMG_0042.jpg"},
+		{"notes.txt"},
+	}
+
+	groups := matchWatchRules(rules, paths)
+	for i, group := range groups {
+		var got []string
+		for _, v := range group {
+			got = append(got, v.Source)
+		}
+
+		if strings.Join(got, ",") != strings.Join(want[i], ",") {
+			t.Fatalf("Rule %d: expected %v, but got: %v", i+1, want[i], got)
+		}
+	}
+
+	if got := rules[1].matcher.ReplaceAllString("IMG_0042.jpg", rules[1].replace); got != "photo-0042.jpg" {
+		t.Fatalf("Expected the regex groups to be usable in the template, but got: %s", got)
+	}
+}
+
+func TestReadWatchRulesErrors(t *testing.T) {
+	cases := []struct {
+		content string
+		want    string
+	}{
+		{content: "[[watch]]\nreplace = 'a'", want: "exactly one of"},
+		{content: "[[watch]]\nmatch = '*'\nregex = '.*'\nreplace = 'a'", want: "exactly one of"},
+		{content: "[[watch]]\nregex = '('\nreplace = 'a'", want: "Invalid watch rule 1"},
+		{content: "[[watch]]\nmatch = '*'\nfind = 'a'", want: "unknown option 'find'"},
+		{content: "[[rule]]\nfind = 'a'", want: "Unknown option"},
+	}
+
+	for _, tc := range cases {
+		_, err := readWatchRules(writeRules(t, tc.content))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%q: expected an error containing %q, but got: %v", tc.content, tc.want, err)
+		}
+	}
+
+	_, err := readWatchRules(writeRules(t, "# no rules"))
+	if !errors.Is(err, errNoWatchRules) {
+		t.Fatalf("Expected errNoWatchRules, but got: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package f2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const webhookTimeout = 20 * time.Second
+
+// ansiRegex matches the escape sequences used to print coloured output
+var ansiRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// summaryError represents a file that could not be renamed
+type summaryError struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Error  string `json:"error"`
+}
+
+// operationSummary describes the outcome of a renaming operation
+type operationSummary struct {
+	WorkingDir string            `json:"working_dir"`
+	Date       string            `json:"date"`
+	Exec       bool              `json:"exec"`
+	Undo       bool              `json:"undo"`
+	Success    bool              `json:"success"`
+	Error      string            `json:"error,omitempty"`
+	Changes    []Change          `json:"changes"`
+	Conflicts  []summaryConflict `json:"conflicts,omitempty"`
+	Errors     []summaryError    `json:"errors,omitempty"`
+}
+
+// summary returns the outcome of the operation. The error returned
+// by the operation (if any) is included
+func (op *Operation) summary(err error) operationSummary {
+	s := operationSummary{
+		WorkingDir: op.workingDir,
+		Date:       time.Now().Format(time.RFC3339),
+		Exec:       op.exec,
+		Undo:       op.revert,
+		Success:    err == nil,
+		Changes:    op.matches,
+		Conflicts:  op.summaryConflicts(),
+	}
+
+	if s.Changes == nil {
+		s.Changes = []Change{}
+	}
+
+	if err != nil {
+		s.Error = ansiRegex.ReplaceAllString(err.Error(), "")
+	}
+
+	for _, v := range op.errors {
+		s.Errors = append(s.Errors, summaryError{
+			Source: v.entry.Source,
+			Target: v.entry.Target,
+			Error:  v.err.Error(),
+		})
+	}
+
+	return s
+}
+
+// postWebhook sends the summary of the operation as JSON
+// to the specified URL
+func (op *Operation) postWebhook(err error) {
+	if op.webhook == "" {
+		return
+	}
+
+	b, merr := json.Marshal(op.summary(err))
+	if merr != nil {
+		printError(op.silent, merr)
+		return
+	}
+
+	c := http.Client{Timeout: webhookTimeout}
+
+	resp, perr := c.Post(op.webhook, "application/json", bytes.NewReader(b))
+	if perr != nil {
+		printError(op.silent, fmt.Errorf("Failed to call webhook: %w", perr))
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		printError(
+			op.silent,
+			fmt.Errorf("Webhook responded with status: %s", resp.Status),
+		)
+	}
+}
@@ -0,0 +1,116 @@
+package f2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPostWebhook(t *testing.T) {
+	var got operationSummary
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("Expected a POST request, but got: %s", r.Method)
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}),
+	)
+	defer server.Close()
+
+	op := &Operation{
+		webhook:    server.URL,
+		quiet:      true,
+		exec:       true,
+		workingDir: "/home/user",
+		matches: []Change{
+			{Source: "a.txt", Target: "b.txt", BaseDir: "docs"},
+		},
+		errors: []renameError{
+			{
+				entry: Change{Source: "c.txt", Target: "d.txt"},
+				err:   errors.New("permission denied"),
+			},
+		},
+	}
+
+	op.postWebhook(errors.New("failed"))
+
+	if got.Success || got.Error != "failed" || !got.Exec {
+		t.Fatalf("Unexpected summary: %+v", got)
+	}
+
+	if len(got.Changes) != 1 || got.Changes[0].Target != "b.txt" {
+		t.Fatalf("Unexpected changes in summary: %+v", got.Changes)
+	}
+
+	if len(got.Errors) != 1 || got.Errors[0].Error != "permission denied" {
+		t.Fatalf("Unexpected errors in summary: %+v", got.Errors)
+	}
+}
+
+func TestSubcommandWebhook(t *testing.T) {
+	var got []operationSummary
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var s operationSummary
+			if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			got = append(got, s)
+		}),
+	)
+	defer server.Close()
+
+	testDir := setupFiles(t, []string{"a.txt"})
+
+	plan := filepath.Join(testDir, "plan.json")
+	content := `{
+    "working_dir": "` + filepath.ToSlash(testDir) + `",
+    "changes": [
+        {"base_dir": ".", "source": "a.txt", "target": "b.txt"}
+    ]
+}`
+
+	err := os.WriteFile(plan, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands := [][]string{
+		{"apply", "-x", "-q", "--webhook", server.URL, plan},
+		{"history", "undo", "-x", "-q", "--webhook", server.URL},
+		{"history", "redo", "-x", "-q", "--webhook", server.URL},
+	}
+
+	for _, args := range commands {
+		err = GetApp().Run(append(os.Args[0:1], args...))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", args[0], err)
+		}
+	}
+
+	if len(got) != len(commands) {
+		t.Fatalf("Expected %d webhook calls, but got: %d", len(commands), len(got))
+	}
+
+	for i, s := range got {
+		if !s.Success || len(s.Changes) != 1 || s.Undo != (i == 1) {
+			t.Fatalf("%s: unexpected summary: %+v", commands[i][0], s)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(testDir, "b.txt")); err != nil {
+		t.Fatalf("Expected b.txt to exist after redo: %v", err)
+	}
+}
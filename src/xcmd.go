@@ -0,0 +1,112 @@
+package f2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var (
+	xcmdRegex = regexp.MustCompile(`{{xcmd\.([\w-]+)}}`)
+
+	errInvalidXcmd = errors.New(
+		"Invalid argument: --xcmd must be in the form <name>=<command> (e.g. --xcmd 'duration=ffprobe -v quiet -show_entries format=duration -of csv=p=0')",
+	)
+)
+
+// parseXcmds parses the named commands used by the `{{xcmd.<name>}}`
+// variable into the program and its arguments
+func parseXcmds(values []string) (map[string][]string, error) {
+	commands := make(map[string][]string)
+
+	for _, v := range values {
+		i := strings.IndexByte(v, '=')
+		if i < 1 {
+			return nil, errInvalidXcmd
+		}
+
+		name, command := v[:i], strings.Fields(v[i+1:])
+		if !xcmdRegex.MatchString("{{xcmd."+name+"}}") || len(command) == 0 {
+			return nil, errInvalidXcmd
+		}
+
+		commands[name] = command
+	}
+
+	return commands, nil
+}
+
+// runXcmd runs the command with the file path as its final argument and on
+// the standard input. The output is trimmed and joined into a single line
+func runXcmd(command []string, filePath string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	args := append(append([]string{}, command[1:]...), filePath)
+
+	cmd := exec.Command(command[0], args...)
+	cmd.Stdin = strings.NewReader(filePath + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf(
+			"Command '%s' failed for %s: %w: %s",
+			command[0],
+			filePath,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	return strings.Join(lines, " "), nil
+}
+
+// replaceXcmdVariables replaces each `{{xcmd.<name>}}` variable with the
+// output of the named command for the file. Each command is run only once
+// per file
+func (op *Operation) replaceXcmdVariables(
+	input, filePath string,
+) (string, error) {
+	var err error
+
+	outputs := make(map[string]string)
+
+	out := xcmdRegex.ReplaceAllStringFunc(input, func(v string) string {
+		name := xcmdRegex.FindStringSubmatch(v)[1]
+		if output, ok := outputs[name]; ok {
+			return output
+		}
+
+		command, ok := op.xcmds[name]
+		if !ok {
+			err = fmt.Errorf(
+				"Unknown command in %s: define it with --xcmd %s=<command>",
+				v,
+				name,
+			)
+
+			return v
+		}
+
+		output, cerr := runXcmd(command, filePath)
+		if cerr != nil {
+			err = cerr
+			return v
+		}
+
+		outputs[name] = output
+
+		return output
+	})
+
+	return out, err
+}
@@ -0,0 +1,64 @@
+package f2
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseXcmds(t *testing.T) {
+	got, err := parseXcmds([]string{"dur=ffprobe -v quiet", "sum=sha1sum"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Join(got["dur"], " ") != "ffprobe -v quiet" ||
+		strings.Join(got["sum"], " ") != "sha1sum" {
+		t.Fatalf("Unexpected commands: %v", got)
+	}
+
+	for _, v := range []string{"dur", "=ffprobe", "dur=", "d u r=ffprobe"} {
+		_, err = parseXcmds([]string{v})
+		if err != errInvalidXcmd {
+			t.Fatalf("Expected %q to be rejected, but got: %v", v, err)
+		}
+	}
+}
+
+func TestReplaceXcmdVariables(t *testing.T) {
+	if runtime.GOOS == windows {
+		t.Skip("echo and xargs are not executables on Windows")
+	}
+
+	op := &Operation{
+		xcmds: map[string][]string{
+			"label": {"echo", "length:"},
+			"stdin": {"xargs", "echo"},
+		},
+	}
+
+	got, err := op.replaceXcmdVariables(
+		"{{xcmd.label}} ({{xcmd.stdin}}) {{xcmd.label}}",
+		"clip.mp4",
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "length: clip.mp4 (clip.mp4 clip.mp4) length: clip.mp4"
+	if got != want {
+		t.Fatalf("Expected: %s, but got: %s", want, got)
+	}
+
+	_, err = op.replaceXcmdVariables("{{xcmd.unknown}}", "clip.mp4")
+	if err == nil {
+		t.Fatal("Expected an error for an undefined command")
+	}
+
+	op.xcmds["fail"] = []string{"false"}
+
+	_, err = op.replaceXcmdVariables("{{xcmd.fail}}", "clip.mp4")
+	if err == nil {
+		t.Fatal("Expected an error for a failing command")
+	}
+}